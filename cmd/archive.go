@@ -5,16 +5,20 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
-	"github.com/BurntSushi/toml"
+	"github.com/datakaicr/pk/pkg/backup"
 	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/lifecycle"
+	"github.com/datakaicr/pk/pkg/paths"
+	"github.com/datakaicr/pk/pkg/relations"
+	"github.com/datakaicr/pk/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 var archiveCmd = &cobra.Command{
-	Use:   "archive <name>",
-	Short: "Archive a project",
+	Use:   "archive <name>...",
+	Short: "Archive projects",
 	Long: `Move a project to the archive directory and update its status.
 
 This will:
@@ -23,24 +27,64 @@ This will:
   3. Set completion date to today
   4. Auto-sync shell aliases (if enabled)
 
-Example:
+With --compress the archived project also goes into cold storage (see
+'pk archive compact').
+
+` + filterHelp + `
+
+Examples:
   pk archive old-project
-  pk archive keplr-data-model`,
-	Args:              cobra.ExactArgs(1),
+  pk archive keplr-data-model
+  pk archive app api-gateway --compress
+  pk archive --filter 'client="Acme Corp" and status=completed'`,
 	Run:               runArchive,
 	ValidArgsFunction: validProjectNames,
 }
 
-var archiveAutoSync bool
+var archiveCompactCmd = &cobra.Command{
+	Use:   "compact [name]...",
+	Short: "Move archived projects into cold storage",
+	Long: `Pack archived projects into compressed bundles to save space.
+
+Ignored files (node_modules, build output, ...) are dropped. Everything
+else goes into ` + backup.ColdBundle + ` (a 'pk backup' bundle: the git history,
+local git config, hooks and info/, untracked, uncommitted and deleted
+files) inside the project directory, which keeps
+only its .project.toml next to the bundle. The project is still listed by
+'pk list archived' and shown by 'pk show'; 'pk restore' unpacks it, and
+'pk undo' reverses the compaction.
+
+Projects without git are packed whole. Repositories with submodules,
+linked worktrees, git LFS objects or more than one stash are refused.
+
+Without names or --filter, every archived project not yet compacted is
+selected.
+
+Examples:
+  pk archive compact
+  pk archive compact old-project
+  pk archive compact --filter 'completed<2024-01-01' --yes`,
+	Run:               runArchiveCompact,
+	ValidArgsFunction: validArchivedProjectNames,
+}
+
+var (
+	archiveAutoSync bool
+	archiveCompress bool
+	archiveFilter   string
+	archiveYes      bool
+)
 
 func init() {
 	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.AddCommand(archiveCompactCmd)
 	archiveCmd.Flags().BoolVar(&archiveAutoSync, "sync", true, "Auto-sync aliases after archiving")
+	archiveCmd.Flags().BoolVar(&archiveCompress, "compress", false, "Also move the project into cold storage (see 'pk archive compact')")
+	addBulkFlags(archiveCmd, &archiveFilter, &archiveYes)
+	addBulkFlags(archiveCompactCmd, &archiveFilter, &archiveYes)
 }
 
 func runArchive(cmd *cobra.Command, args []string) {
-	projectName := strings.ToLower(args[0])
-
 	homeDir, _ := os.UserHomeDir()
 	projectsDir := filepath.Join(homeDir, "projects")
 	archiveDir := filepath.Join(homeDir, "archive")
@@ -52,6 +96,18 @@ func runArchive(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if isBulk(args, archiveFilter) {
+		runArchiveBulk(cmd, args, projects, archiveDir)
+		return
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: give a project name or --filter\n")
+		os.Exit(1)
+	}
+
+	projectName := strings.ToLower(args[0])
+
 	var found *config.Project
 	for _, p := range projects {
 		if strings.ToLower(p.ProjectInfo.ID) == projectName ||
@@ -67,64 +123,220 @@ func runArchive(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Check if already exists in archive
-	destPath := filepath.Join(archiveDir, filepath.Base(found.Path))
-	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Project already exists in archive: %s\n", destPath)
-		os.Exit(1)
-	}
-
-	// Ensure archive directory exists
-	if err := os.MkdirAll(archiveDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create archive directory: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Move project
 	fmt.Printf("Moving project: %s\n", found.ProjectInfo.Name)
 	fmt.Printf("  From: %s\n", found.Path)
-	fmt.Printf("  To:   %s\n", destPath)
+	fmt.Printf("  To:   %s\n", filepath.Join(archiveDir, filepath.Base(found.Path)))
+	warnDependents([]*config.Project{found}, projects)
 
-	if err := os.Rename(found.Path, destPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to move project: %v\n", err)
+	tx := journal.Begin("archive", found.ProjectInfo.ID)
+	destPath, err := archiveProject(tx, found, archiveDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to archive project: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Update .project.toml
-	tomlPath := filepath.Join(destPath, ".project.toml")
-	if err := updateProjectToml(tomlPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to update .project.toml: %v\n", err)
-	} else {
-		fmt.Printf("\n\033[32m✓\033[0m Archived successfully\n")
-		fmt.Printf("  Status: \033[33marchived\033[0m\n")
-		fmt.Printf("  Location: %s\n", destPath)
+	fmt.Printf("\n\033[32m✓\033[0m Archived successfully\n")
+	fmt.Printf("  Status: \033[33marchived\033[0m\n")
+	fmt.Printf("  Location: %s\n", destPath)
+	if archiveCompress {
+		fmt.Printf("  Storage: compressed into %s\n", backup.ColdBundle)
 	}
+	fmt.Printf("  Undo with: pk undo (or later: pk restore %s)\n", found.ProjectInfo.ID)
 
 	// Auto-sync aliases
 	if archiveAutoSync {
 		fmt.Printf("\nSyncing aliases...\n")
 		runSync(cmd, []string{})
+		tx.Record(journal.Step{Kind: journal.StepSync})
+	}
+
+	commitJournal(tx)
+}
+
+// runArchiveBulk archives several projects as one journaled operation
+func runArchiveBulk(cmd *cobra.Command, names []string, projects []*config.Project, archiveDir string) {
+	selected := requireSelection(selectProjects(names, archiveFilter, projects))
+
+	describe := func(p *config.Project) string {
+		return fmt.Sprintf("%s → %s", p.Path, filepath.Join(archiveDir, filepath.Base(p.Path)))
+	}
+	warnDependents(selected, projects)
+	if !confirmPlan("Archive", selected, describe, archiveYes) {
+		return
+	}
+
+	tx := journal.Begin("archive", bulkCommand(names, archiveFilter)...)
+	var results bulkResults
+	for _, p := range selected {
+		destPath, err := archiveProject(tx, p, archiveDir)
+		results.report(p, err, "archived to "+destPath)
+	}
+
+	if archiveAutoSync && results.succeeded > 0 {
+		tx.Record(journal.Step{Kind: journal.StepSync})
 	}
+	commitJournal(tx)
+
+	if results.succeeded > 0 {
+		fmt.Println("\nUndo with: pk undo")
+	}
+	results.finish(cmd, archiveAutoSync)
 }
 
-func updateProjectToml(path string) error {
-	// Read current TOML
-	var project config.Project
-	if _, err := toml.DecodeFile(path, &project); err != nil {
-		return err
+// archiveProject moves p into archiveDir and marks it archived, journaling
+// each step in tx for 'pk undo'. A failed metadata update moves the project
+// back, leaving steps recorded earlier in tx alone. The caller commits tx.
+func archiveProject(tx *journal.Tx, p *config.Project, archiveDir string) (string, error) {
+	if err := checkNotSubproject(p); err != nil {
+		return "", err
+	}
+	if p.ProjectInfo.Status != "archived" {
+		if err := statusMachine().Check(p.ProjectInfo.Status, "archived"); err != nil {
+			return "", err
+		}
 	}
 
-	// Update status and completion date
-	project.ProjectInfo.Status = "archived"
-	project.Dates.Completed = time.Now().Format("2006-01-02")
+	savepoint := tx.Savepoint()
 
-	// Write back to file
-	f, err := os.Create(path)
+	destPath, err := lifecycle.MoveToArchive(p, archiveDir)
 	if err != nil {
-		return err
+		return "", err
+	}
+	tx.Record(journal.Step{Kind: journal.StepMove, From: p.Path, To: destPath})
+	tx.Record(journal.Step{Kind: journal.StepRelocate, ProjectID: p.ProjectInfo.ID, From: p.Path, To: destPath})
+
+	tomlPath := filepath.Join(destPath, ".project.toml")
+	if err := tx.Write(tomlPath, func() error { return lifecycle.MarkArchived(tomlPath) }); err != nil {
+		if rollbackErr := tx.RollbackTo(savepoint); rollbackErr != nil {
+			return "", fmt.Errorf("failed to update .project.toml: %w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return "", fmt.Errorf("failed to update .project.toml, move rolled back: %w", err)
 	}
-	defer f.Close()
 
-	encoder := toml.NewEncoder(f)
-	return encoder.Encode(&project)
+	if archiveCompress {
+		archived := *p
+		archived.Path = destPath
+		if _, _, err := compactProject(tx, &archived, archiveDir); err != nil {
+			if rollbackErr := tx.RollbackTo(savepoint); rollbackErr != nil {
+				return "", fmt.Errorf("failed to compress: %w (rollback incomplete: %v)", err, rollbackErr)
+			}
+			return "", fmt.Errorf("failed to compress, archive rolled back: %w", err)
+		}
+	}
+
+	return destPath, nil
+}
+
+func runArchiveCompact(cmd *cobra.Command, args []string) {
+	homeDir, _ := os.UserHomeDir()
+	archiveDir := filepath.Join(homeDir, "archive")
+
+	projects, err := config.FindProjects(archiveDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	var selected []*config.Project
+	if len(args) == 0 && archiveFilter == "" {
+		for _, p := range projects {
+			if !backup.IsCompacted(p.Path) {
+				selected = append(selected, p)
+			}
+		}
+		if len(selected) == 0 {
+			fmt.Println("Every archived project is already compacted")
+			return
+		}
+	} else {
+		selected = requireSelection(selectProjects(args, archiveFilter, projects))
+	}
+
+	describe := func(p *config.Project) string {
+		if backup.IsCompacted(p.Path) {
+			return "already compacted"
+		}
+		return p.Path
+	}
+	if !confirmPlan("Compact", selected, describe, archiveYes) {
+		return
+	}
+
+	tx := journal.Begin("archive compact", bulkCommand(args, archiveFilter)...)
+	var results bulkResults
+	var saved int64
+	for _, p := range selected {
+		before, after, err := compactProject(tx, p, archiveDir)
+		results.report(p, err, fmt.Sprintf("%s → %s", formatBytes(before), formatBytes(after)))
+		saved += before - after
+	}
+	commitJournal(tx)
+
+	if results.succeeded > 0 {
+		if saved > 0 {
+			fmt.Printf("\nFreed %s.", formatBytes(saved))
+		} else {
+			fmt.Printf("\nNo space freed, the projects were already small.")
+		}
+		fmt.Println(" Undo with: pk undo")
+	}
+	results.finish(cmd, false)
+}
+
+// compactProject moves an archived project into cold storage, journaling
+// it for 'pk undo'. Returns the directory size before and after.
+func compactProject(tx *journal.Tx, p *config.Project, archiveDir string) (int64, int64, error) {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		return 0, 0, err
+	}
+	roots := workspace.ResolverRoots(resolver)
+	roots[workspace.RootArchive] = archiveDir
+
+	before, after, err := backup.Compact(p, roots)
+	if err != nil {
+		return 0, 0, err
+	}
+	tx.Record(journal.Step{Kind: journal.StepCompact, ProjectID: p.ProjectInfo.ID, Path: p.Path})
+	return before, after, nil
+}
+
+// formatBytes renders a size like 1.5 MB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// warnDependents warns when projects about to be archived are still
+// depended on by projects in pool that stay active
+func warnDependents(archiving []*config.Project, pool []*config.Project) {
+	leaving := make(map[*config.Project]bool)
+	for _, p := range archiving {
+		leaving[p] = true
+	}
+
+	graph := relations.Build(pool)
+	for _, p := range archiving {
+		var dependents []string
+		for _, e := range graph.Inbound(p.ProjectInfo.ID) {
+			from := graph.Project(e.From)
+			if e.Kind != relations.DependsOn || from == nil || leaving[from] || from.ProjectInfo.Status == "archived" {
+				continue
+			}
+			dependents = append(dependents, from.ProjectInfo.ID)
+		}
+		if len(dependents) > 0 {
+			fmt.Fprintf(os.Stderr, "\033[33m⚠\033[0m Warning: %s is still a dependency of %s\n",
+				p.ProjectInfo.ID, strings.Join(dependents, ", "))
+		}
+	}
 }
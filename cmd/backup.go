@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <name>...",
+	Short: "Back up projects into self-describing bundles",
+	Long: `Write each project into one .tar.gz bundle that 'pk backup restore' can
+rebuild it from.
+
+A bundle holds:
+  - manifest.json: id, root and path, branch, remotes, pins, access record
+  - repo.bundle: a git bundle of every branch and tag
+  - git/: the repository's local config, hooks and info/
+  - files/: untracked (not ignored) and uncommitted files; every file for
+    projects without git. Tracked files deleted since the last commit are
+    listed in the manifest and deleted again on restore
+  - .project.toml, the project's activity and its shared work log
+
+Bundles go to ~/.local/share/pk/backups unless --out is given.
+
+Several names can be given at once, or --filter selects projects by
+field (see 'pk delete --help' for the syntax).
+
+Examples:
+  pk backup my-project
+  pk backup my-project --out /mnt/usb
+  pk backup --filter 'status=completed' --out ~/backups`,
+	Args:              cobra.ArbitraryArgs,
+	Run:               runBackup,
+	ValidArgsFunction: validProjectNames,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <bundle>",
+	Short: "Rebuild a project from a backup bundle",
+	Long: `Rebuild a project from a bundle written by 'pk backup'.
+
+The project goes back to its root and path (resolved with this machine's
+[paths] configuration) or to --to. Nothing is overwritten: the target must
+not exist, no project may already have the bundle's ID (rename or delete it
+first), pins return only to slots that are still free, and activity and
+work log entries are merged.
+
+Examples:
+  pk backup restore ~/.local/share/pk/backups/my-project-20250101-120000.tar.gz
+  pk backup restore my-project.tar.gz --to ~/projects/my-project-copy`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBackupRestore,
+}
+
+var (
+	backupOut       string
+	backupFilter    string
+	backupRestoreTo string
+	backupAutoSync  bool
+)
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	backupCmd.Flags().StringVar(&backupOut, "out", "", "Directory to write bundles to (default ~/.local/share/pk/backups)")
+	backupCmd.Flags().StringVar(&backupFilter, "filter", "", "Select projects by expression (e.g. 'client=Acme and status=completed')")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreTo, "to", "", "Restore to this directory instead of the original path")
+	backupRestoreCmd.Flags().BoolVar(&backupAutoSync, "sync", true, "Auto-sync aliases after restoring")
+}
+
+func runBackup(cmd *cobra.Command, args []string) {
+	roots, projects := workspaceProjects()
+	selected := requireSelection(selectProjects(args, backupFilter, projects))
+
+	outDir := backupOut
+	if outDir == "" {
+		dir, err := backup.GetBackupDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		outDir = dir
+	}
+
+	failed := 0
+	for _, p := range selected {
+		path, m, err := backup.Create(p, roots, outDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\033[31m✗\033[0m %s: %v\n", p.ProjectInfo.ID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("\033[32m✓\033[0m %s: %s (%s)\n", p.ProjectInfo.ID, path, describeBundle(m))
+	}
+
+	if len(selected) > 1 {
+		fmt.Printf("\nDone: %d backed up, %d failed\n", len(selected)-failed, failed)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// describeBundle summarizes what a bundle holds
+func describeBundle(m *backup.Manifest) string {
+	var parts []string
+	switch {
+	case m.Git && m.Branch != "":
+		parts = append(parts, "git on "+m.Branch)
+	case m.Git:
+		parts = append(parts, "git")
+	}
+	parts = append(parts, fmt.Sprintf("%d file(s)", m.Files))
+	if len(m.Pins) > 0 {
+		parts = append(parts, fmt.Sprintf("pinned %v", m.Pins))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) {
+	roots, projects := workspaceProjects()
+
+	var err error
+	to := backupRestoreTo
+	if to != "" {
+		if to, err = filepath.Abs(to); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	restored, err := backup.Restore(args[0], roots, to, projects)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to restore %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	m := restored.Manifest
+	fmt.Printf("\033[32m✓\033[0m Restored %s to %s\n", m.ProjectID, restored.Path)
+	fmt.Printf("  Backed up: %s (%s)\n", m.CreatedAt.Format("2006-01-02 15:04"), describeBundle(m))
+	for _, slot := range restored.Pins {
+		fmt.Printf("\033[32m✓\033[0m Pinned to slot %d\n", slot)
+	}
+	for _, slot := range restored.PinsSkipped {
+		fmt.Printf("\033[33m⚠\033[0m Slot %d is taken, not pinned (use 'pk pin add %s')\n", slot, m.ProjectID)
+	}
+
+	if backupAutoSync {
+		fmt.Printf("\nSyncing aliases...\n")
+		runSync(cmd, []string{})
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/filter"
+	"github.com/spf13/cobra"
+)
+
+// filterHelp documents --filter for commands that act on several projects
+const filterHelp = `Several names can be given at once, or --filter selects projects by
+field, e.g. --filter 'client="Acme Corp" and status=completed'.
+Fields: ` + "id, name, status, type, tag, owner, client, partner, role, billable,\n" +
+	`rate_type, deliverable, stack, domain, started, completed, path.
+Operators: = != ~ (contains) !~ < <= > >=, combined with and, or, not
+and parentheses. A plan is shown and confirmed once (skip with --yes).`
+
+// addBulkFlags registers --filter and --yes on a command
+func addBulkFlags(cmd *cobra.Command, filterExpr *string, yes *bool) {
+	cmd.Flags().StringVar(filterExpr, "filter", "", "Select projects by expression (e.g. 'client=Acme and status=completed')")
+	cmd.Flags().BoolVarP(yes, "yes", "y", false, "Don't ask for confirmation")
+}
+
+// isBulk reports whether a command should run in bulk mode
+func isBulk(names []string, filterExpr string) bool {
+	return filterExpr != "" || len(names) > 1
+}
+
+// selectProjects resolves project names and a --filter expression against pool
+// Every name must match a project; the result keeps pool order without duplicates.
+func selectProjects(names []string, filterExpr string, pool []*config.Project) ([]*config.Project, error) {
+	if len(names) == 0 && filterExpr == "" {
+		return nil, fmt.Errorf("give at least one project name or --filter")
+	}
+
+	chosen := make(map[*config.Project]bool)
+	for _, name := range names {
+		p := findProject(name, pool)
+		if p == nil {
+			return nil, fmt.Errorf("project '%s' not found", name)
+		}
+		chosen[p] = true
+	}
+
+	if filterExpr != "" {
+		expr, err := filter.Parse(filterExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --filter: %w", err)
+		}
+		for _, p := range filter.Select(pool, expr) {
+			chosen[p] = true
+		}
+	}
+
+	var selected []*config.Project
+	for _, p := range pool {
+		if chosen[p] {
+			selected = append(selected, p)
+		}
+	}
+	return selected, nil
+}
+
+// bulkCommand describes a bulk invocation for the journal, e.g.
+// ["a", "b", "--filter", "\"status=done\""]
+func bulkCommand(names []string, filterExpr string) []string {
+	args := append([]string{}, names...)
+	if filterExpr != "" {
+		args = append(args, "--filter", strconv.Quote(filterExpr))
+	}
+	return args
+}
+
+// confirmPlan prints what a bulk command will do and asks once unless yes
+func confirmPlan(action string, projects []*config.Project, describe func(*config.Project) string, yes bool) bool {
+	fmt.Printf("%s %d project(s):\n\n", action, len(projects))
+	for _, p := range projects {
+		fmt.Printf("  %-25s %s\n", p.ProjectInfo.ID, describe(p))
+	}
+
+	if yes {
+		fmt.Println()
+		return true
+	}
+
+	fmt.Print("\nContinue? (y/N): ")
+
+	var response string
+	fmt.Scanln(&response)
+
+	if strings.ToLower(response) != "y" {
+		fmt.Println("Cancelled")
+		return false
+	}
+	fmt.Println()
+	return true
+}
+
+// bulkResults counts per-project outcomes of a bulk command
+type bulkResults struct {
+	succeeded int
+	failed    int
+}
+
+// report prints one project's outcome
+func (r *bulkResults) report(p *config.Project, err error, done string) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31m✗\033[0m %s: %v\n", p.ProjectInfo.ID, err)
+		r.failed++
+		return
+	}
+	fmt.Printf("\033[32m✓\033[0m %s: %s\n", p.ProjectInfo.ID, done)
+	r.succeeded++
+}
+
+// finish invalidates the project cache, syncs aliases once if asked and
+// prints the summary. Exits non-zero when any project failed.
+func (r *bulkResults) finish(cmd *cobra.Command, sync bool) {
+	cache.InvalidateCache()
+
+	if sync && r.succeeded > 0 {
+		fmt.Println("\nSyncing aliases...")
+		runSync(cmd, []string{})
+	}
+
+	fmt.Printf("\nDone: %d succeeded, %d failed\n", r.succeeded, r.failed)
+	if r.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// requireSelection exits when a bulk selection is invalid or empty
+func requireSelection(projects []*config.Project, err error) []*config.Project {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(projects) == 0 {
+		fmt.Println("No projects match")
+		os.Exit(0)
+	}
+	return projects
+}
@@ -150,7 +150,7 @@ func createBasicProjectToml(path, projectName, repoURL string) error {
 [project]
 name = "%s"
 id = "%s"
-status = "active"
+status = "%s"
 type = "product"
 
 [ownership]
@@ -170,7 +170,7 @@ documentation = ""
 
 [notes]
 description = ""
-`, projectName, projectName, getCurrentDate(), repoURL)
+`, projectName, projectName, statusMachine().Initial(), getCurrentDate(), repoURL)
 
 	return os.WriteFile(path, []byte(content), 0644)
 }
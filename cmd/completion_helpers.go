@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/trash"
 	"github.com/spf13/cobra"
 )
 
@@ -26,17 +29,67 @@ func validProjectNames(cmd *cobra.Command, args []string, toComplete string) ([]
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
+	// Most frecent projects first
 	var names []string
-	for _, p := range projects {
+	for _, p := range rankByFrecency(config.WithSubprojects(projects)) {
 		// Add project ID
 		if strings.HasPrefix(p.ProjectInfo.ID, toComplete) {
 			names = append(names, p.ProjectInfo.ID)
 		}
 	}
 
+	return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// validArchivedProjectNames returns IDs of projects in ~/archive for completion
+func validArchivedProjectNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projects, err := cache.FindProjectsCached(filepath.Join(homeDir, "archive"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, p := range projects {
+		if strings.HasPrefix(p.ProjectInfo.ID, toComplete) {
+			names = append(names, p.ProjectInfo.ID)
+		}
+	}
+
 	return names, cobra.ShellCompDirectiveNoFileComp
 }
 
+// validTrashNames returns trash IDs and project names for completion
+func validTrashNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	items, err := trash.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, item := range items {
+		ref := item.ProjectID
+		if ref == "" {
+			ref = item.Name
+		}
+		for _, name := range []string{item.ID, ref} {
+			if name != "" && strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
 // validScratchNames returns list of scratch project names for completion
 func validScratchNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	homeDir, err := os.UserHomeDir()
@@ -69,42 +122,20 @@ func validScratchNames(cmd *cobra.Command, args []string, toComplete string) ([]
 
 // validAllProjectNames returns both regular projects and scratch projects
 func validAllProjectNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp
-	}
-
-	projectsDir := filepath.Join(homeDir, "projects")
-	archiveDir := filepath.Join(homeDir, "archive")
-	scriptoriumDir := filepath.Join(homeDir, "scriptorium")
-	scratchDir := filepath.Join(homeDir, "scratch")
-
-	// Get regular projects
-	projects, err := cache.FindProjectsCached(projectsDir, archiveDir, scriptoriumDir)
+	projects, err := loadAllProjects()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
+	// Most frecent projects first
 	var names []string
-	for _, p := range projects {
+	for _, p := range rankByFrecency(projects) {
 		if strings.HasPrefix(p.ProjectInfo.ID, toComplete) {
 			names = append(names, p.ProjectInfo.ID)
 		}
 	}
 
-	// Get scratch projects
-	if _, err := os.Stat(scratchDir); err == nil {
-		entries, err := os.ReadDir(scratchDir)
-		if err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() && strings.HasPrefix(entry.Name(), toComplete) {
-					names = append(names, entry.Name())
-				}
-			}
-		}
-	}
-
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
 }
 
 // validListFilters returns valid filter options for pk list
@@ -118,3 +149,59 @@ func validListFilters(cmd *cobra.Command, args []string, toComplete string) ([]s
 	}
 	return matches, cobra.ShellCompDirectiveNoFileComp
 }
+
+// validTagNames returns tags used by any project for completion
+func validTagNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var tags []string
+	for _, tag := range sortedTags(projectsByTag(projects)) {
+		if strings.HasPrefix(tag, toComplete) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, cobra.ShellCompDirectiveNoFileComp
+}
+
+// validSavedSessionNames returns names of saved session snapshots for completion
+func validSavedSessionNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	states, err := session.ListStates()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, state := range states {
+		if strings.HasPrefix(state.Name, toComplete) {
+			names = append(names, state.Name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// validActiveSessionNames returns running tmux session names for completion
+func validActiveSessionNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	sessions, _ := session.ListSessions()
+
+	var names []string
+	for _, name := range sessions {
+		if name != "" && strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
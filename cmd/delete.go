@@ -3,39 +3,52 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/datakaicr/pk/pkg/backup"
 	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/paths"
 	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/trash"
+	"github.com/datakaicr/pk/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 var (
-	deleteKeepGit bool
-	deleteForce   bool
+	deleteKeepGit   bool
+	deleteForce     bool
+	deletePermanent bool
+	deleteFilter    string
+	deleteYes       bool
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete <name>",
-	Short: "Delete a project",
-	Long: `Remove a project directory and all its contents.
+	Use:   "delete <name>...",
+	Short: "Delete projects",
+	Long: `Move a project to the pk trash.
 
 This will:
   1. Validate project exists
   2. Check for active tmux session and optionally kill it
-  3. Optionally archive git history (--keep-git)
-  4. Remove entire project directory
+  3. Optionally back it up first (--keep-git, see 'pk backup')
+  4. Move the project directory to ~/.local/share/pk/trash
   5. Auto-sync shell aliases
 
-WARNING: This operation is permanent. Data will be deleted.
+The trash keeps the original path, a copy of .project.toml and the tmux
+session layout, so 'pk trash restore <name>' puts everything back.
+With --permanent the directory is removed right away instead.
+
+` + filterHelp + `
+Active tmux sessions of the selected projects are killed.
 
 Example:
   pk delete old-project
   pk delete legacy-project --force         # Skip confirmation, auto-kill session
-  pk delete archived-proj --keep-git       # Save git history first`,
-	Args:              cobra.ExactArgs(1),
+  pk delete archived-proj --keep-git       # Write a pk backup first
+  pk delete scrap --permanent              # Skip the trash
+  pk delete --filter 'status=archived and completed<2024-01-01'`,
 	Run:               runDelete,
 	ValidArgsFunction: validProjectNames,
 }
@@ -43,14 +56,15 @@ Example:
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.Flags().BoolVar(&deleteKeepGit, "keep-git", false,
-		"Archive git history before deletion")
+		"Write a pk backup (git history and uncommitted files) before deletion")
 	deleteCmd.Flags().BoolVar(&deleteForce, "force", false,
 		"Skip confirmation prompt")
+	deleteCmd.Flags().BoolVar(&deletePermanent, "permanent", false,
+		"Delete immediately instead of moving to the trash")
+	addBulkFlags(deleteCmd, &deleteFilter, &deleteYes)
 }
 
 func runDelete(cmd *cobra.Command, args []string) {
-	projectName := strings.ToLower(args[0])
-
 	// Find project
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -67,6 +81,18 @@ func runDelete(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if isBulk(args, deleteFilter) {
+		runDeleteBulk(cmd, args, projects)
+		return
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: give a project name or --filter\n")
+		os.Exit(1)
+	}
+
+	projectName := strings.ToLower(args[0])
+
 	var found *config.Project
 	for _, p := range projects {
 		if strings.ToLower(p.ProjectInfo.ID) == projectName ||
@@ -88,7 +114,11 @@ func runDelete(cmd *cobra.Command, args []string) {
 
 	// Show confirmation prompt
 	if !deleteForce {
-		fmt.Printf("\033[33mWARNING: This will permanently delete the project.\033[0m\n\n")
+		if deletePermanent {
+			fmt.Printf("\033[33mWARNING: This will permanently delete the project.\033[0m\n\n")
+		} else {
+			fmt.Printf("This will move the project to the trash.\n\n")
+		}
 		fmt.Printf("Project:  %s\n", found.ProjectInfo.Name)
 		fmt.Printf("Location: %s\n", found.Path)
 		fmt.Printf("Status:   %s\n", found.ProjectInfo.Status)
@@ -108,6 +138,12 @@ func runDelete(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Snapshot the session layout so restoring from the trash can rebuild it
+	var sessionState *session.SessionState
+	if hasSession {
+		sessionState, _ = session.CaptureSession(sessionName)
+	}
+
 	// Kill tmux session if it exists
 	if hasSession {
 		if !deleteForce {
@@ -115,7 +151,7 @@ func runDelete(cmd *cobra.Command, args []string) {
 			var response string
 			fmt.Scanln(&response)
 			if strings.ToLower(response) == "y" {
-				if err := session.KillSession(sessionName); err != nil {
+				if err := session.KillProjectSession(found); err != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to kill tmux session: %v\n", err)
 				} else {
 					fmt.Printf("\033[32m✓\033[0m Tmux session killed\n")
@@ -125,7 +161,7 @@ func runDelete(cmd *cobra.Command, args []string) {
 			}
 		} else {
 			// Force flag: auto-kill session
-			if err := session.KillSession(sessionName); err != nil {
+			if err := session.KillProjectSession(found); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to kill tmux session: %v\n", err)
 			} else {
 				fmt.Printf("\033[32m✓\033[0m Tmux session killed\n")
@@ -133,46 +169,148 @@ func runDelete(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Archive git history if requested
+	// Back up if requested
 	if deleteKeepGit {
-		gitDir := filepath.Join(found.Path, ".git")
-		if _, err := os.Stat(gitDir); err == nil {
-			archiveName := filepath.Base(found.Path) + ".git-archive.tar.gz"
-			archivePath := filepath.Join(filepath.Dir(found.Path), archiveName)
-
-			fmt.Printf("Archiving git history to: %s\n", archivePath)
-
-			tarCmd := exec.Command("tar", "czf", archivePath, "-C", found.Path, ".git")
-			if err := tarCmd.Run(); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to archive git history: %v\n", err)
-				fmt.Print("Continue with deletion? (y/N): ")
+		if err := keepBackup(found); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to back up project: %v\n", err)
+			fmt.Print("Continue with deletion? (y/N): ")
 
-				var response string
-				fmt.Scanln(&response)
+			var response string
+			fmt.Scanln(&response)
 
-				if strings.ToLower(response) != "y" {
-					fmt.Println("Cancelled")
-					return
-				}
-			} else {
-				fmt.Printf("\033[32m✓\033[0m Git history archived\n")
+			if strings.ToLower(response) != "y" {
+				fmt.Println("Cancelled")
+				return
 			}
-		} else {
-			fmt.Println("No git repository found, skipping archive")
 		}
 	}
 
-	// Delete project directory
-	if err := os.RemoveAll(found.Path); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to delete project: %v\n", err)
+	tx := journal.Begin("delete", found.ProjectInfo.ID)
+
+	done, err := discardProject(tx, found, sessionState, deletePermanent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !deletePermanent {
+			fmt.Fprintf(os.Stderr, "Use --permanent to delete without the trash.\n")
+		}
 		os.Exit(1)
 	}
-
-	fmt.Printf("\033[32m✓\033[0m Deleted: %s\n", found.Path)
+	fmt.Printf("\033[32m✓\033[0m %s\n", done)
 
 	// Sync aliases
 	fmt.Println("Syncing aliases...")
 	runSync(cmd, []string{})
+	if !deletePermanent {
+		// A permanent delete can't be undone, so only trashing is journaled
+		tx.Record(journal.Step{Kind: journal.StepSync})
+		commitJournal(tx)
+	}
 
 	fmt.Printf("\n\033[32m✓\033[0m Project '%s' deleted successfully\n", found.ProjectInfo.Name)
+	if !deletePermanent {
+		fmt.Printf("Undo with: pk undo (or later: pk trash restore %s)\n", found.ProjectInfo.ID)
+	}
+}
+
+// runDeleteBulk trashes (or with --permanent removes) several projects,
+// killing their sessions, and journals the trashing as one operation
+func runDeleteBulk(cmd *cobra.Command, names []string, projects []*config.Project) {
+	selected := requireSelection(selectProjects(names, deleteFilter, projects))
+
+	action := "Move to trash"
+	if deletePermanent {
+		fmt.Printf("\033[33mWARNING: This will permanently delete the projects.\033[0m\n\n")
+		action = "Permanently delete"
+	}
+	describe := func(p *config.Project) string {
+		desc := fmt.Sprintf("%-10s %s", p.ProjectInfo.Status, p.Path)
+		if session.SessionExists(session.SanitizeSessionName(p.ProjectInfo.ID)) {
+			desc += "  \033[33m● session will be killed\033[0m"
+		}
+		return desc
+	}
+	if !confirmPlan(action, selected, describe, deleteYes || deleteForce) {
+		return
+	}
+
+	tx := journal.Begin("delete", bulkCommand(names, deleteFilter)...)
+	var results bulkResults
+	for _, p := range selected {
+		done, err := deleteProject(tx, p)
+		results.report(p, err, done)
+	}
+
+	if !deletePermanent && results.succeeded > 0 {
+		tx.Record(journal.Step{Kind: journal.StepSync})
+		commitJournal(tx)
+		fmt.Println("\nUndo with: pk undo (or later: pk trash restore <name>)")
+	}
+	results.finish(cmd, true)
+}
+
+// deleteProject kills a project's session and discards it without prompting
+func deleteProject(tx *journal.Tx, p *config.Project) (string, error) {
+	sessionName := session.SanitizeSessionName(p.ProjectInfo.ID)
+
+	var sessionState *session.SessionState
+	if session.SessionExists(sessionName) {
+		sessionState, _ = session.CaptureSession(sessionName)
+		if err := session.KillProjectSession(p); err != nil {
+			return "", fmt.Errorf("failed to kill tmux session: %w", err)
+		}
+	}
+
+	if deleteKeepGit {
+		if err := keepBackup(p); err != nil {
+			return "", fmt.Errorf("failed to back up, not deleted: %w", err)
+		}
+	}
+
+	return discardProject(tx, p, sessionState, deletePermanent)
+}
+
+// keepBackup writes a pk backup of p before it is deleted
+func keepBackup(p *config.Project) error {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		return err
+	}
+	outDir, err := backup.GetBackupDir()
+	if err != nil {
+		return err
+	}
+
+	path, _, err := backup.Create(p, workspace.ResolverRoots(resolver), outDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\033[32m✓\033[0m Backed up to: %s\n", path)
+	fmt.Printf("  Rebuild with: pk backup restore %s\n", path)
+	return nil
+}
+
+// discardProject moves p to the trash, recording it in tx, or removes it
+// for good when permanent. Returns what was done.
+func discardProject(tx *journal.Tx, p *config.Project, sessionState *session.SessionState, permanent bool) (string, error) {
+	if permanent {
+		if err := os.RemoveAll(p.Path); err != nil {
+			return "", fmt.Errorf("failed to delete project: %w", err)
+		}
+		return "Deleted: " + p.Path, nil
+	}
+
+	item, err := trash.Move(trash.Item{
+		Kind:         trash.KindProject,
+		ProjectID:    p.ProjectInfo.ID,
+		Name:         p.ProjectInfo.Name,
+		OriginalPath: p.Path,
+		Session:      sessionState,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tx.Record(journal.Step{Kind: journal.StepTrash, TrashID: item.ID, From: p.Path})
+	return "Moved to trash: " + item.ID, nil
 }
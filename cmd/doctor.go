@@ -5,9 +5,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
 	"github.com/datakaicr/pk/pkg/paths"
+	"github.com/datakaicr/pk/pkg/relations"
+	"github.com/datakaicr/pk/pkg/status"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +27,9 @@ This command performs health checks on:
   - Cache file integrity
   - Stale path detection
   - Config file validity
+  - Project statuses (known status, matching location and history)
+  - Project relations (targets exist, no dependency cycles)
+  - Subprojects (valid names, directories inside the project)
 
 Example:
   pk doctor`,
@@ -56,7 +63,7 @@ func runDoctor(cmd *cobra.Command, args []string) {
 	// Check 2: Dependencies
 	fmt.Println("🔧 Checking dependencies...")
 	checkCommand("tmux", "Required for 'pk session' and tmux keybindings", &issues)
-	checkCommand("fzf", "Required for interactive project selection", &issues)
+	checkOptionalCommand("fzf", "Optional: the builtin picker is used without it")
 	fmt.Println()
 
 	// Check 3: Tmux configuration
@@ -79,6 +86,21 @@ func runDoctor(cmd *cobra.Command, args []string) {
 	checkStalePaths(&issues)
 	fmt.Println()
 
+	// Check 7: Project statuses
+	fmt.Println("📋 Checking project statuses...")
+	checkProjectStatuses(&issues)
+	fmt.Println()
+
+	// Check 8: Project relations
+	fmt.Println("🔗 Checking project relations...")
+	checkProjectRelations(&issues)
+	fmt.Println()
+
+	// Check 9: Subprojects
+	fmt.Println("📦 Checking subprojects...")
+	checkSubprojects(&issues)
+	fmt.Println()
+
 	// Summary
 	fmt.Println("════════════════════════════════════════")
 	if issues == 0 {
@@ -109,6 +131,15 @@ func checkCommand(name, description string, issues *int) {
 	}
 }
 
+// checkOptionalCommand reports a missing command without counting it as an issue
+func checkOptionalCommand(name, description string) {
+	if _, err := exec.LookPath(name); err == nil {
+		fmt.Printf("   ✓ %s installed\n", name)
+	} else {
+		fmt.Printf("   ⚠ %s not found - %s\n", name, description)
+	}
+}
+
 func checkTmuxConfig(issues *int) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -255,6 +286,120 @@ func checkStalePaths(issues *int) {
 	}
 }
 
+func checkProjectStatuses(issues *int) {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		fmt.Printf("   ❌ Cannot check statuses: %v\n", err)
+		*issues++
+		return
+	}
+
+	m, err := resolver.StatusMachine()
+	if err != nil {
+		fmt.Printf("   ❌ Invalid [[status]] config: %v\n", err)
+		fmt.Printf("      Checking against the default statuses instead\n")
+		*issues++
+		m = status.Default()
+	}
+
+	projects, err := config.FindProjects(resolver.Projects(), resolver.Archive())
+	if err != nil {
+		fmt.Printf("   ❌ Cannot load projects: %v\n", err)
+		*issues++
+		return
+	}
+
+	problems := 0
+	for _, p := range projects {
+		id, current := p.ProjectInfo.ID, p.ProjectInfo.Status
+		state, known := m.State(current)
+		inArchive := isUnder(p.Path, resolver.Archive())
+
+		switch {
+		case !known:
+			fmt.Printf("   ⚠️  %s: unknown status '%s'\n", id, current)
+			fmt.Printf("      Fix: pk status %s <%s>\n", id, strings.Join(m.Names(), "|"))
+		case state.Has(status.EffectArchive) && !inArchive:
+			fmt.Printf("   ⚠️  %s: status '%s' but not in the archive directory\n", id, current)
+			fmt.Printf("      Fix: pk archive %s\n", id)
+		case state.Has(status.EffectUnarchive) && inArchive:
+			fmt.Printf("   ⚠️  %s: status '%s' but still in the archive directory\n", id, current)
+			fmt.Printf("      Fix: pk restore %s --status %s\n", id, current)
+		case len(p.StatusHistory) > 0 && p.StatusHistory[len(p.StatusHistory)-1].To != current:
+			last := p.StatusHistory[len(p.StatusHistory)-1].To
+			fmt.Printf("   ⚠️  %s: status '%s' was edited by hand (history ends at '%s')\n", id, current, last)
+			fmt.Printf("      Use 'pk status %s <status>' so changes are recorded\n", id)
+		default:
+			continue
+		}
+		problems++
+	}
+
+	if problems == 0 {
+		fmt.Printf("   ✓ All %d project statuses are valid\n", len(projects))
+	}
+	*issues += problems
+}
+
+func checkProjectRelations(issues *int) {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		fmt.Printf("   ❌ Cannot check relations: %v\n", err)
+		*issues++
+		return
+	}
+
+	projects, err := config.FindProjects(resolver.Projects(), resolver.Archive())
+	if err != nil {
+		fmt.Printf("   ❌ Cannot load projects: %v\n", err)
+		*issues++
+		return
+	}
+
+	graph := relations.Build(projects)
+	problems := graph.Check()
+	for _, problem := range problems {
+		fmt.Printf("   ⚠️  %v\n", problem)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("      Fix with 'pk set' (e.g. pk set <name> depends_on-=<id>) or 'pk edit'\n")
+	} else {
+		fmt.Printf("   ✓ All %d relations point at known projects\n", len(graph.Edges))
+	}
+	*issues += len(problems)
+}
+
+func checkSubprojects(issues *int) {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		fmt.Printf("   ❌ Cannot check subprojects: %v\n", err)
+		*issues++
+		return
+	}
+
+	projects, err := config.FindProjects(resolver.Projects(), resolver.Archive())
+	if err != nil {
+		fmt.Printf("   ❌ Cannot load projects: %v\n", err)
+		*issues++
+		return
+	}
+
+	count, problems := 0, 0
+	for _, p := range projects {
+		count += len(p.Subprojects)
+		for _, err := range p.CheckSubprojects() {
+			fmt.Printf("   ⚠️  %s: %v\n", p.ProjectInfo.ID, err)
+			problems++
+		}
+	}
+	if problems > 0 {
+		fmt.Printf("      Fix the [[subprojects]] entries with 'pk edit'\n")
+	} else {
+		fmt.Printf("   ✓ All %d subprojects are valid\n", count)
+	}
+	*issues += problems
+}
+
 func containsString(haystack, needle string) bool {
 	return len(haystack) >= len(needle) &&
 		   (haystack == needle ||
@@ -76,15 +76,7 @@ func runEdit(cmd *cobra.Command, args []string) {
 	// Store original ID to detect changes
 	originalID := found.ProjectInfo.ID
 
-	// Determine editor
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vim"
-		// Check if vim exists, fallback to nano
-		if _, err := exec.LookPath("vim"); err != nil {
-			editor = "nano"
-		}
-	}
+	editor := editorCommand()
 
 	fmt.Printf("Opening %s in %s...\n", tomlPath, editor)
 
@@ -120,3 +112,16 @@ func runEdit(cmd *cobra.Command, args []string) {
 	fmt.Printf("Status:  %s\n", project.ProjectInfo.Status)
 	fmt.Printf("Type:    %s\n", project.ProjectInfo.Type)
 }
+
+// editorCommand returns $EDITOR, falling back to vim or nano
+func editorCommand() string {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vim"
+		// Check if vim exists, fallback to nano
+		if _, err := exec.LookPath("vim"); err != nil {
+			editor = "nano"
+		}
+	}
+	return editor
+}
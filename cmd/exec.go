@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [name...] [--tag t] [--filter expr] -- <command> [args...]",
+	Short: "Run a command in several projects",
+	Long: `Run a command in the directory of each selected project, one after
+the other. Projects are picked by name, --tag (repeatable, all must
+match) and --filter; with names or --filter, --tag narrows them down.
+
+A single command argument runs through 'sh -c', so pipes and globs work
+when quoted; several arguments run the program directly. PK_PROJECT and
+PK_PROJECT_PATH are set for the command. Every project is visited even
+when one fails, and pk exits non-zero if any did.
+
+Examples:
+  pk exec --tag needs-upgrade -- git status --short
+  pk exec app api-gateway -- make test
+  pk exec --filter 'stack=go and status=active' -- 'go mod tidy && git diff --stat'`,
+	Args:              cobra.MinimumNArgs(1),
+	Run:               runExec,
+	ValidArgsFunction: validProjectNames,
+}
+
+var (
+	execTags   []string
+	execFilter string
+)
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().StringSliceVarP(&execTags, "tag", "t", nil, "Only projects with this tag (repeatable)")
+	execCmd.Flags().StringVar(&execFilter, "filter", "", "Select projects by expression (e.g. 'client=Acme and status=active')")
+	execCmd.RegisterFlagCompletionFunc("tag", validTagNames)
+}
+
+func runExec(cmd *cobra.Command, args []string) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 || dash == len(args) {
+		fmt.Fprintf(os.Stderr, "Error: Give the command after --, e.g. pk exec --tag web -- git pull\n")
+		os.Exit(1)
+	}
+	names, command := args[:dash], args[dash:]
+
+	if len(names) == 0 && execFilter == "" && len(execTags) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: Select projects by name, --tag or --filter\n")
+		os.Exit(1)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	selected := projects
+	if len(names) > 0 || execFilter != "" {
+		selected, err = selectProjects(names, execFilter, projects)
+	}
+	selected = requireSelection(filterByTags(selected, execTags), err)
+
+	var results bulkResults
+	for i, p := range selected {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("\033[1;34m==> %s\033[0m \033[90m(%s)\033[0m\n", p.ProjectInfo.ID, p.Path)
+
+		var c *exec.Cmd
+		if len(command) == 1 {
+			c = exec.Command("sh", "-c", command[0])
+		} else {
+			c = exec.Command(command[0], command[1:]...)
+		}
+		c.Dir = p.Path
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = append(os.Environ(), "PK_PROJECT="+p.ProjectInfo.ID, "PK_PROJECT_PATH="+p.Path)
+
+		if err := c.Run(); err != nil {
+			results.report(p, err, "")
+			continue
+		}
+		results.succeeded++
+	}
+
+	results.finish(cmd, false)
+}
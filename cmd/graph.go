@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/relations"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the project relationship graph",
+	Long: `Print how projects relate to each other, from the [relations] table
+of each .project.toml:
+
+  [relations]
+  depends_on = ["api", "shared-lib"]   # Needs these to build or run
+  deploys = ["web"]                    # Ships these (e.g. an infra repo)
+  fork_of = "upstream-app"             # Forked from
+  part_of = "platform"                 # Parent program or monorepo
+
+Formats:
+  dot      Graphviz (default), e.g. pk graph | dot -Tsvg > graph.svg
+  mermaid  Mermaid flowchart, for Markdown docs
+  json     {"nodes": [...], "edges": [...]}
+
+With --root, only projects connected to it are shown: everything it
+depends on, deploys or belongs to (transitively), and everything that
+leads to it. Archived projects are grayed out; relations to unknown
+projects are drawn as missing and reported on stderr.
+
+Examples:
+  pk graph
+  pk graph --root api --format mermaid
+  pk graph --format json | jq '.edges[] | select(.kind == "depends_on")'`,
+	Args: cobra.NoArgs,
+	Run:  runGraph,
+}
+
+var (
+	graphFormat string
+	graphRoot   string
+)
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Output format: dot, mermaid or json")
+	graphCmd.Flags().StringVar(&graphRoot, "root", "", "Only show projects connected to this one")
+	graphCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{"dot", "mermaid", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	graphCmd.RegisterFlagCompletionFunc("root", validProjectNames)
+}
+
+func runGraph(cmd *cobra.Command, args []string) {
+	homeDir, _ := os.UserHomeDir()
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	g := relations.Build(projects)
+	for _, err := range g.Check() {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if graphRoot != "" {
+		if g.Project(graphRoot) == nil {
+			fmt.Fprintf(os.Stderr, "Error: Project '%s' not found\n", graphRoot)
+			os.Exit(1)
+		}
+		g = g.Around(graphRoot)
+	}
+
+	switch strings.ToLower(graphFormat) {
+	case "dot":
+		fmt.Print(g.DOT())
+	case "mermaid":
+		fmt.Print(g.Mermaid())
+	case "json":
+		data, err := g.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown format '%s' (use dot, mermaid or json)\n", graphFormat)
+		os.Exit(1)
+	}
+}
@@ -27,7 +27,7 @@ Requires sudo permissions for binary and man page installation.
 
 Core commands work without dependencies.
 Optional features:
-  - tmux session management: requires tmux and fzf
+  - tmux session management: requires tmux (fzf optional)
   - Context switching: requires cloud CLIs (aws, az, gcloud, etc.)
 
 Example:
@@ -144,7 +144,7 @@ func runInstall(cmd *cobra.Command, args []string) {
 	// 5. Check optional dependencies
 	fmt.Println("5. Checking optional dependencies...")
 	checkDependency("tmux", "Required for 'pk session'")
-	checkDependency("fzf", "Required for interactive 'pk session'")
+	checkDependency("fzf", "Optional for 'pk session' (builtin picker is used without it)")
 	fmt.Println()
 
 	// Success message
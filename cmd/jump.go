@@ -66,6 +66,12 @@ func runJump(cmd *cobra.Command, args []string) {
 	projectToml := pin.ProjectPath + "/.project.toml"
 	project, err := config.LoadProject(projectToml)
 	if err != nil {
+		// Subprojects have no .project.toml of their own
+		if projects, loadErr := loadAllProjects(); loadErr == nil {
+			project = findProject(pin.ProjectID, projects)
+		}
+	}
+	if project == nil {
 		// Create a basic project structure if .project.toml doesn't exist
 		project = &config.Project{
 			Path: pin.ProjectPath,
@@ -76,7 +82,7 @@ func runJump(cmd *cobra.Command, args []string) {
 	}
 
 	// Record access
-	cache.RecordAccess(pin.ProjectID, pin.ProjectPath)
+	recordVisit(project, "jump")
 
 	// Switch context if configured
 	context.Switch(project)
@@ -23,16 +23,23 @@ Filters:
   product     - Product projects
   client      - Client projects
 
+--tag keeps projects carrying a tag; repeat it to require several.
+
 Examples:
   pk list              # All projects
   pk list active       # Active projects only
-  pk list datakai      # DataKai projects only`,
+  pk list datakai      # DataKai projects only
+  pk list active --tag q4-focus`,
 	Run:               runList,
 	ValidArgsFunction: validListFilters,
 }
 
+var listTags []string
+
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "Only projects with this tag (repeatable)")
+	listCmd.RegisterFlagCompletionFunc("tag", validTagNames)
 }
 
 func runList(cmd *cobra.Command, args []string) {
@@ -52,6 +59,7 @@ func runList(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "Error finding projects: %v\n", err)
 		os.Exit(1)
 	}
+	projects = config.WithSubprojects(projects)
 
 	if len(projects) == 0 {
 		fmt.Println("No projects found")
@@ -59,7 +67,7 @@ func runList(cmd *cobra.Command, args []string) {
 	}
 
 	// Apply filter
-	filtered := filterProjects(projects, filter)
+	filtered := filterByTags(filterProjects(projects, filter), listTags)
 
 	// Print header
 	fmt.Printf("\n=== Projects (%s) ===\n\n", getFilterLabel(filter))
@@ -110,10 +118,14 @@ func filterProjects(projects []*config.Project, filter string) []*config.Project
 }
 
 func getFilterLabel(filter string) string {
-	if filter == "" {
-		return "all"
+	label := filter
+	if label == "" {
+		label = "all"
+	}
+	for _, tag := range listTags {
+		label += ", #" + config.NormalizeTag(tag)
 	}
-	return filter
+	return label
 }
 
 func printProject(p *config.Project) {
@@ -129,21 +141,17 @@ func printProject(p *config.Project) {
 		p.ProjectInfo.Type,
 		p.GetOwner())
 
+	if len(p.ProjectInfo.Tags) > 0 {
+		fmt.Printf("  Tags: \033[36m%s\033[0m\n", strings.Join(p.ProjectInfo.Tags, ", "))
+	}
+
 	// Path
 	fmt.Printf("  Path: %s\n", p.Path)
 
 	fmt.Println()
 }
 
+// getStatusColor returns the ANSI color of a status from the status machine
 func getStatusColor(status string) string {
-	switch status {
-	case "active":
-		return "\033[32m" // Green
-	case "archived":
-		return "\033[33m" // Yellow
-	case "paused":
-		return "\033[36m" // Cyan
-	default:
-		return ""
-	}
+	return statusMachine().Color(status)
 }
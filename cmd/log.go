@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/worklog"
+	"github.com/spf13/cobra"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log <project> <duration> <note...>",
+	Short: "Log work done outside tmux",
+	Long: `Append a manual work entry to a project's work log.
+
+The text starts with a duration (2h, 45m, 1h30m, 1.5h or 1:30); the rest
+is the note. Words starting with # become tags, as does every --tag.
+
+Entries are stored in ~/.local/share/pk/logs/<id>.jsonl, or in the project
+under .dev/worklog.jsonl once that file exists (--in-project creates it).
+Logged hours are added to 'pk time report' and 'pk report billing'.
+
+Examples:
+  pk log api "2h reviewed PR #review"
+  pk log api 45m client call --tag meeting
+  pk log api 1:30 design notes --date yesterday
+  pk log list api --from 2026-09-01`,
+	Args:              cobra.MinimumNArgs(2),
+	Run:               runLog,
+	ValidArgsFunction: validLogArgs,
+}
+
+var logListCmd = &cobra.Command{
+	Use:   "list [project]",
+	Short: "List work log entries",
+	Long: `List manual work log entries, optionally for one project.
+
+Examples:
+  pk log list
+  pk log list api --from 2026-09-01 --to 2026-09-30
+  pk log list --tag meeting --format json`,
+	Args:              cobra.MaximumNArgs(1),
+	Run:               runLogList,
+	ValidArgsFunction: validAllProjectNames,
+}
+
+var (
+	logDate      string
+	logTags      []string
+	logInProject bool
+	logFrom      string
+	logTo        string
+	logTag       string
+	logFormat    string
+)
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.AddCommand(logListCmd)
+
+	logCmd.Flags().StringVar(&logDate, "date", "today", "Day the work happened (YYYY-MM-DD, today, yesterday)")
+	logCmd.Flags().StringSliceVarP(&logTags, "tag", "t", nil, "Tag the entry (repeatable)")
+	logCmd.Flags().BoolVar(&logInProject, "in-project", false, "Store the log in the project under .dev/worklog.jsonl")
+
+	logListCmd.Flags().StringVar(&logFrom, "from", "", "First day to include (YYYY-MM-DD)")
+	logListCmd.Flags().StringVar(&logTo, "to", "", "Last day to include (YYYY-MM-DD)")
+	logListCmd.Flags().StringVar(&logTag, "tag", "", "Only entries with this tag")
+	logListCmd.Flags().StringVar(&logFormat, "format", "table", "Output format: table or json")
+}
+
+func runLog(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	project := findProject(args[0], projects)
+	if project == nil {
+		fmt.Fprintf(os.Stderr, "Error: Project '%s' not found\n", args[0])
+		os.Exit(1)
+	}
+
+	minutes, note, tags, err := worklog.ParseEntry(strings.Join(args[1:], " "))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	date, err := worklog.ParseDate(logDate, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logFile, err := worklog.LogFile(project)
+	if logInProject {
+		logFile, err = worklog.ProjectLogFile(project), nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	entry := worklog.Entry{
+		ProjectID: project.ProjectInfo.ID,
+		Date:      date,
+		Minutes:   minutes,
+		Note:      note,
+		Tags:      worklog.MergeTags(tags, logTags),
+		LoggedAt:  time.Now(),
+	}
+	if err := worklog.Append(logFile, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to write work log: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\033[32m✓\033[0m Logged %s to %s on %s\n", formatMinutes(minutes), project.ProjectInfo.ID, date)
+}
+
+func runLogList(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		project := findProject(args[0], projects)
+		if project == nil {
+			fmt.Fprintf(os.Stderr, "Error: Project '%s' not found\n", args[0])
+			os.Exit(1)
+		}
+		projects = []*config.Project{project}
+	}
+
+	for _, value := range []string{logFrom, logTo} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(worklog.DateFormat, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid date '%s' (use YYYY-MM-DD)\n", value)
+			os.Exit(1)
+		}
+	}
+
+	var entries []worklog.Entry
+	for _, entry := range loadWorkLogs(projects) {
+		if logFrom != "" && entry.Date < logFrom || logTo != "" && entry.Date > logTo {
+			continue
+		}
+		if logTag != "" && !entry.HasTag(logTag) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	switch logFormat {
+	case "table":
+		printWorkLog(entries)
+	case "json":
+		if entries == nil {
+			entries = []worklog.Entry{}
+		}
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --format must be table or json\n")
+		os.Exit(1)
+	}
+}
+
+// loadWorkLogs returns the work log entries of projects, oldest day first
+func loadWorkLogs(projects []*config.Project) []worklog.Entry {
+	var entries []worklog.Entry
+	for _, p := range projects {
+		projectEntries, err := worklog.Load(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to read work log of %s: %v\n", p.ProjectInfo.ID, err)
+			continue
+		}
+		entries = append(entries, projectEntries...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		return entries[i].LoggedAt.Before(entries[j].LoggedAt)
+	})
+	return entries
+}
+
+func printWorkLog(entries []worklog.Entry) {
+	if len(entries) == 0 {
+		fmt.Println("No work log entries")
+		fmt.Println("\nLog work with: pk log <project> \"2h what you did\"")
+		return
+	}
+
+	total := 0
+	for _, entry := range entries {
+		tags := ""
+		if len(entry.Tags) > 0 {
+			tags = "  \033[36m" + strings.Join(entry.Tags, ", ") + "\033[0m"
+		}
+		fmt.Printf("%s  %-20s %7s  %s%s\n", entry.Date, entry.ProjectID, formatMinutes(entry.Minutes), entry.Note, tags)
+		total += entry.Minutes
+	}
+	fmt.Printf("\n%d entries, %s total\n", len(entries), formatMinutes(total))
+}
+
+// formatMinutes renders minutes as e.g. "1h30m", "2h" or "45m"
+func formatMinutes(minutes int) string {
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// validLogArgs completes the project for 'pk log <project>'
+func validLogArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return validAllProjectNames(cmd, args, toComplete)
+}
@@ -125,7 +125,7 @@ func createProjectToml(path, name, projectPath string) error {
 	// Core fields
 	project.ProjectInfo.Name = name
 	project.ProjectInfo.ID = name
-	project.ProjectInfo.Status = "active"
+	project.ProjectInfo.Status = statusMachine().Initial()
 	project.ProjectInfo.Type = newType
 	project.Tech.Stack = []string{}
 	project.Tech.Domain = []string{}
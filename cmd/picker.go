@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/picker"
+)
+
+// pickerMode selects the interactive picker: auto | fzf | builtin
+var pickerMode string
+
+// useBuiltinPicker resolves --picker, falling back to the builtin picker
+// when fzf is not installed
+func useBuiltinPicker() bool {
+	switch pickerMode {
+	case "builtin":
+		return true
+	case "fzf":
+		if _, err := exec.LookPath("fzf"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: fzf is not installed (--picker fzf)\n")
+			fmt.Fprintf(os.Stderr, "Install: brew install fzf (macOS) or apt install fzf (Linux)\n")
+			fmt.Fprintf(os.Stderr, "Or use the builtin picker: --picker builtin\n")
+			os.Exit(1)
+		}
+		return false
+	case "auto", "":
+		_, err := exec.LookPath("fzf")
+		return err != nil
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Invalid picker '%s' (use auto, fzf or builtin)\n", pickerMode)
+		os.Exit(1)
+	}
+	return false
+}
+
+// runBuiltinPicker shows picker lines in the native picker and returns the chosen key
+// With hiddenKey, the first tab-separated column is the key and is not displayed
+func runBuiltinPicker(lines string, hiddenKey bool, prompt, header string) string {
+	var items []picker.Item
+	for _, line := range strings.Split(strings.TrimRight(lines, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		key, text, _ := strings.Cut(line, "\t")
+		if !hiddenKey {
+			text = line
+		}
+		items = append(items, picker.Item{Key: key, Text: text})
+	}
+
+	selected, err := picker.Run(items, picker.Options{
+		Prompt:  prompt,
+		Header:  header,
+		Tabstop: 24,
+		Preview: previewCard,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return selected
+}
+
+// previewCard renders 'pk preview <key>' for the builtin picker
+func previewCard(key string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	output, _ := exec.Command(exe, "preview", key).Output()
+	return string(output)
+}
@@ -23,14 +23,15 @@ Pinned projects can be quickly accessed with keyboard shortcuts:
   etc.
 
 Subcommands:
-  pk pin add <project> <slot>   # Pin a project to a slot
+  pk pin add <project> [slot]   # Pin a project to a slot
+  pk pin add <project>...       # Pin several to free slots
   pk pin remove <slot|project>  # Remove a pin
   pk pin list                   # Show all pins
   pk pin clear                  # Remove all pins`,
 }
 
 var pinAddCmd = &cobra.Command{
-	Use:   "add <project> <slot>",
+	Use:   "add <project> [slot] | <project>...",
 	Short: "Pin a project to a slot (1-5)",
 	Long: `Pin a project to a numbered slot for quick access.
 
@@ -43,11 +44,17 @@ Or with tmux keybindings:
   Ctrl+b g 1
   Ctrl+b g 2
 
+Without a slot, the project takes the first free slot. Several projects
+(or --filter) take free slots in order; already pinned ones keep theirs.
+` + filterHelp + `
+
 Examples:
+  pk pin add pk            # Pin 'pk' to the first free slot
   pk pin add pk 1          # Pin 'pk' to slot 1
   pk pin add dkos 2        # Pin 'dkos' to slot 2
-  pk pin add conduit 3     # Pin 'conduit' to slot 3`,
-	Args:              cobra.ExactArgs(2),
+  pk pin add conduit 3     # Pin 'conduit' to slot 3
+  pk pin add pk dkos       # Pin both to free slots
+  pk pin add --filter 'status=active and client=Acme'`,
 	Run:               runPinAdd,
 	ValidArgsFunction: validPinAddArgs,
 }
@@ -88,19 +95,37 @@ func init() {
 	pinCmd.AddCommand(pinRemoveCmd)
 	pinCmd.AddCommand(pinListCmd)
 	pinCmd.AddCommand(pinClearCmd)
+	addBulkFlags(pinAddCmd, &pinFilter, &pinYes)
 }
 
-func runPinAdd(cmd *cobra.Command, args []string) {
-	projectName := strings.ToLower(args[0])
-	slotStr := args[1]
+var (
+	pinFilter string
+	pinYes    bool
+)
 
-	// Parse slot number
-	slot, err := strconv.Atoi(slotStr)
-	if err != nil || slot < 1 || slot > 5 {
-		fmt.Fprintf(os.Stderr, "Error: Slot must be a number between 1 and 5\n")
+func runPinAdd(cmd *cobra.Command, args []string) {
+	// "pk pin add <project> <slot>" pins to a given slot; anything else
+	// with several names or --filter takes free slots
+	slotArg := false
+	if len(args) == 2 && pinFilter == "" {
+		_, err := strconv.Atoi(args[1])
+		slotArg = err == nil
+	}
+	if len(args) == 0 && pinFilter == "" {
+		fmt.Fprintf(os.Stderr, "Error: give a project name or --filter\n")
 		os.Exit(1)
 	}
 
+	// Parse slot number (0 = first free slot, resolved below)
+	slot := 0
+	if slotArg {
+		slot, _ = strconv.Atoi(args[1])
+		if slot < 1 || slot > 5 {
+			fmt.Fprintf(os.Stderr, "Error: Slot must be a number between 1 and 5\n")
+			os.Exit(1)
+		}
+	}
+
 	// Find the project
 	homeDir, _ := os.UserHomeDir()
 	projectsDir := filepath.Join(homeDir, "projects")
@@ -113,9 +138,16 @@ func runPinAdd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Check scratch projects too
+	// Check subprojects and scratch projects too
 	scratchProjects, _ := findScratchProjects(scratchDir)
-	projects = append(projects, scratchProjects...)
+	projects = append(config.WithSubprojects(projects), scratchProjects...)
+
+	if !slotArg && isBulk(args, pinFilter) {
+		runPinAddBulk(args, projects)
+		return
+	}
+
+	projectName := strings.ToLower(args[0])
 
 	// Find matching project
 	var foundProject *config.Project
@@ -132,6 +164,19 @@ func runPinAdd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	// Pick the first free slot unless one was given
+	if slot == 0 {
+		if pinned := cache.IsPinned(foundProject.ProjectInfo.ID); pinned > 0 {
+			fmt.Printf("'%s' is already pinned to slot %d\n", foundProject.ProjectInfo.ID, pinned)
+			return
+		}
+		slot, err = cache.FreeSlot()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (specify a slot to replace)\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Check if slot is already occupied
 	existingPin, _ := cache.GetPin(slot)
 	if existingPin != nil {
@@ -151,6 +196,63 @@ func runPinAdd(cmd *cobra.Command, args []string) {
 	fmt.Printf("  Ctrl+b g %d\n", slot)
 }
 
+// runPinAddBulk pins several projects to free slots, failing up front
+// when there aren't enough
+func runPinAddBulk(names []string, projects []*config.Project) {
+	selected := requireSelection(selectProjects(names, pinFilter, projects))
+
+	var toPin []*config.Project
+	for _, p := range selected {
+		if pinned := cache.IsPinned(p.ProjectInfo.ID); pinned > 0 {
+			fmt.Printf("'%s' is already pinned to slot %d\n", p.ProjectInfo.ID, pinned)
+			continue
+		}
+		toPin = append(toPin, p)
+	}
+	if len(toPin) == 0 {
+		return
+	}
+
+	pins, err := cache.LoadPins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load pins: %v\n", err)
+		os.Exit(1)
+	}
+	var free []int
+	for slot := 1; slot <= 5; slot++ {
+		if _, taken := pins[slot]; !taken {
+			free = append(free, slot)
+		}
+	}
+	if len(free) < len(toPin) {
+		fmt.Fprintf(os.Stderr, "Error: %d project(s) to pin but only %d free slot(s)\n", len(toPin), len(free))
+		fmt.Fprintf(os.Stderr, "Free slots with 'pk pin remove <slot>' or narrow the selection.\n")
+		os.Exit(1)
+	}
+
+	slots := make(map[*config.Project]int)
+	for i, p := range toPin {
+		slots[p] = free[i]
+	}
+	describe := func(p *config.Project) string {
+		return fmt.Sprintf("→ slot %d", slots[p])
+	}
+	if !confirmPlan("Pin", toPin, describe, pinYes) {
+		return
+	}
+
+	var results bulkResults
+	for _, p := range toPin {
+		err := cache.AddPin(slots[p], p.ProjectInfo.ID, p.Path)
+		results.report(p, err, fmt.Sprintf("pinned to slot %d", slots[p]))
+	}
+
+	fmt.Printf("\nDone: %d succeeded, %d failed\n", results.succeeded, results.failed)
+	if results.failed > 0 {
+		os.Exit(1)
+	}
+}
+
 func runPinRemove(cmd *cobra.Command, args []string) {
 	target := args[0]
 
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/spf13/cobra"
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview [project]",
+	Short: "Render a project card for the picker preview",
+	Long: `Render a compact project card for the picker preview (fzf --preview
+or the builtin picker).
+
+Used by the interactive pickers of 'pk session' and 'pk sessions'.
+The card shows description, stack, client, git branch and dirty state,
+last access, pin slot, live session windows and the top of the README
+and roadmap.
+
+--list prints picker lines (used to reload the picker after an action)
+and --open opens the project's repository URL in the browser.`,
+	Hidden: true,
+	Args:   cobra.MaximumNArgs(1),
+	Run:    runPreview,
+}
+
+var (
+	previewList   bool
+	previewActive bool
+	previewOpen   bool
+)
+
+// previewLines is how many lines of README/roadmap the card shows
+const previewLines = 12
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.Flags().BoolVar(&previewList, "list", false, "Print picker lines instead of a card")
+	previewCmd.Flags().BoolVar(&previewActive, "active", false, "With --list, only projects with a running session")
+	previewCmd.Flags().BoolVar(&previewOpen, "open", false, "Open the project's repository URL")
+}
+
+func runPreview(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if previewList {
+		if previewActive {
+			fmt.Print(sessionPickerLines(activeSessionProjects(projects)))
+		} else {
+			fmt.Print(projectPickerLines(projects))
+		}
+		return
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: Specify a project\n")
+		os.Exit(1)
+	}
+
+	project := findProject(args[0], projects)
+	if project == nil {
+		// Sessions without a project still get a minimal card
+		project = &config.Project{}
+		project.ProjectInfo.ID = args[0]
+		project.ProjectInfo.Name = args[0]
+	}
+
+	if previewOpen {
+		openRepository(project)
+		return
+	}
+
+	writePreviewCard(os.Stdout, project)
+}
+
+// findProject matches a project by ID or name
+func findProject(name string, projects []*config.Project) *config.Project {
+	name = strings.ToLower(name)
+	for _, p := range projects {
+		if strings.ToLower(p.ProjectInfo.ID) == name ||
+			strings.ToLower(p.ProjectInfo.Name) == name {
+			return p
+		}
+	}
+	return projectForSession(session.SanitizeSessionName(name), projects)
+}
+
+// checkNotSubproject refuses changes to a subproject, whose metadata lives
+// in its parent's .project.toml
+func checkNotSubproject(p *config.Project) error {
+	if p.IsSubproject() {
+		return fmt.Errorf("'%s' is a subproject of %s; change %s instead", p.ProjectInfo.ID, p.Parent, p.Parent)
+	}
+	return nil
+}
+
+// writePreviewCard renders the project card to w
+func writePreviewCard(w io.Writer, p *config.Project) {
+	homeDir, _ := os.UserHomeDir()
+
+	// Header
+	fmt.Fprintf(w, "\033[1;34m%s\033[0m \033[90m(%s)\033[0m\n", p.ProjectInfo.Name, p.ProjectInfo.ID)
+
+	var meta []string
+	if p.ProjectInfo.Status != "" {
+		meta = append(meta, getStatusColor(p.ProjectInfo.Status)+p.ProjectInfo.Status+"\033[0m")
+	}
+	if owner := p.GetOwner(); owner != "" {
+		meta = append(meta, "["+owner+"]")
+	}
+	if client := p.GetClientName(); client != "" {
+		if partner := p.GetPartner(); partner != "" {
+			client += " via " + partner
+		}
+		meta = append(meta, client)
+	}
+	if len(meta) > 0 {
+		fmt.Fprintln(w, strings.Join(meta, " · "))
+	}
+
+	if p.Notes.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(p.Notes.Description))
+	}
+	fmt.Fprintln(w)
+
+	// Facts
+	if len(p.Tech.Stack) > 0 {
+		fmt.Fprintf(w, "Stack:    %s\n", strings.Join(p.Tech.Stack, ", "))
+	}
+	if len(p.ProjectInfo.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:     %s\n", strings.Join(p.ProjectInfo.Tags, ", "))
+	}
+	if p.Path != "" {
+		fmt.Fprintf(w, "Path:     %s\n", shortenHome(p.Path, homeDir))
+		if git := gitSummary(p.Path); git != "" {
+			fmt.Fprintf(w, "Git:      %s\n", git)
+		}
+	}
+
+	if records, err := cache.LoadAccessRecords(); err == nil {
+		if record, ok := records[p.ProjectInfo.ID]; ok {
+			fmt.Fprintf(w, "Accessed: %s\n", formatAccessTime(record.LastAccessed))
+		}
+	}
+	if slot := cache.IsPinned(p.ProjectInfo.ID); slot > 0 {
+		fmt.Fprintf(w, "Pinned:   slot %d\n", slot)
+	}
+
+	sessionName := session.SanitizeSessionName(p.ProjectInfo.ID)
+	if session.SessionExists(sessionName) {
+		if state, err := session.CaptureSession(sessionName); err == nil {
+			var windows []string
+			for _, w := range state.Windows {
+				name := w.Name
+				if w.Active {
+					name += "*"
+				}
+				windows = append(windows, name)
+			}
+			fmt.Fprintf(w, "Session:  \033[32m●\033[0m %s\n", strings.Join(windows, " "))
+		}
+	}
+
+	// Top of README and roadmap
+	if p.Path == "" {
+		return
+	}
+	for _, name := range []string{"README.md", "README", "readme.md"} {
+		if printFileHead(w, filepath.Join(p.Path, name), name) {
+			break
+		}
+	}
+	if p.Dev.Roadmap != "" {
+		roadmap := p.Dev.Roadmap
+		if !filepath.IsAbs(roadmap) {
+			roadmap = filepath.Join(p.Path, roadmap)
+		}
+		printFileHead(w, roadmap, filepath.Base(roadmap))
+	}
+}
+
+// gitSummary returns "branch" plus dirty state for a git work tree, or "" otherwise
+func gitSummary(path string) string {
+	branch, err := exec.Command("git", "-C", path, "branch", "--show-current").Output()
+	if err != nil {
+		return ""
+	}
+
+	summary := strings.TrimSpace(string(branch))
+	if summary == "" {
+		summary = "(detached)"
+	}
+
+	status, err := exec.Command("git", "-C", path, "status", "--porcelain").Output()
+	if err != nil {
+		return summary
+	}
+	changes := strings.TrimSpace(string(status))
+	if changes == "" {
+		return summary + " \033[32m✓ clean\033[0m"
+	}
+	return fmt.Sprintf("%s \033[33m● %d changed\033[0m", summary, len(strings.Split(changes, "\n")))
+}
+
+// printFileHead prints the first lines of a file under a title, reporting whether it existed
+func printFileHead(w io.Writer, path, title string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "\n\033[1m── %s ──\033[0m\n", title)
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < previewLines && scanner.Scan(); i++ {
+		fmt.Fprintln(w, scanner.Text())
+	}
+	return true
+}
+
+// openRepository opens the project's repository URL in the default browser
+func openRepository(p *config.Project) {
+	url := p.RepositoryWebURL()
+	if url == "" {
+		fmt.Fprintf(os.Stderr, "Error: No repository link for '%s'\n", p.ProjectInfo.ID)
+		os.Exit(1)
+	}
+
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+
+	if err := exec.Command(opener, url).Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to open %s: %v\n", url, err)
+		os.Exit(1)
+	}
+}
+
+// pickerBindings returns fzf flags for the preview card and the action keys
+// Actions call back into pk and reload the list with reloadArgs
+func pickerBindings(reloadArgs string) []string {
+	exe := "pk"
+	if path, err := os.Executable(); err == nil {
+		exe = shellQuote(path)
+	}
+	reload := fmt.Sprintf("reload(%s preview --list %s)", exe, reloadArgs)
+
+	return []string{
+		"--preview", exe + " preview {1}",
+		"--bind", fmt.Sprintf("ctrl-p:execute-silent(%s pin add {1})+refresh-preview", exe),
+		"--bind", fmt.Sprintf("ctrl-a:execute-silent(%s archive {1})+%s", exe, reload),
+		"--bind", fmt.Sprintf("ctrl-x:execute-silent(%s sessions kill {1})+%s", exe, reload),
+		"--bind", fmt.Sprintf("ctrl-o:execute-silent(%s preview --open {1})", exe),
+	}
+}
+
+// pickerKeysHeader describes the action keys for fzf headers
+const pickerKeysHeader = "ctrl-p pin · ctrl-a archive · ctrl-x kill session · ctrl-o open repo"
+
+// shellQuote quotes a string for use in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// projectPickerLines builds the 'pk session' picker input, one project per line
+// Format: "project-id    [owner]    status    [session-indicator]"
+func projectPickerLines(projects []*config.Project) string {
+	// Get list of existing sessions
+	existingSessions, _ := session.ListSessions()
+	sessionSet := make(map[string]bool)
+	for _, s := range existingSessions {
+		sessionSet[s] = true
+	}
+
+	var builder strings.Builder
+	for _, p := range rankByFrecency(projects) {
+		owner := p.GetOwner()
+		if owner == "" {
+			owner = "none"
+		}
+		status := p.ProjectInfo.Status
+		if status == "" {
+			status = "unknown"
+		}
+
+		sessionIndicator := ""
+		if sessionSet[session.SanitizeSessionName(p.ProjectInfo.ID)] {
+			sessionIndicator = "●" // Indicates active session
+		}
+
+		fmt.Fprintf(&builder, "%s\t[%s]\t%s\t%s\n", p.ProjectInfo.ID, owner, status, sessionIndicator)
+	}
+
+	return builder.String()
+}
+
+// rankByFrecency returns a copy of projects ordered by access frecency
+func rankByFrecency(projects []*config.Project) []*config.Project {
+	ranked := append([]*config.Project(nil), projects...)
+	records, err := cache.LoadAccessRecords()
+	if err != nil {
+		return ranked
+	}
+	cache.RankProjects(ranked, records, time.Now())
+	return ranked
+}
+
+// sessionPickerLines builds the 'pk sessions' picker input, one session per line
+// The first (hidden) column is the bare project ID used by fzf placeholders
+func sessionPickerLines(sessionProjects map[string]*config.Project) string {
+	// Load pins to show which projects are pinned
+	pins, _ := cache.ListPins()
+	pinMap := make(map[string]int)
+	for _, pin := range pins {
+		pinMap[pin.ProjectID] = pin.Slot
+	}
+
+	names := make([]string, 0, len(sessionProjects))
+	for name := range sessionProjects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		p := sessionProjects[name]
+
+		owner := p.GetOwner()
+		if owner == "" {
+			owner = "none"
+		}
+
+		status := p.ProjectInfo.Status
+		if status == "" {
+			status = "active"
+		}
+
+		// Show pin number if pinned
+		pinIndicator := ""
+		if slot, isPinned := pinMap[p.ProjectInfo.ID]; isPinned {
+			pinIndicator = fmt.Sprintf("[%d]", slot)
+		}
+
+		fmt.Fprintf(&builder, "%s\t%s%s\t[%s]\t%s\t●\n",
+			p.ProjectInfo.ID,
+			pinIndicator,
+			p.ProjectInfo.ID,
+			owner,
+			status)
+	}
+
+	return builder.String()
+}
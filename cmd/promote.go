@@ -10,6 +10,7 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
 	"github.com/spf13/cobra"
 )
 
@@ -112,6 +113,8 @@ func runPromote(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	tx := journal.Begin("promote", args[0])
+
 	// Move to ~/projects if --move
 	if promoteMove {
 		newPath := filepath.Join(homeDir, "projects", projectName)
@@ -122,14 +125,8 @@ func runPromote(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
-		// Ensure parent directory exists
-		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to create parent directory: %v\n", err)
-			os.Exit(1)
-		}
-
 		// Move directory
-		if err := os.Rename(dirPath, newPath); err != nil {
+		if err := tx.Move(dirPath, newPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Failed to move directory: %v\n", err)
 			os.Exit(1)
 		}
@@ -156,8 +153,9 @@ func runPromote(cmd *cobra.Command, args []string) {
 
 	// Create .project.toml
 	tomlPath = filepath.Join(dirPath, ".project.toml")
-	if err := createPromoteProjectToml(tomlPath, projectName, dirPath); err != nil {
+	if err := tx.Write(tomlPath, func() error { return createPromoteProjectToml(tomlPath, projectName, dirPath) }); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to create .project.toml: %v\n", err)
+		rollback(tx)
 		os.Exit(1)
 	}
 
@@ -166,6 +164,8 @@ func runPromote(cmd *cobra.Command, args []string) {
 	// Sync aliases
 	fmt.Println("Syncing aliases...")
 	runSync(cmd, []string{})
+	tx.Record(journal.Step{Kind: journal.StepSync})
+	commitJournal(tx)
 
 	fmt.Printf("\n\033[32m✓\033[0m Project '%s' promoted successfully!\n", projectName)
 	fmt.Printf("\nNext steps:\n")
@@ -179,7 +179,7 @@ func createPromoteProjectToml(path, name, projectPath string) error {
 	project.Path = projectPath
 	project.ProjectInfo.Name = name
 	project.ProjectInfo.ID = name
-	project.ProjectInfo.Status = "active"
+	project.ProjectInfo.Status = statusMachine().Initial()
 	project.ProjectInfo.Type = promoteType
 	project.Consultant.Ownership = promoteOwner
 	project.Consultant.MyRole = "owner"
@@ -14,9 +14,10 @@ var recentLimit int
 var recentCmd = &cobra.Command{
 	Use:   "recent",
 	Short: "List recently accessed projects",
-	Long: `List projects sorted by most recent access time.
+	Long: `List projects ranked by frecency (frequency and recency of access).
 
-Shows projects you've opened with 'pk session' recently. Projects never
+Every visit counts, and recent visits count more: a project opened daily
+this week ranks above one opened once an hour ago. Projects never
 accessed are not shown.
 
 Examples:
@@ -58,31 +59,7 @@ func runRecent(cmd *cobra.Command, args []string) {
 			continue
 		}
 
-		// Format access time
-		accessTime := record.LastAccessed
-		var timeStr string
-
-		now := time.Now()
-		diff := now.Sub(accessTime)
-
-		if diff < time.Minute {
-			timeStr = "just now"
-		} else if diff < time.Hour {
-			minutes := int(diff.Minutes())
-			timeStr = fmt.Sprintf("%dm ago", minutes)
-		} else if diff < 24*time.Hour {
-			hours := int(diff.Hours())
-			timeStr = fmt.Sprintf("%dh ago", hours)
-		} else if diff < 7*24*time.Hour {
-			days := int(diff.Hours() / 24)
-			if days == 1 {
-				timeStr = "1 day ago"
-			} else {
-				timeStr = fmt.Sprintf("%d days ago", days)
-			}
-		} else {
-			timeStr = accessTime.Format("Jan 2, 2006")
-		}
+		timeStr := formatAccessTime(record.LastAccessed)
 
 		owner := p.GetOwner()
 		if owner == "" {
@@ -94,12 +71,38 @@ func runRecent(cmd *cobra.Command, args []string) {
 			status = "unknown"
 		}
 
-		fmt.Printf("%-25s [%s] %-12s  %s\n",
+		visits := record.Count
+		if visits == 0 {
+			visits = 1
+		}
+
+		fmt.Printf("%-25s [%s] %-12s  %-12s  %d visit(s)\n",
 			p.ProjectInfo.ID,
 			owner,
 			status,
-			timeStr)
+			timeStr,
+			visits)
 	}
 
-	fmt.Printf("\nUse 'pk session <name>' to open a project\n")
+	fmt.Printf("\nUse 'pk session <name>' or 'pk z <partial>' to open a project\n")
+}
+
+// formatAccessTime renders an access time relative to now
+func formatAccessTime(accessTime time.Time) string {
+	diff := time.Since(accessTime)
+
+	if diff < time.Minute {
+		return "just now"
+	} else if diff < time.Hour {
+		return fmt.Sprintf("%dm ago", int(diff.Minutes()))
+	} else if diff < 24*time.Hour {
+		return fmt.Sprintf("%dh ago", int(diff.Hours()))
+	} else if diff < 7*24*time.Hour {
+		days := int(diff.Hours() / 24)
+		if days == 1 {
+			return "1 day ago"
+		}
+		return fmt.Sprintf("%d days ago", days)
+	}
+	return accessTime.Format("Jan 2, 2006")
 }
@@ -4,33 +4,69 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/lifecycle"
+	"github.com/datakaicr/pk/pkg/relations"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/tomledit"
+	"github.com/datakaicr/pk/pkg/worklog"
 	"github.com/spf13/cobra"
 )
 
 var renameCmd = &cobra.Command{
 	Use:   "rename <old-name> <new-name>",
 	Short: "Rename a project",
-	Long: `Rename a project directory and update all metadata.
+	Long: `Rename a project directory and ID and carry everything along.
 
 This will:
   1. Validate both old and new names
   2. Rename the project directory
-  3. Update .project.toml (name and ID)
-  4. Auto-sync shell aliases
+  3. Update the ID in .project.toml (the display name is kept unless
+     --name is given) and [tmux] window paths that pointed inside it
+  4. Move pins, access history, the work log and tracked time (pk time)
+     to the new ID
+  5. Point [relations] of other projects at the new ID
+  6. Rename a running tmux session
+  7. Auto-sync shell aliases
+
+--id-only changes the ID (and session) but leaves the directory alone;
+--dir-only renames the directory but keeps the ID. Every step is
+journaled, so 'pk undo' reverses the rename.
 
 Example:
   pk rename old-name new-name
-  pk rename prototype awesome-product`,
-	Args: cobra.ExactArgs(2),
-	Run:  runRename,
+  pk rename prototype awesome-product --name "Awesome Product"
+  pk rename app api --id-only          # Keep ~/projects/app
+  pk rename app app-v1 --dir-only      # Keep the ID 'app'`,
+	Args:              cobra.ExactArgs(2),
+	Run:               runRename,
+	ValidArgsFunction: validRenameArgs,
 }
 
+var (
+	renameName    string
+	renameIDOnly  bool
+	renameDirOnly bool
+)
+
 func init() {
 	rootCmd.AddCommand(renameCmd)
+	renameCmd.Flags().StringVar(&renameName, "name", "", "New display name (default: keep the current one)")
+	renameCmd.Flags().BoolVar(&renameIDOnly, "id-only", false, "Change the ID but not the directory")
+	renameCmd.Flags().BoolVar(&renameDirOnly, "dir-only", false, "Rename the directory but keep the ID")
+	renameCmd.MarkFlagsMutuallyExclusive("id-only", "dir-only")
+}
+
+// validRenameArgs completes the project to rename, not the new name
+func validRenameArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return validProjectNames(cmd, args, toComplete)
 }
 
 func runRename(cmd *cobra.Command, args []string) {
@@ -74,69 +110,233 @@ func runRename(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Determine new path
-	parentDir := filepath.Dir(found.Path)
-	newPath := filepath.Join(parentDir, newName)
+	oldID := found.ProjectInfo.ID
+	newID := oldID
+	if !renameDirOnly {
+		newID = newName
+	}
+	newPath := found.Path
+	if !renameIDOnly {
+		newPath = filepath.Join(filepath.Dir(found.Path), newName)
+	}
 
-	// Check if new name already exists
-	if _, err := os.Stat(newPath); err == nil {
-		fmt.Fprintf(os.Stderr, "Error: A project with name '%s' already exists at %s\n", newName, newPath)
+	if newID == oldID && newPath == found.Path && renameName == "" {
+		fmt.Fprintf(os.Stderr, "Error: Nothing to rename\n")
 		os.Exit(1)
 	}
 
-	fmt.Printf("Renaming project: %s → %s\n", found.ProjectInfo.Name, newName)
-	fmt.Printf("Location: %s → %s\n", found.Path, newPath)
+	// Check if new ID or directory is taken
+	if newID != oldID {
+		for _, p := range projects {
+			if p != found && strings.EqualFold(p.ProjectInfo.ID, newID) {
+				fmt.Fprintf(os.Stderr, "Error: Project ID '%s' is already used by %s\n", newID, p.Path)
+				os.Exit(1)
+			}
+		}
+	}
+	if newPath != found.Path {
+		if _, err := os.Stat(newPath); err == nil {
+			fmt.Fprintf(os.Stderr, "Error: A project with name '%s' already exists at %s\n", newName, newPath)
+			os.Exit(1)
+		}
+	}
 
-	// Rename directory
-	if err := os.Rename(found.Path, newPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to rename directory: %v\n", err)
-		os.Exit(1)
+	fmt.Printf("Renaming project: %s\n", found.ProjectInfo.Name)
+	if newID != oldID {
+		fmt.Printf("ID:       %s → %s\n", oldID, newID)
+	}
+	if renameName != "" {
+		fmt.Printf("Name:     %s → %s\n", found.ProjectInfo.Name, renameName)
+	}
+	if newPath != found.Path {
+		fmt.Printf("Location: %s → %s\n", found.Path, newPath)
 	}
 
-	fmt.Printf("\033[32m✓\033[0m Directory renamed\n")
+	tx := journal.Begin("rename", append([]string{oldID, newName}, renameFlags()...)...)
+
+	// Rename directory
+	if newPath != found.Path {
+		if err := tx.Move(found.Path, newPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to rename directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("\033[32m✓\033[0m Directory renamed\n")
+	}
 
 	// Update .project.toml
+	idChange := ""
+	if newID != oldID {
+		idChange = newID
+	}
 	tomlPath := filepath.Join(newPath, ".project.toml")
-	if err := updateProjectTomlRename(tomlPath, newName, newPath); err != nil {
+	if err := tx.Write(tomlPath, func() error {
+		return lifecycle.MarkRenamed(tomlPath, idChange, renameName, found.Path, newPath)
+	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to update .project.toml: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Directory was renamed but metadata update failed.\n")
+		rollback(tx)
 		os.Exit(1)
 	}
 
 	fmt.Printf("\033[32m✓\033[0m Metadata updated\n")
 
+	// Carry pins and access history along
+	if err := tx.Rekey(oldID, newID, found.Path, newPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to update pins and access history: %v\n", err)
+		rollback(tx)
+		os.Exit(1)
+	}
+
+	if newID != oldID {
+		fmt.Printf("\033[32m✓\033[0m Pins and access history moved to '%s'\n", newID)
+		renameWorkLog(tx, oldID, newID)
+		renameActivity(tx, oldID, newID)
+		renameRelations(tx, projects, found, oldID, newID)
+		renameLiveSession(tx, found, oldID, newID)
+	}
+
 	// Sync aliases
 	fmt.Println("Syncing aliases...")
 	runSync(cmd, []string{})
+	tx.Record(journal.Step{Kind: journal.StepSync})
+	commitJournal(tx)
 
 	fmt.Printf("\n\033[32m✓\033[0m Project renamed successfully!\n")
-	fmt.Printf("\nNew alias:\n")
-	fmt.Printf("  %s    # Jump to project (after reloading shell)\n", newName)
+	if newID != oldID {
+		fmt.Printf("\nNew alias:\n")
+		fmt.Printf("  %s    # Jump to project (after reloading shell)\n", newID)
+	}
+	fmt.Printf("\nUndo with: pk undo\n")
 }
 
-func updateProjectTomlRename(path, newName, newPath string) error {
-	// Read current TOML
-	var project config.Project
-	if _, err := toml.DecodeFile(path, &project); err != nil {
-		return err
+// renameFlags returns the rename flags in use, for the journal entry
+func renameFlags() []string {
+	var flags []string
+	if renameName != "" {
+		flags = append(flags, "--name", strconv.Quote(renameName))
 	}
+	if renameIDOnly {
+		flags = append(flags, "--id-only")
+	}
+	if renameDirOnly {
+		flags = append(flags, "--dir-only")
+	}
+	return flags
+}
 
-	// Update fields
-	project.Path = newPath
-	project.ProjectInfo.Name = newName
-	project.ProjectInfo.ID = newName
+// renameWorkLog moves the shared work logs (pk log) of the project and its
+// subprojects to the new ID
+func renameWorkLog(tx *journal.Tx, oldID, newID string) {
+	logDir, err := worklog.GetLogDir()
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return
+	}
 
-	// Write back
-	f, err := os.Create(path)
+	oldPrefix, newPrefix := config.FileSafeID(oldID), config.FileSafeID(newID)
+	subPrefix := config.FileSafeID(oldID + "/")
+	moved := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != oldPrefix+".jsonl" && !(strings.HasPrefix(name, subPrefix) && strings.HasSuffix(name, ".jsonl")) {
+			continue
+		}
+
+		oldLog := filepath.Join(logDir, name)
+		newLog := filepath.Join(logDir, newPrefix+strings.TrimPrefix(name, oldPrefix))
+		if _, err := os.Stat(newLog); err == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s already exists, work log left at %s\n", newLog, oldLog)
+			continue
+		}
+		if err := tx.Move(oldLog, newLog); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to move work log %s: %v\n", oldLog, err)
+			continue
+		}
+		moved++
+	}
+
+	switch {
+	case moved == 1:
+		fmt.Printf("\033[32m✓\033[0m Work log moved\n")
+	case moved > 1:
+		fmt.Printf("\033[32m✓\033[0m %d work logs moved (subprojects included)\n", moved)
+	}
+}
+
+// renameActivity points the project's activity log events (pk time) at the
+// new ID
+func renameActivity(tx *journal.Tx, oldID, newID string) {
+	changed, err := tx.RenameActivity(oldID, newID)
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "Warning: Failed to move tracked time: %v\n", err)
+		return
+	}
+	if changed > 0 {
+		fmt.Printf("\033[32m✓\033[0m Tracked time moved (%d event(s))\n", changed)
+	}
+}
+
+// renameRelations points other projects' [relations] at the new ID
+func renameRelations(tx *journal.Tx, projects []*config.Project, renamed *config.Project, oldID, newID string) {
+	var updated []string
+	for _, p := range projects {
+		if p == renamed {
+			continue
+		}
+		r := p.Relations
+		r.DependsOn = append([]string{}, r.DependsOn...)
+		r.Deploys = append([]string{}, r.Deploys...)
+		changed := relations.Retarget(&r, oldID, newID)
+		if len(changed) == 0 {
+			continue
+		}
+
+		tomlPath := filepath.Join(p.Path, ".project.toml")
+		err := tx.Write(tomlPath, func() error {
+			data, err := os.ReadFile(tomlPath)
+			if err != nil {
+				return err
+			}
+			for _, kind := range changed {
+				field, _ := config.LookupField("relations." + kind)
+				data = tomledit.Set(data, "relations", kind, tomledit.Literal(field.Get(&config.Project{Relations: r})))
+			}
+			return os.WriteFile(tomlPath, data, 0644)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to update relations of %s: %v\n", p.ProjectInfo.ID, err)
+			continue
+		}
+		updated = append(updated, p.ProjectInfo.ID)
 	}
-	defer f.Close()
 
-	// Write header comment
-	fmt.Fprintln(f, "# Project Metadata")
-	fmt.Fprintln(f, "")
+	if len(updated) > 0 {
+		fmt.Printf("\033[32m✓\033[0m Relations updated in: %s\n", strings.Join(updated, ", "))
+	}
+}
+
+// renameLiveSession renames the running tmux sessions of the project and
+// its subprojects to match the new ID
+func renameLiveSession(tx *journal.Tx, renamed *config.Project, oldID, newID string) {
+	ids := [][2]string{{oldID, newID}}
+	for _, sub := range renamed.Subprojects {
+		ids = append(ids, [2]string{config.SubprojectID(oldID, sub.Name), config.SubprojectID(newID, sub.Name)})
+	}
 
-	encoder := toml.NewEncoder(f)
-	return encoder.Encode(&project)
+	for _, id := range ids {
+		oldSession := session.SanitizeSessionName(id[0])
+		newSession := session.SanitizeSessionName(id[1])
+		if oldSession == newSession || !session.SessionExists(oldSession) {
+			continue
+		}
+
+		if err := tx.RenameSession(oldSession, newSession, id[0], id[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to rename tmux session %s: %v\n", oldSession, err)
+			continue
+		}
+		fmt.Printf("\033[32m✓\033[0m Tmux session renamed: %s → %s\n", oldSession, newSession)
+	}
 }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/billing"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Business reports from project metadata",
+}
+
+var reportBillingCmd = &cobra.Command{
+	Use:   "billing",
+	Short: "Draft invoices for billable consultant projects",
+	Long: `Draft invoices for a month from [consultant] metadata and tracked hours.
+
+Billable projects are grouped by who receives the invoice: the partner when
+the project is delivered through one, otherwise the client. Hours are the
+tracked session time (see 'pk time') plus work logged with 'pk log', priced
+by rate_type:
+
+  hourly     hours × rate
+  retainer   rate, once per month while the project runs (its started
+             and completed dates overlap the month)
+  fixed      hours listed, fee billed per milestone
+
+Subprojects have no line of their own: their hours are billed on the
+parent's line.
+
+Rates are set per project:
+
+  [consultant]
+  billable = true
+  rate_type = "hourly"
+  rate = 150
+  currency = "USD"      # Default: USD
+
+Examples:
+  pk report billing                          # Current month, Markdown
+  pk report billing --month 2026-09
+  pk report billing --month 2026-09 --format csv > invoices.csv`,
+	Args: cobra.NoArgs,
+	Run:  runReportBilling,
+}
+
+var (
+	billingMonth  string
+	billingFormat string
+	billingMax    time.Duration
+	billingSource string
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportBillingCmd)
+
+	reportBillingCmd.Flags().StringVar(&billingMonth, "month", "", "Month to bill (YYYY-MM, default: current month)")
+	reportBillingCmd.Flags().StringVar(&billingFormat, "format", "markdown", "Output format: markdown, csv or json")
+	reportBillingCmd.Flags().StringVar(&billingSource, "source", "all", "Hours to bill: all, tracked (sessions) or logged (pk log)")
+	reportBillingCmd.Flags().DurationVar(&billingMax, "max", 8*time.Hour, "Longest stretch counted without a logged event (0 = no limit)")
+}
+
+func runReportBilling(cmd *cobra.Command, args []string) {
+	from, err := billingPeriod(billingMonth, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	to := from.AddDate(0, 1, 0)
+	period := from.Format("2006-01")
+
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	intervals, err := workIntervals(projects, from, to, billingMax, billingSource)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hours := make(map[string]float64)
+	for _, interval := range intervals {
+		hours[interval.ProjectID] += interval.Duration().Hours()
+	}
+
+	invoices := billing.BuildInvoices(projects, hours, billing.Period{From: from, To: to})
+
+	switch billingFormat {
+	case "markdown", "md":
+		billing.WriteMarkdown(os.Stdout, invoices, period)
+	case "csv":
+		err = billing.WriteCSV(os.Stdout, invoices)
+	case "json":
+		err = billing.WriteJSON(os.Stdout, invoices, period)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --format must be markdown, csv or json\n")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// billingPeriod parses --month into the first instant of that month
+func billingPeriod(month string, now time.Time) (time.Time, error) {
+	if month == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), nil
+	}
+
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --month '%s' (use YYYY-MM)", month)
+	}
+	return start, nil
+}
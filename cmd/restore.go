@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/lifecycle"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name>...",
+	Short: "Restore archived projects",
+	Long: `Move an archived project back to ~/projects and reactivate it.
+
+This will:
+  1. Move the project from ~/archive to ~/projects
+  2. Set status to "active" (or --status) in .project.toml
+  3. Clear the completion date
+  4. Point pins and access history at the new location
+  5. Auto-sync shell aliases (if enabled)
+
+Refuses if ~/projects already has a directory with the same name.
+Projects in cold storage ('pk archive compact') are unpacked first.
+
+` + filterHelp + `
+
+Examples:
+  pk restore old-project
+  pk restore old-project --status paused
+  pk restore --filter 'client=Acme and completed>=2024-01-01'`,
+	Run:               runRestore,
+	ValidArgsFunction: validArchivedProjectNames,
+}
+
+var (
+	restoreStatus   string
+	restoreAutoSync bool
+	restoreFilter   string
+	restoreYes      bool
+)
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVar(&restoreStatus, "status", lifecycle.DefaultRestoreStatus, "Status to restore the project with")
+	restoreCmd.Flags().BoolVar(&restoreAutoSync, "sync", true, "Auto-sync aliases after restoring")
+	addBulkFlags(restoreCmd, &restoreFilter, &restoreYes)
+}
+
+func runRestore(cmd *cobra.Command, args []string) {
+	homeDir, _ := os.UserHomeDir()
+	projectsDir := filepath.Join(homeDir, "projects")
+	archiveDir := filepath.Join(homeDir, "archive")
+
+	// Find project in archive directory
+	projects, err := config.FindProjects(archiveDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if isBulk(args, restoreFilter) {
+		runRestoreBulk(cmd, args, projects, projectsDir)
+		return
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: give a project name or --filter\n")
+		os.Exit(1)
+	}
+
+	projectName := strings.ToLower(args[0])
+
+	var found *config.Project
+	for _, p := range projects {
+		if strings.ToLower(p.ProjectInfo.ID) == projectName ||
+			strings.ToLower(p.ProjectInfo.Name) == projectName {
+			found = p
+			break
+		}
+	}
+
+	if found == nil {
+		fmt.Fprintf(os.Stderr, "Project '%s' not found in ~/archive\n", projectName)
+		fmt.Fprintf(os.Stderr, "Hint: Use 'pk list archived' to see archived projects\n")
+		os.Exit(1)
+	}
+
+	// Move project
+	fmt.Printf("Restoring project: %s\n", found.ProjectInfo.Name)
+	fmt.Printf("  From: %s\n", found.Path)
+	fmt.Printf("  To:   %s\n", filepath.Join(projectsDir, filepath.Base(found.Path)))
+
+	destPath, err := restoreProject(found, projectsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to restore project: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n\033[32m✓\033[0m Restored successfully\n")
+	fmt.Printf("  Status: %s%s\033[0m\n", getStatusColor(restoreStatus), restoreStatus)
+	fmt.Printf("  Location: %s\n", destPath)
+
+	// Auto-sync aliases
+	if restoreAutoSync {
+		fmt.Printf("\nSyncing aliases...\n")
+		runSync(cmd, []string{})
+	}
+}
+
+// runRestoreBulk restores several archived projects and syncs once
+func runRestoreBulk(cmd *cobra.Command, names []string, projects []*config.Project, projectsDir string) {
+	selected := requireSelection(selectProjects(names, restoreFilter, projects))
+
+	describe := func(p *config.Project) string {
+		return fmt.Sprintf("%s → %s", p.Path, filepath.Join(projectsDir, filepath.Base(p.Path)))
+	}
+	if !confirmPlan("Restore", selected, describe, restoreYes) {
+		return
+	}
+
+	var results bulkResults
+	for _, p := range selected {
+		destPath, err := restoreProject(p, projectsDir)
+		results.report(p, err, "restored to "+destPath)
+	}
+	results.finish(cmd, restoreAutoSync)
+}
+
+// restoreProject restores p with --status, if the status machine allows it
+func restoreProject(p *config.Project, projectsDir string) (string, error) {
+	if p.ProjectInfo.Status != restoreStatus {
+		if err := statusMachine().Check(p.ProjectInfo.Status, restoreStatus); err != nil {
+			return "", err
+		}
+	}
+	if backup.IsCompacted(p.Path) {
+		if err := backup.Expand(p.Path); err != nil {
+			return "", fmt.Errorf("failed to unpack cold storage: %w", err)
+		}
+	}
+	return lifecycle.Restore(p, projectsDir, restoreStatus)
+}
@@ -21,12 +21,30 @@ Commands:
   pk new <name>            # Create a new project
   pk list [filter]     # List all projects (active, archived, datakai, etc.)
   pk show <name>       # Show detailed project information
+  pk ui                # Interactive project dashboard
+  pk z <partial>       # Jump to the most frecent matching project
+  pk time report       # Hours per project from session activity
+  pk report billing    # Draft invoices for billable projects
+  pk log <name> 2h ... # Log work done outside tmux
   pk edit <name>       # Edit project metadata
+  pk set <name> k=v    # Set metadata fields (several names or --filter)
+  pk get <name> <path> # Print a metadata field
+  pk status <name> [s] # Show or change project status
+  pk tag add t <name>  # Tag projects (list --tag, session --tag)
+  pk exec --tag t -- c # Run a command in several projects
+  pk graph             # Project relationship graph (dot, mermaid, json)
+  pk session dk/dojo   # Open a [[subprojects]] entry as its own session
   pk rename <old> <new>  # Rename a project
   pk promote <path>    # Convert directory into a project
   pk archive <name>    # Archive a project (move to ~/archive)
-  pk delete <name>     # Delete a project permanently
+  pk archive compact   # Pack archived projects into cold storage
+  pk restore <name>    # Restore an archived project
+  pk delete <name>     # Move a project to the trash
+  pk trash list        # Show deleted projects (restore, empty)
+  pk backup <name>     # Back up a project (pk backup restore <bundle>)
+  pk undo              # Reverse the last rename, archive, set, promote or delete
   pk sync              # Generate shell aliases for all projects
+  pk workspace export  # Describe this machine's projects (apply elsewhere)
 
 Workflow:
   pk scratch new prototype      # Quick experimentation
@@ -45,6 +63,7 @@ Examples:
   pk rename old-name new    # Rename project
   pk promote api-test       # Promote scratch to project
   pk archive old-proj       # Archive a project
+  pk archive --filter 'client=Acme and status=completed'
   pk delete test --force    # Delete without confirmation`,
 }
 
@@ -7,7 +7,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
 	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/trash"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +19,8 @@ var (
 )
 
 var (
-	scratchDeleteForce bool
+	scratchDeleteForce     bool
+	scratchDeletePermanent bool
 )
 
 var scratchCmd = &cobra.Command{
@@ -56,15 +60,16 @@ Then later:
 var scratchDeleteCmd = &cobra.Command{
 	Use:   "delete <name>",
 	Short: "Delete a scratch project",
-	Long: `Remove a scratch project from ~/scratch.
+	Long: `Move a scratch project from ~/scratch to the pk trash.
 
 This will check for active tmux sessions and optionally kill them.
-
-WARNING: This operation is permanent. Data will be deleted.
+Use 'pk trash restore <name>' to bring it back, or --permanent to
+delete it right away.
 
 Example:
   pk scratch delete old-test
-  pk scratch delete prototype --force  # Skip confirmation, auto-kill session`,
+  pk scratch delete prototype --force  # Skip confirmation, auto-kill session
+  pk scratch delete junk --permanent   # Skip the trash`,
 	Args:              cobra.ExactArgs(1),
 	Run:               runScratchDelete,
 	ValidArgsFunction: validScratchNames,
@@ -86,6 +91,8 @@ func init() {
 		"Skip git initialization")
 	scratchDeleteCmd.Flags().BoolVar(&scratchDeleteForce, "force", false,
 		"Skip confirmation prompt")
+	scratchDeleteCmd.Flags().BoolVar(&scratchDeletePermanent, "permanent", false,
+		"Delete immediately instead of moving to the trash")
 }
 
 func runScratchNew(cmd *cobra.Command, args []string) {
@@ -170,7 +177,11 @@ func runScratchDelete(cmd *cobra.Command, args []string) {
 
 	// Show confirmation prompt
 	if !scratchDeleteForce {
-		fmt.Printf("\033[33mWARNING: This will permanently delete the scratch project.\033[0m\n\n")
+		if scratchDeletePermanent {
+			fmt.Printf("\033[33mWARNING: This will permanently delete the scratch project.\033[0m\n\n")
+		} else {
+			fmt.Printf("This will move the scratch project to the trash.\n\n")
+		}
 		fmt.Printf("Project:  %s\n", projectName)
 		fmt.Printf("Location: %s\n", scratchPath)
 		if hasSession {
@@ -187,14 +198,24 @@ func runScratchDelete(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Snapshot the session layout so restoring from the trash can rebuild it
+	var sessionState *session.SessionState
+	if hasSession {
+		sessionState, _ = session.CaptureSession(sessionName)
+	}
+
 	// Kill tmux session if it exists
 	if hasSession {
+		scratchProject := &config.Project{Path: scratchPath}
+		scratchProject.ProjectInfo.ID = projectName
+		scratchProject.ProjectInfo.Name = projectName
+
 		if !scratchDeleteForce {
 			fmt.Print("\nKill active tmux session? (y/N): ")
 			var response string
 			fmt.Scanln(&response)
 			if strings.ToLower(response) == "y" {
-				if err := session.KillSession(sessionName); err != nil {
+				if err := session.KillProjectSession(scratchProject); err != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to kill tmux session: %v\n", err)
 				} else {
 					fmt.Printf("\033[32m✓\033[0m Tmux session killed\n")
@@ -204,7 +225,7 @@ func runScratchDelete(cmd *cobra.Command, args []string) {
 			}
 		} else {
 			// Force flag: auto-kill session
-			if err := session.KillSession(sessionName); err != nil {
+			if err := session.KillProjectSession(scratchProject); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to kill tmux session: %v\n", err)
 			} else {
 				fmt.Printf("\033[32m✓\033[0m Tmux session killed\n")
@@ -212,14 +233,38 @@ func runScratchDelete(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Delete directory
-	if err := os.RemoveAll(scratchPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to delete scratch project: %v\n", err)
-		os.Exit(1)
+	if scratchDeletePermanent {
+		// Delete directory
+		if err := os.RemoveAll(scratchPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to delete scratch project: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("\033[32m✓\033[0m Deleted: %s\n", scratchPath)
+	} else {
+		item, err := trash.Move(trash.Item{
+			Kind:         trash.KindScratch,
+			Name:         projectName,
+			OriginalPath: scratchPath,
+			Session:      sessionState,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Use --permanent to delete without the trash.\n")
+			os.Exit(1)
+		}
+
+		fmt.Printf("\033[32m✓\033[0m Moved to trash: %s\n", item.ID)
+
+		tx := journal.Begin("scratch delete", projectName)
+		tx.Record(journal.Step{Kind: journal.StepTrash, TrashID: item.ID, From: scratchPath})
+		commitJournal(tx)
 	}
 
-	fmt.Printf("\033[32m✓\033[0m Deleted: %s\n", scratchPath)
 	fmt.Printf("\n\033[32m✓\033[0m Scratch project '%s' deleted successfully\n", projectName)
+	if !scratchDeletePermanent {
+		fmt.Printf("Undo with: pk undo (or later: pk trash restore %s)\n", projectName)
+	}
 }
 
 func runScratchList(cmd *cobra.Command, args []string) {
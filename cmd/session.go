@@ -7,9 +7,12 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/datakaicr/pk/pkg/activity"
 	"github.com/datakaicr/pk/pkg/cache"
 	"github.com/datakaicr/pk/pkg/config"
 	"github.com/datakaicr/pk/pkg/context"
+	"github.com/datakaicr/pk/pkg/hooks"
+	"github.com/datakaicr/pk/pkg/paths"
 	"github.com/datakaicr/pk/pkg/session"
 	"github.com/spf13/cobra"
 )
@@ -19,12 +22,13 @@ var sessionCmd = &cobra.Command{
 	Short: "Open project in tmux session (requires tmux)",
 	Long: `Open a project in a tmux session with optional custom layouts.
 
-If no project is specified, displays an interactive fzf selector.
+If no project is specified, displays an interactive selector (fzf, or
+the builtin picker when fzf is not installed; see --picker).
 If a project name is provided, opens that project directly.
 
 Requires:
   - tmux: brew install tmux (macOS) or apt install tmux (Linux)
-  - fzf: brew install fzf (macOS) or apt install fzf (Linux)
+  - fzf (optional): brew install fzf (macOS) or apt install fzf (Linux)
 
 Custom layouts can be configured in .project.toml:
 
@@ -36,9 +40,31 @@ windows = [
     {name = "server", command = "npm run dev"}
 ]
 
+Windows can also be split into panes. Each pane after the first splits
+the pane created before it:
+
+[[tmux.windows]]
+name = "code"
+layout = "main-vertical"
+focus = true
+env = {PORT = "3000"}
+panes = [
+    {command = "nvim", focus = true},
+    {command = "go test ./...", split = "horizontal", size = "40%"},
+    {command = "tail -f app.log", split = "vertical"}
+]
+
+Window indexes follow tmux's base-index and pane-base-index options.
+Use --reset to re-apply the configured layout to a running session;
+its windows are replaced rather than duplicated.
+
+--tag limits the selector to projects carrying a tag.
+
 Example:
   pk session              # Interactive selector
-  pk session dojo         # Open dojo project directly`,
+  pk session --tag q4-focus
+  pk session dojo         # Open dojo project directly
+  pk session dojo --reset # Rebuild dojo's windows from .project.toml`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		return session.CheckTmux()
 	},
@@ -46,8 +72,85 @@ Example:
 	ValidArgsFunction: validAllProjectNames,
 }
 
+var sessionSaveCmd = &cobra.Command{
+	Use:   "save [project]",
+	Short: "Save the layout of running project sessions",
+	Long: `Capture windows, panes, working directories and running commands of
+pk-managed tmux sessions so they can be rebuilt after a reboot.
+
+Snapshots are stored in ~/.local/state/pk/sessions/<session>.json.
+Without arguments, the current tmux session is saved.
+
+Example:
+  pk session save              # Save the current session
+  pk session save dojo         # Save the dojo session
+  pk session save --all        # Save every pk-managed session`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run:               runSessionSave,
+	ValidArgsFunction: validAllProjectNames,
+}
+
+var sessionRestoreCmd = &cobra.Command{
+	Use:   "restore [project]",
+	Short: "Rebuild sessions from saved snapshots",
+	Long: `Recreate tmux sessions captured with 'pk session save'.
+
+Windows, panes and working directories are rebuilt and commands that
+were running in the foreground are started again. Sessions that are
+already running are skipped.
+
+With --write-config the captured layout is also written to the
+project's [tmux] table so future 'pk session' calls use it.
+
+Example:
+  pk session restore                 # List saved snapshots
+  pk session restore dojo            # Restore and switch to dojo
+  pk session restore --all           # Restore every saved session
+  pk session restore dojo --write-config`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run:               runSessionRestore,
+	ValidArgsFunction: validSavedSessionNames,
+}
+
+var sessionHookCmd = &cobra.Command{
+	Use:    "hook <event> <session>",
+	Short:  "Run a lifecycle hook for a session (used by tmux hooks)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	Run:    runSessionHook,
+}
+
+var (
+	sessionReset       bool
+	sessionSaveAll     bool
+	sessionRestoreAll  bool
+	sessionWriteConfig bool
+	sessionTags        []string
+)
+
 func init() {
 	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionSaveCmd)
+	sessionCmd.AddCommand(sessionRestoreCmd)
+	sessionCmd.AddCommand(sessionHookCmd)
+
+	sessionCmd.Flags().BoolVar(&sessionReset, "reset", false,
+		"Re-apply the project's layout to its running session")
+	sessionCmd.Flags().StringVar(&pickerMode, "picker", "auto",
+		"Interactive picker: auto, fzf or builtin")
+	sessionCmd.Flags().StringSliceVarP(&sessionTags, "tag", "t", nil,
+		"Only offer projects with this tag in the selector (repeatable)")
+	sessionCmd.RegisterFlagCompletionFunc("tag", validTagNames)
+	sessionSaveCmd.Flags().BoolVar(&sessionSaveAll, "all", false,
+		"Save every pk-managed session")
+	sessionRestoreCmd.Flags().BoolVar(&sessionRestoreAll, "all", false,
+		"Restore every saved session")
+	sessionRestoreCmd.Flags().BoolVar(&sessionWriteConfig, "write-config", false,
+		"Write the restored layout into the project's [tmux] table")
 }
 
 func runSession(cmd *cobra.Command, args []string) {
@@ -76,8 +179,8 @@ func runSession(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Combine projects and scratch
-	allProjects := append(projects, scratchProjects...)
+	// Combine projects, their subprojects and scratch
+	allProjects := append(config.WithSubprojects(projects), scratchProjects...)
 
 	var selectedProject *config.Project
 
@@ -97,8 +200,14 @@ func runSession(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 	} else {
+		candidates := filterByTags(allProjects, sessionTags)
+		if len(candidates) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: No projects tagged %s\n", strings.Join(sessionTags, ", "))
+			os.Exit(1)
+		}
+
 		// Interactive selection with fzf
-		selectedProject = selectProjectWithFzf(allProjects)
+		selectedProject = selectProjectWithFzf(candidates)
 		if selectedProject == nil {
 			// User cancelled
 			return
@@ -106,11 +215,20 @@ func runSession(cmd *cobra.Command, args []string) {
 	}
 
 	// Record project access
-	cache.RecordAccess(selectedProject.ProjectInfo.ID, selectedProject.Path)
+	recordVisit(selectedProject, "session")
 
 	// Switch context if configured
 	context.Switch(selectedProject)
 
+	// Rebuild the running session's layout when requested
+	if sessionReset {
+		if err := session.ResetSession(selectedProject); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to reset session: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create or switch to session
 	if err := session.CreateSession(selectedProject); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to create session: %v\n", err)
@@ -155,61 +273,31 @@ func findScratchProjects(scratchDir string) ([]*config.Project, error) {
 }
 
 func selectProjectWithFzf(projects []*config.Project) *config.Project {
-	// Check if fzf is installed
-	if _, err := exec.LookPath("fzf"); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: fzf is required for interactive selection\n")
-		fmt.Fprintf(os.Stderr, "Install: brew install fzf (macOS) or apt install fzf (Linux)\n")
-		fmt.Fprintf(os.Stderr, "\nAlternatively, specify a project: pk session <name>\n")
-		os.Exit(1)
-	}
-
-	// Get list of existing sessions
-	existingSessions, _ := session.ListSessions()
-	sessionSet := make(map[string]bool)
-	for _, s := range existingSessions {
-		sessionSet[s] = true
-	}
-
-	// Build fzf input
-	var builder strings.Builder
 	projectMap := make(map[string]*config.Project)
-
 	for _, p := range projects {
-		// Format: "project-id    [owner]    status    [session-indicator]"
-		owner := p.GetOwner()
-		if owner == "" {
-			owner = "none"
-		}
-		status := p.ProjectInfo.Status
-		if status == "" {
-			status = "unknown"
-		}
-
-		sessionName := session.SanitizeSessionName(p.ProjectInfo.ID)
-		sessionIndicator := ""
-		if sessionSet[sessionName] {
-			sessionIndicator = "●" // Indicates active session
-		}
-
-		line := fmt.Sprintf("%s\t[%s]\t%s\t%s\n", p.ProjectInfo.ID, owner, status, sessionIndicator)
-		builder.WriteString(line)
 		projectMap[p.ProjectInfo.ID] = p
 	}
 
-	// Run fzf
-	fzfCmd := exec.Command("fzf",
+	// Native picker when fzf is missing or --picker builtin
+	if useBuiltinPicker() {
+		projectID := runBuiltinPicker(projectPickerLines(projects), false, "⚡ Project: ", "● = Active Session")
+		return projectMap[projectID]
+	}
+
+	// Run fzf with the project card as preview and action keys
+	fzfArgs := []string{
 		"--height", "60%",
 		"--reverse",
 		"--border",
 		"--ansi",
-		"--tabstop=40",
+		"--tabstop=24",
 		"--prompt", "⚡ Project: ",
-		"--preview", "echo 'Name: {1}\\nOwner: {2}\\nStatus: {3}\\nSession: {4}'",
-		"--preview-window", "right:30%:wrap",
-		"--header", "● = Active Session",
-	)
+		"--preview-window", "right:50%:wrap",
+		"--header", "● = Active Session | " + pickerKeysHeader,
+	}
+	fzfCmd := exec.Command("fzf", append(fzfArgs, pickerBindings("")...)...)
 
-	fzfCmd.Stdin = strings.NewReader(builder.String())
+	fzfCmd.Stdin = strings.NewReader(projectPickerLines(projects))
 	fzfCmd.Stderr = os.Stderr
 
 	output, err := fzfCmd.Output()
@@ -228,3 +316,258 @@ func selectProjectWithFzf(projects []*config.Project) *config.Project {
 	projectID := strings.Fields(selection)[0]
 	return projectMap[projectID]
 }
+
+// loadAllProjects returns projects from all roots, with their subprojects,
+// plus scratch pseudo-projects
+func loadAllProjects() ([]*config.Project, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+		filepath.Join(homeDir, "scriptorium"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	scratchProjects, _ := findScratchProjects(filepath.Join(homeDir, "scratch"))
+	return append(config.WithSubprojects(projects), scratchProjects...), nil
+}
+
+// projectForSession returns the project whose ID maps to a tmux session name
+func projectForSession(sessionName string, projects []*config.Project) *config.Project {
+	for _, p := range projects {
+		if session.SanitizeSessionName(p.ProjectInfo.ID) == sessionName {
+			return p
+		}
+	}
+	return nil
+}
+
+func runSessionSave(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Determine which sessions to save
+	var targets []string
+	switch {
+	case sessionSaveAll:
+		running, _ := session.ListSessions()
+		for _, name := range running {
+			if name != "" && projectForSession(name, projects) != nil {
+				targets = append(targets, name)
+			}
+		}
+	case len(args) > 0:
+		targets = []string{session.SanitizeSessionName(strings.ToLower(args[0]))}
+	default:
+		current, err := session.CurrentSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Not inside tmux. Specify a project or use --all\n")
+			os.Exit(1)
+		}
+		targets = []string{current}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No pk-managed sessions running")
+		return
+	}
+
+	saved := 0
+	for _, name := range targets {
+		project := projectForSession(name, projects)
+		if project == nil {
+			fmt.Fprintf(os.Stderr, "Warning: Session '%s' does not belong to a pk project, skipping\n", name)
+			continue
+		}
+
+		if !session.SessionExists(name) {
+			fmt.Fprintf(os.Stderr, "Warning: Session '%s' is not running, skipping\n", name)
+			continue
+		}
+
+		state, err := session.CaptureSession(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to capture '%s': %v\n", name, err)
+			continue
+		}
+		state.ProjectID = project.ProjectInfo.ID
+		state.ProjectPath = project.Path
+
+		if err := session.SaveState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to save '%s': %v\n", name, err)
+			continue
+		}
+
+		panes := 0
+		for _, w := range state.Windows {
+			panes += len(w.Panes)
+		}
+		fmt.Printf("\033[32m✓\033[0m Saved %s (%d windows, %d panes)\n", name, len(state.Windows), panes)
+		saved++
+	}
+
+	if saved == 0 {
+		os.Exit(1)
+	}
+}
+
+func runSessionRestore(cmd *cobra.Command, args []string) {
+	var states []*session.SessionState
+
+	switch {
+	case sessionRestoreAll:
+		all, err := session.ListStates()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to read saved sessions: %v\n", err)
+			os.Exit(1)
+		}
+		states = all
+	case len(args) > 0:
+		state, err := session.LoadState(session.SanitizeSessionName(strings.ToLower(args[0])))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		states = []*session.SessionState{state}
+	default:
+		printSavedSessions()
+		return
+	}
+
+	if len(states) == 0 {
+		fmt.Println("No saved sessions")
+		return
+	}
+
+	projects, _ := loadAllProjects()
+	resolver, _ := paths.NewResolver()
+
+	restored := 0
+	for _, state := range states {
+		// Prefer live project metadata, fall back to the snapshot's path
+		project := projectForSession(state.Name, projects)
+		if project == nil {
+			projectPath := state.ProjectPath
+			if resolver != nil {
+				if healed, _, err := resolver.ValidatePath(state.ProjectID, state.ProjectPath); err == nil {
+					projectPath = healed
+				}
+			}
+			project = &config.Project{Path: projectPath}
+			project.ProjectInfo.ID = state.ProjectID
+			project.ProjectInfo.Name = state.ProjectID
+		}
+
+		if session.SessionExists(state.Name) {
+			fmt.Printf("Session '%s' is already running, skipping\n", state.Name)
+			continue
+		}
+
+		if err := session.RestoreSession(state, project); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to restore '%s': %v\n", state.Name, err)
+			continue
+		}
+		fmt.Printf("\033[32m✓\033[0m Restored %s (%d windows)\n", state.Name, len(state.Windows))
+		restored++
+
+		if sessionWriteConfig {
+			if err := writeSessionLayout(project, state); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to write layout for '%s': %v\n", state.Name, err)
+			} else {
+				fmt.Printf("  Layout written to %s\n", filepath.Join(project.Path, ".project.toml"))
+			}
+		}
+	}
+
+	// Switch directly when a single session was requested
+	if len(args) > 0 && restored == 1 {
+		if err := session.SwitchSession(states[0].Name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to switch session: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// writeSessionLayout stores a captured layout in the project's [tmux] table
+func writeSessionLayout(project *config.Project, state *session.SessionState) error {
+	onDisk, err := config.LoadProject(filepath.Join(project.Path, ".project.toml"))
+	if err != nil {
+		return err
+	}
+
+	onDisk.Tmux.Layout = ""
+	onDisk.Tmux.Windows = state.TmuxWindows(onDisk.Path)
+
+	if err := config.SaveProject(onDisk); err != nil {
+		return err
+	}
+
+	hooks.InvalidateCache()
+	return nil
+}
+
+func printSavedSessions() {
+	states, err := session.ListStates()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read saved sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(states) == 0 {
+		fmt.Println("No saved sessions")
+		fmt.Println("\nSave one with:")
+		fmt.Println("  pk session save [project|--all]")
+		return
+	}
+
+	fmt.Println("Saved sessions:")
+	fmt.Println()
+	for _, state := range states {
+		running := ""
+		if session.SessionExists(state.Name) {
+			running = "●"
+		}
+		fmt.Printf("  %-25s %2d windows  %s  %s\n",
+			state.Name, len(state.Windows), state.SavedAt.Format("2006-01-02 15:04"), running)
+	}
+	fmt.Println()
+	fmt.Println("Restore with:")
+	fmt.Println("  pk session restore <name|--all>")
+}
+
+func runSessionHook(cmd *cobra.Command, args []string) {
+	event, sessionName := args[0], args[1]
+
+	switch event {
+	case session.HookSessionStart, session.HookAttach, session.HookDetach, session.HookKill:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown hook '%s'\n", event)
+		os.Exit(1)
+	}
+
+	projects, _ := loadAllProjects()
+	project := projectForSession(sessionName, projects)
+	if project == nil {
+		fmt.Fprintf(os.Stderr, "Error: Session '%s' does not belong to a pk project\n", sessionName)
+		os.Exit(1)
+	}
+
+	// This session-level detach hook shadows pk's global one, so log here too
+	if event == session.HookDetach {
+		activity.Record(activity.SwitchOut, project.ProjectInfo.ID, "tmux")
+	}
+
+	if err := session.RunHook(event, project); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s hook failed: %v\n", event, err)
+		os.Exit(1)
+	}
+}
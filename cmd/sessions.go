@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/datakaicr/pk/pkg/cache"
 	"github.com/datakaicr/pk/pkg/config"
@@ -25,7 +26,8 @@ Unlike 'pk session' which shows ALL projects, 'pk sessions' only shows:
   - Perfect for quick switching between active work
 
 If a project name is provided, switches directly to that session.
-If no name is provided, shows an interactive fzf selector with active sessions only.
+If no name is provided, shows an interactive selector with active sessions only
+(fzf, or the builtin picker when fzf is not installed; see --picker).
 
 Bind this to Ctrl+b F (Shift+f) for fast access:
   bind-key F run-shell "tmux display-popup -E -w 90% -h 80% 'pk sessions'"
@@ -39,8 +41,370 @@ Examples:
 	Run: runSessions,
 }
 
+var sessionsKillCmd = &cobra.Command{
+	Use:   "kill [name]",
+	Short: "Kill a session and run its on_kill hook",
+	Long: `Kill a running tmux session.
+
+If the session belongs to a project, the global and project on_kill
+hooks run first (e.g. to stop docker compose or a local database).
+
+With --all-orphans, every pk session whose project was archived or
+deleted is killed instead.
+
+Example:
+  pk sessions kill dojo
+  pk sessions kill --all-orphans`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run:               runSessionsKill,
+	ValidArgsFunction: validActiveSessionNames,
+}
+
+var sessionsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Kill orphaned and idle sessions",
+	Long: `Kill sessions that are no longer needed.
+
+A session is pruned when:
+  - Its project was archived or deleted
+  - It had no activity for longer than --idle (tmux session_activity)
+
+Attached sessions are never pruned. Sessions that were not started by
+pk and don't match a project are left alone.
+
+Example:
+  pk sessions prune                # Kill orphaned sessions
+  pk sessions prune --idle 7d      # Also kill sessions idle for a week
+  pk sessions prune --dry-run      # Show what would be killed`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run: runSessionsPrune,
+}
+
+var sessionsRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a running session",
+	Long: `Rename a running tmux session.
+
+Sessions started by pk keep their link to the project, so hooks and
+'pk sessions tree' still resolve the project after a rename.
+
+Example:
+  pk sessions rename dojo dojo-review`,
+	Args: cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run:               runSessionsRename,
+	ValidArgsFunction: validActiveSessionNames,
+}
+
+var sessionsTreeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show sessions, windows and panes with project metadata",
+	Long: `Show every running tmux session as a tree of windows and panes.
+
+Each session lists its project (owner, status, path), and each pane
+its working directory and foreground command.
+
+Example:
+  pk sessions tree`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return session.CheckTmux()
+	},
+	Run: runSessionsTree,
+}
+
+var (
+	sessionsKillOrphans bool
+	sessionsPruneIdle   string
+	sessionsPruneDryRun bool
+)
+
 func init() {
 	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.Flags().StringVar(&pickerMode, "picker", "auto",
+		"Interactive picker: auto, fzf or builtin")
+	sessionsCmd.AddCommand(sessionsKillCmd)
+	sessionsCmd.AddCommand(sessionsPruneCmd)
+	sessionsCmd.AddCommand(sessionsRenameCmd)
+	sessionsCmd.AddCommand(sessionsTreeCmd)
+
+	sessionsKillCmd.Flags().BoolVar(&sessionsKillOrphans, "all-orphans", false,
+		"Kill every session whose project was archived or deleted")
+	sessionsPruneCmd.Flags().StringVar(&sessionsPruneIdle, "idle", "",
+		"Also kill sessions idle longer than this (e.g. 90m, 12h, 7d)")
+	sessionsPruneCmd.Flags().BoolVar(&sessionsPruneDryRun, "dry-run", false,
+		"Show sessions that would be killed without killing them")
+}
+
+func runSessionsKill(cmd *cobra.Command, args []string) {
+	if sessionsKillOrphans {
+		if len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --all-orphans does not take a session name\n")
+			os.Exit(1)
+		}
+		killOrphanSessions()
+		return
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: Specify a session name or --all-orphans\n")
+		os.Exit(1)
+	}
+
+	sessionName := session.SanitizeSessionName(args[0])
+
+	if !session.SessionExists(sessionName) {
+		fmt.Fprintf(os.Stderr, "Error: Session '%s' not found in active sessions\n", sessionName)
+		os.Exit(1)
+	}
+
+	// Resolve project metadata so project hooks apply
+	info := session.SessionInfo{Name: sessionName}
+	if sessions, err := session.ListSessionInfo(); err == nil {
+		for _, s := range sessions {
+			if s.Name == sessionName {
+				info = s
+			}
+		}
+	}
+	projects, _ := loadAllProjects()
+	project := sessionProject(info, projects)
+
+	if err := session.KillNamedSession(project, sessionName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to kill session: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\033[32m✓\033[0m Killed session: %s\n", sessionName)
+}
+
+// killOrphanSessions kills all sessions whose project is archived or deleted
+func killOrphanSessions() {
+	sessions, projects := loadSessionsAndProjects()
+
+	killed := 0
+	for _, info := range sessions {
+		reason := sessionOrphanReason(info, projects)
+		if reason == "" {
+			continue
+		}
+		killPrunedSession(info, projects, reason)
+		killed++
+	}
+
+	if killed == 0 {
+		fmt.Println("No orphaned sessions")
+	}
+}
+
+func runSessionsPrune(cmd *cobra.Command, args []string) {
+	var idle time.Duration
+	if sessionsPruneIdle != "" {
+		var err error
+		idle, err = session.ParseIdle(sessionsPruneIdle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	sessions, projects := loadSessionsAndProjects()
+	orphan := func(info session.SessionInfo) string {
+		return sessionOrphanReason(info, projects)
+	}
+
+	candidates := session.PruneCandidates(sessions, orphan, idle, time.Now())
+	if len(candidates) == 0 {
+		fmt.Println("Nothing to prune")
+		return
+	}
+
+	for _, c := range candidates {
+		if sessionsPruneDryRun {
+			fmt.Printf("  Would kill: %s (%s)\n", c.Session.Name, c.Reason)
+			continue
+		}
+		killPrunedSession(c.Session, projects, c.Reason)
+	}
+}
+
+// killPrunedSession kills a session (running on_kill hooks) and reports why
+func killPrunedSession(info session.SessionInfo, projects []*config.Project, reason string) {
+	project := sessionProject(info, projects)
+	if err := session.KillNamedSession(project, info.Name); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to kill %s: %v\n", info.Name, err)
+		return
+	}
+	fmt.Printf("\033[32m✓\033[0m Killed session: %s (%s)\n", info.Name, reason)
+}
+
+func runSessionsRename(cmd *cobra.Command, args []string) {
+	oldName := session.SanitizeSessionName(args[0])
+	newName := session.SanitizeSessionName(args[1])
+
+	if err := session.RenameSession(oldName, newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\033[32m✓\033[0m Renamed session: %s → %s\n", oldName, newName)
+}
+
+func runSessionsTree(cmd *cobra.Command, args []string) {
+	sessions, projects := loadSessionsAndProjects()
+	if len(sessions) == 0 {
+		fmt.Println("No active tmux sessions")
+		return
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	now := time.Now()
+
+	for i, info := range sessions {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		// Session line with project metadata
+		marker := "○"
+		if info.Attached {
+			marker = "\033[32m●\033[0m"
+		}
+		fmt.Printf("%s \033[1m%s\033[0m  (%d windows, active %s ago)\n",
+			marker, info.Name, info.Windows, session.FormatAge(now.Sub(info.Activity)))
+
+		if project := projectForSessionInfo(info, projects); project != nil {
+			owner := project.GetOwner()
+			if owner == "" {
+				owner = "none"
+			}
+			fmt.Printf("  Project: %s [%s] %s%s\033[0m  %s\n",
+				project.ProjectInfo.ID, owner,
+				getStatusColor(project.ProjectInfo.Status), project.ProjectInfo.Status,
+				shortenHome(project.Path, homeDir))
+		} else if info.ProjectID != "" {
+			fmt.Printf("  Project: %s \033[31m(not found)\033[0m\n", info.ProjectID)
+		}
+
+		state, err := session.CaptureSession(info.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+			continue
+		}
+
+		for w, window := range state.Windows {
+			branch, indent := "├──", "│   "
+			if w == len(state.Windows)-1 {
+				branch, indent = "└──", "    "
+			}
+
+			active := ""
+			if window.Active {
+				active = " *"
+			}
+			fmt.Printf("%s %d: %s%s\n", branch, window.Index, window.Name, active)
+
+			for p, pane := range window.Panes {
+				paneBranch := "├──"
+				if p == len(window.Panes)-1 {
+					paneBranch = "└──"
+				}
+
+				command := pane.Command
+				if command == "" {
+					command = "shell"
+				}
+				fmt.Printf("%s%s %d: %s  \033[90m%s\033[0m\n",
+					indent, paneBranch, pane.Index, command, shortenHome(pane.Path, homeDir))
+			}
+		}
+	}
+}
+
+// loadSessionsAndProjects returns running sessions and all known projects
+func loadSessionsAndProjects() ([]session.SessionInfo, []*config.Project) {
+	sessions, err := session.ListSessionInfo()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to list tmux sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	return sessions, projects
+}
+
+// projectForSessionInfo resolves a session's project, preferring the
+// project recorded on the session over its (possibly renamed) name
+func projectForSessionInfo(info session.SessionInfo, projects []*config.Project) *config.Project {
+	if info.ProjectID != "" {
+		for _, p := range projects {
+			if p.ProjectInfo.ID == info.ProjectID {
+				return p
+			}
+		}
+		return nil
+	}
+	return projectForSession(info.Name, projects)
+}
+
+// sessionProject returns the session's project or a minimal stand-in for hooks
+func sessionProject(info session.SessionInfo, projects []*config.Project) *config.Project {
+	if project := projectForSessionInfo(info, projects); project != nil {
+		return project
+	}
+
+	project := &config.Project{}
+	project.ProjectInfo.ID = info.Name
+	project.ProjectInfo.Name = info.Name
+	return project
+}
+
+// sessionOrphanReason reports why a session's project is gone, or "" if it isn't
+// Sessions without a pk project marker or matching project are not pk's to judge
+func sessionOrphanReason(info session.SessionInfo, projects []*config.Project) string {
+	project := projectForSessionInfo(info, projects)
+	if project == nil {
+		if info.ProjectID != "" {
+			return "project deleted"
+		}
+		return ""
+	}
+
+	if project.ProjectInfo.Status == "archived" {
+		return "project archived"
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		archiveDir := filepath.Join(homeDir, "archive") + string(filepath.Separator)
+		if strings.HasPrefix(project.Path, archiveDir) {
+			return "project archived"
+		}
+	}
+	if _, err := os.Stat(project.Path); os.IsNotExist(err) {
+		return "project deleted"
+	}
+
+	return ""
+}
+
+// shortenHome replaces the home directory prefix of path with ~
+func shortenHome(path, homeDir string) string {
+	if homeDir != "" && strings.HasPrefix(path, homeDir) {
+		return "~" + strings.TrimPrefix(path, homeDir)
+	}
+	return path
 }
 
 func runSessions(cmd *cobra.Command, args []string) {
@@ -76,32 +440,11 @@ func runSessions(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Also load scratch projects
+	// Also load subprojects and scratch projects
 	scratchProjects, _ := findScratchProjects(scratchDir)
-	allProjects = append(allProjects, scratchProjects...)
+	allProjects = append(config.WithSubprojects(allProjects), scratchProjects...)
 
-	// Build map of active sessions to projects
-	sessionProjects := make(map[string]*config.Project)
-	for _, sessionName := range activeSessions {
-		// Try to match session name to project
-		for _, p := range allProjects {
-			sanitizedID := session.SanitizeSessionName(p.ProjectInfo.ID)
-			if sanitizedID == sessionName {
-				sessionProjects[sessionName] = p
-				break
-			}
-		}
-
-		// If no project found, create a minimal one
-		if _, found := sessionProjects[sessionName]; !found {
-			sessionProjects[sessionName] = &config.Project{
-				Path: "",
-			}
-			sessionProjects[sessionName].ProjectInfo.ID = sessionName
-			sessionProjects[sessionName].ProjectInfo.Name = sessionName
-			sessionProjects[sessionName].ProjectInfo.Status = "active"
-		}
-	}
+	sessionProjects := activeSessionProjects(allProjects)
 
 	// If project name provided, switch directly
 	if len(args) > 0 {
@@ -126,17 +469,16 @@ func runSessions(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
+		// Record access
+		project := sessionProjects[targetSession]
+		recordVisit(project, "sessions")
+
 		// Switch to session
-		if err := session.SwitchSession(targetSession); err != nil {
+		if err := session.AttachSession(project, targetSession); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Failed to switch session: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Record access if we have project metadata
-		if project, exists := sessionProjects[targetSession]; exists {
-			cache.RecordAccess(project.ProjectInfo.ID, project.Path)
-		}
-
 		return
 	}
 
@@ -148,79 +490,77 @@ func runSessions(cmd *cobra.Command, args []string) {
 	}
 
 	// Record access
-	cache.RecordAccess(selectedProject.ProjectInfo.ID, selectedProject.Path)
+	recordVisit(selectedProject, "sessions")
 
 	// Switch context if configured
 	context.Switch(selectedProject)
 
 	// Switch to session
 	sessionName := session.SanitizeSessionName(selectedProject.ProjectInfo.ID)
-	if err := session.SwitchSession(sessionName); err != nil {
+	if err := session.AttachSession(selectedProject, sessionName); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to switch session: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func selectActiveSessionWithFzf(sessionProjects map[string]*config.Project) *config.Project {
-	// Check if fzf is installed
-	if _, err := exec.LookPath("fzf"); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: fzf is required for interactive selection\n")
-		fmt.Fprintf(os.Stderr, "Install: brew install fzf (macOS) or apt install fzf (Linux)\n")
-		fmt.Fprintf(os.Stderr, "\nAlternatively, specify a session: pk sessions <name>\n")
-		os.Exit(1)
-	}
-
-	// Load pins to show which projects are pinned
-	pins, _ := cache.ListPins()
-	pinMap := make(map[string]int)
-	for _, pin := range pins {
-		pinMap[pin.ProjectID] = pin.Slot
-	}
-
-	// Build fzf input
-	var builder strings.Builder
-	projectMap := make(map[string]*config.Project)
+// activeSessionProjects maps running session names to their projects
+// Sessions without a project get a minimal stand-in
+func activeSessionProjects(allProjects []*config.Project) map[string]*config.Project {
+	sessionProjects := make(map[string]*config.Project)
 
-	for _, p := range sessionProjects {
-		owner := p.GetOwner()
-		if owner == "" {
-			owner = "none"
+	activeSessions, _ := session.ListSessions()
+	for _, sessionName := range activeSessions {
+		// Try to match session name to project
+		for _, p := range allProjects {
+			sanitizedID := session.SanitizeSessionName(p.ProjectInfo.ID)
+			if sanitizedID == sessionName {
+				sessionProjects[sessionName] = p
+				break
+			}
 		}
 
-		status := p.ProjectInfo.Status
-		if status == "" {
-			status = "active"
+		// If no project found, create a minimal one
+		if _, found := sessionProjects[sessionName]; !found {
+			sessionProjects[sessionName] = &config.Project{
+				Path: "",
+			}
+			sessionProjects[sessionName].ProjectInfo.ID = sessionName
+			sessionProjects[sessionName].ProjectInfo.Name = sessionName
+			sessionProjects[sessionName].ProjectInfo.Status = "active"
 		}
+	}
 
-		// Show pin number if pinned
-		pinIndicator := ""
-		if slot, isPinned := pinMap[p.ProjectInfo.ID]; isPinned {
-			pinIndicator = fmt.Sprintf("[%d]", slot)
-		}
+	return sessionProjects
+}
 
-		line := fmt.Sprintf("%s%s\t[%s]\t%s\t●\n",
-			pinIndicator,
-			p.ProjectInfo.ID,
-			owner,
-			status)
-		builder.WriteString(line)
+func selectActiveSessionWithFzf(sessionProjects map[string]*config.Project) *config.Project {
+	projectMap := make(map[string]*config.Project)
+	for _, p := range sessionProjects {
 		projectMap[p.ProjectInfo.ID] = p
 	}
 
-	// Run fzf
-	fzfCmd := exec.Command("fzf",
+	// Native picker when fzf is missing or --picker builtin
+	if useBuiltinPicker() {
+		projectID := runBuiltinPicker(sessionPickerLines(sessionProjects), true, "⚡ Active Session: ", "[N] = Pinned slot")
+		return projectMap[projectID]
+	}
+
+	// Run fzf; the first column holds the bare project ID for placeholders
+	fzfArgs := []string{
 		"--height", "60%",
 		"--reverse",
 		"--border",
 		"--ansi",
-		"--tabstop=40",
+		"--delimiter", "\t",
+		"--with-nth", "2..",
+		"--tabstop=24",
 		"--prompt", "⚡ Active Session: ",
-		"--preview", "echo 'Name: {1}\\nOwner: {2}\\nStatus: {3}\\nSession: {4}'",
-		"--preview-window", "right:30%:wrap",
-		"--header", "Active tmux sessions only | [N] = Pinned slot",
-	)
+		"--preview-window", "right:50%:wrap",
+		"--header", "[N] = Pinned slot | " + pickerKeysHeader,
+	}
+	fzfCmd := exec.Command("fzf", append(fzfArgs, pickerBindings("--active")...)...)
 
-	fzfCmd.Stdin = strings.NewReader(builder.String())
+	fzfCmd.Stdin = strings.NewReader(sessionPickerLines(sessionProjects))
 	fzfCmd.Stderr = os.Stderr
 
 	output, err := fzfCmd.Output()
@@ -235,18 +575,7 @@ func selectActiveSessionWithFzf(sessionProjects map[string]*config.Project) *con
 		return nil
 	}
 
-	// Get first field (project ID, potentially with [N] prefix)
-	firstField := strings.Fields(selection)[0]
-
-	// Remove pin indicator if present [1]pk -> pk
-	projectID := firstField
-	if strings.HasPrefix(projectID, "[") {
-		// Extract ID after ]
-		parts := strings.SplitN(projectID, "]", 2)
-		if len(parts) == 2 {
-			projectID = parts[1]
-		}
-	}
-
+	// Get first (hidden) field: the project ID
+	projectID := strings.SplitN(selection, "\t", 2)[0]
 	return projectMap[projectID]
 }
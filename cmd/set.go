@@ -0,0 +1,555 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/relations"
+	"github.com/datakaicr/pk/pkg/tomledit"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <name>... path=value...",
+	Short: "Set metadata fields on projects",
+	Long: `Change fields in .project.toml of one or more projects.
+
+Arguments containing '=' are assignments, the rest are project names.
+Fields are dotted paths into .project.toml (see 'pk get <name>'):
+
+  path=value      Set a field
+  path+=a,b       Append to a list (skips values already present)
+  path-=a         Remove from a list
+
+Values are checked against the schema: booleans must be true/false,
+numbers must parse, [dates] must be YYYY-MM-DD and lists are
+comma-separated. Comments and formatting in the file are kept; only the
+changed lines are rewritten. project.id can't be set, use 'pk rename'.
+project.status follows the status state machine (see 'pk status').
+[relations] must name existing projects (see 'pk graph').
+
+Short names work for common fields: status, type, tags, owner, client,
+partner, role, billable, rate_type, rate, currency, deliverable,
+started, completed, description, repository, stack, domain,
+depends_on, deploys, fork_of, part_of.
+
+` + filterHelp + `
+Changes are journaled, so 'pk undo' puts the old files back.
+
+Examples:
+  pk set app status=paused tech.stack+=rust consultant.billable=true
+  pk set app tech.stack-=python
+  pk set web depends_on+=api,db
+  pk set app api-gateway client="Acme Corp" --dry-run
+  pk set --filter 'client=Acme and status=active' status=completed completed=2025-06-30`,
+	Run:               runSet,
+	ValidArgsFunction: validSetArgs,
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <name> [path]",
+	Short: "Print metadata fields of a project",
+	Long: `Print a field of a project's .project.toml.
+
+A field path prints its value (lists one item per line), a table name
+prints all its fields, and no path prints every field that is set.
+
+Examples:
+  pk get app consultant.client_name
+  pk get app tech.stack
+  pk get app consultant
+  pk get app`,
+	Args:              cobra.RangeArgs(1, 2),
+	Run:               runGet,
+	ValidArgsFunction: validGetArgs,
+}
+
+var (
+	setFilter string
+	setYes    bool
+	setDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(getCmd)
+	addBulkFlags(setCmd, &setFilter, &setYes)
+	setCmd.Flags().BoolVar(&setDryRun, "dry-run", false, "Show the changes as a diff without writing")
+}
+
+// fieldAliases maps short names to .project.toml paths
+var fieldAliases = map[string]string{
+	"status":      "project.status",
+	"type":        "project.type",
+	"tags":        "project.tags",
+	"owner":       "consultant.ownership",
+	"client":      "consultant.client_name",
+	"partner":     "consultant.partner",
+	"role":        "consultant.my_role",
+	"billable":    "consultant.billable",
+	"rate_type":   "consultant.rate_type",
+	"rate":        "consultant.rate",
+	"currency":    "consultant.currency",
+	"deliverable": "consultant.deliverable_type",
+	"started":     "dates.started",
+	"completed":   "dates.completed",
+	"description": "notes.description",
+	"repository":  "links.repository",
+	"stack":       "tech.stack",
+	"domain":      "tech.domain",
+	"depends_on":  "relations.depends_on",
+	"deploys":     "relations.deploys",
+	"fork_of":     "relations.fork_of",
+	"part_of":     "relations.part_of",
+}
+
+// lookupField resolves a dotted path or short name to a schema field
+func lookupField(name string) (config.Field, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if path, ok := fieldAliases[name]; ok {
+		name = path
+	}
+
+	field, ok := config.LookupField(name)
+	if !ok {
+		return config.Field{}, fmt.Errorf("unknown field '%s' (see 'pk get <name>' for all fields)", name)
+	}
+	return field, nil
+}
+
+// assignment is one path=value, path+=value or path-=value argument
+type assignment struct {
+	field config.Field
+	op    string
+	value interface{}
+}
+
+func (a assignment) String() string {
+	return a.field.Path + a.op + strconv.Quote(config.FormatValue(a.value))
+}
+
+// parseAssignments splits 'pk set' arguments into project names and
+// type-checked assignments
+func parseAssignments(args []string) ([]string, []assignment, error) {
+	var names []string
+	var assignments []assignment
+
+	for _, arg := range args {
+		eq := strings.Index(arg, "=")
+		if eq < 0 {
+			names = append(names, arg)
+			continue
+		}
+
+		key, raw, op := arg[:eq], arg[eq+1:], "="
+		if strings.HasSuffix(key, "+") || strings.HasSuffix(key, "-") {
+			op = key[len(key)-1:] + "="
+			key = key[:len(key)-1]
+		}
+
+		field, err := lookupField(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if field.Path == "project.id" {
+			return nil, nil, fmt.Errorf("project.id can't be set, use 'pk rename' so paths, pins and sessions follow")
+		}
+		if op != "=" && field.Kind != config.KindList {
+			return nil, nil, fmt.Errorf("%s is a %s, %s only works on lists", field.Path, field.Kind, op)
+		}
+
+		value, err := field.Parse(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, known := statusMachine().State(raw); field.Path == "project.status" && !known {
+			return nil, nil, fmt.Errorf("unknown status '%s' (known: %s)", raw, strings.Join(statusMachine().Names(), ", "))
+		}
+		assignments = append(assignments, assignment{field: field, op: op, value: value})
+	}
+
+	if len(assignments) == 0 {
+		return nil, nil, fmt.Errorf("nothing to set (use path=value, e.g. status=paused)")
+	}
+	return names, assignments, nil
+}
+
+// setPlan is the edit 'pk set' will make to one project
+type setPlan struct {
+	tomlPath string
+	before   []byte
+	after    []byte
+	changes  []string
+}
+
+// planSet applies assignments to a project's .project.toml in memory
+func planSet(p *config.Project, assignments []assignment) (*setPlan, error) {
+	plan := &setPlan{tomlPath: filepath.Join(p.Path, ".project.toml")}
+
+	data, err := os.ReadFile(plan.tomlPath)
+	if err != nil {
+		return nil, err
+	}
+	plan.before, plan.after = data, data
+
+	project, err := config.DecodeProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .project.toml: %w", err)
+	}
+
+	// Several assignments to one field show as a single old → new
+	var changed []config.Field
+	original := make(map[string]interface{})
+
+	for _, a := range assignments {
+		old := a.field.Get(project)
+		value := a.value
+
+		// Status changes follow the state machine and record their history
+		if a.field.Path == "project.status" {
+			to := value.(string)
+			if to == project.ProjectInfo.Status {
+				continue
+			}
+			if err := statusMachine().Check(project.ProjectInfo.Status, to); err != nil {
+				return nil, err
+			}
+			if state, _ := statusMachine().State(to); movesProject(state, p.Path) {
+				return nil, fmt.Errorf("%s moves the project, use 'pk status %s %s'", to, p.ProjectInfo.ID, to)
+			}
+
+			for _, f := range []string{"project.status", "dates.completed"} {
+				if _, seen := original[f]; !seen {
+					field, _ := config.LookupField(f)
+					original[f] = field.Get(project)
+					changed = append(changed, field)
+				}
+			}
+			plan.after = applyStatus(plan.after, project, to, time.Now().Format("2006-01-02"))
+			continue
+		}
+
+		switch a.op {
+		case "+=":
+			value = appendMissing(old.([]string), a.value.([]string))
+		case "-=":
+			value = removeAll(old.([]string), a.value.([]string))
+		}
+
+		if config.FormatValue(old) == config.FormatValue(value) {
+			continue
+		}
+
+		if _, seen := original[a.field.Path]; !seen {
+			original[a.field.Path] = old
+			changed = append(changed, a.field)
+		}
+		a.field.Set(project, value)
+		plan.after = tomledit.Set(plan.after, a.field.Table, a.field.Key, tomledit.Literal(value))
+	}
+
+	for _, f := range changed {
+		old, value := config.FormatValue(original[f.Path]), config.FormatValue(f.Get(project))
+		if old == value {
+			continue
+		}
+		if old == "" {
+			old = "(unset)"
+		}
+		if value == "" {
+			value = "(unset)"
+		}
+		plan.changes = append(plan.changes, fmt.Sprintf("%s: %s → %s", f.Path, old, value))
+	}
+
+	// Never write a file pk can't read back
+	if _, err := config.DecodeProject(plan.after); err != nil {
+		return nil, fmt.Errorf("edit would produce invalid TOML: %w", err)
+	}
+	return plan, nil
+}
+
+// appendMissing adds the items not already in list
+func appendMissing(list, items []string) []string {
+	result := append([]string{}, list...)
+	for _, item := range items {
+		if !hasValue(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// removeAll drops every occurrence of items from list
+func removeAll(list, items []string) []string {
+	result := []string{}
+	for _, v := range list {
+		if !hasValue(items, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func runSet(cmd *cobra.Command, args []string) {
+	names, assignments, err := parseAssignments(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	selected := requireSelection(selectProjects(names, setFilter, projects))
+
+	graph := relations.Build(projects)
+	if err := checkRelationTargets(assignments, graph); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	plans := make(map[*config.Project]*setPlan)
+	planErrs := make(map[*config.Project]error)
+	for _, p := range selected {
+		if err := checkSelfRelation(p, assignments); err != nil {
+			planErrs[p] = err
+			continue
+		}
+		plans[p], planErrs[p] = planSet(p, assignments)
+	}
+
+	if setDryRun {
+		for _, p := range selected {
+			printSetDiff(p, plans[p], planErrs[p])
+		}
+		return
+	}
+
+	describe := func(p *config.Project) string {
+		if planErrs[p] != nil {
+			return "\033[31m" + planErrs[p].Error() + "\033[0m"
+		}
+		if len(plans[p].changes) == 0 {
+			return "(no change)"
+		}
+		return strings.Join(plans[p].changes, ", ")
+	}
+
+	// A single named project needs no confirmation, like 'pk edit'
+	if !confirmPlan("Update", selected, describe, setYes || !isBulk(names, setFilter)) {
+		return
+	}
+
+	journalArgs := bulkCommand(names, setFilter)
+	for _, a := range assignments {
+		journalArgs = append(journalArgs, a.String())
+	}
+	tx := journal.Begin("set", journalArgs...)
+
+	var results bulkResults
+	for _, p := range selected {
+		plan, err := plans[p], planErrs[p]
+		if err != nil {
+			results.report(p, err, "")
+			continue
+		}
+		if len(plan.changes) == 0 {
+			results.report(p, nil, "unchanged")
+			continue
+		}
+		err = tx.Write(plan.tomlPath, func() error {
+			return os.WriteFile(plan.tomlPath, plan.after, 0644)
+		})
+		results.report(p, err, strings.Join(plan.changes, ", "))
+	}
+
+	commitJournal(tx)
+	if results.succeeded > 0 {
+		fmt.Println("\nUndo with: pk undo")
+	}
+	results.finish(cmd, false)
+}
+
+// relationTargets returns the project IDs an assignment adds to [relations]
+func relationTargets(a assignment) []string {
+	if a.field.Table != "relations" || a.op == "-=" {
+		return nil
+	}
+	switch v := a.value.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// checkRelationTargets refuses relations to projects that don't exist
+func checkRelationTargets(assignments []assignment, graph *relations.Graph) error {
+	for _, a := range assignments {
+		for _, id := range relationTargets(a) {
+			if graph.Project(id) == nil {
+				return fmt.Errorf("%s: '%s' is not a known project", a.field.Path, id)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSelfRelation refuses relations from a project to itself
+func checkSelfRelation(p *config.Project, assignments []assignment) error {
+	for _, a := range assignments {
+		for _, id := range relationTargets(a) {
+			if strings.EqualFold(id, p.ProjectInfo.ID) {
+				return fmt.Errorf("%s can't refer to the project itself", a.field.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// printSetDiff shows what 'pk set --dry-run' would write to one project
+func printSetDiff(p *config.Project, plan *setPlan, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\033[31m✗\033[0m %s: %v\n", p.ProjectInfo.ID, err)
+		return
+	}
+
+	fmt.Printf("\033[1m%s\033[0m\n", plan.tomlPath)
+	diff := tomledit.Diff(plan.before, plan.after)
+	if len(diff) == 0 {
+		fmt.Println("  (no change)")
+	}
+	for _, line := range diff {
+		switch line[0] {
+		case '-':
+			fmt.Printf("\033[31m%s\033[0m\n", line)
+		case '+':
+			fmt.Printf("\033[32m%s\033[0m\n", line)
+		default:
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
+
+func runGet(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	found := findProject(args[0], projects)
+	if found == nil {
+		fmt.Fprintf(os.Stderr, "Error: Project '%s' not found\n", args[0])
+		os.Exit(1)
+	}
+	if err := checkNotSubproject(found); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Read the file itself, the cache may lag behind an edit
+	project, err := config.LoadProject(filepath.Join(found.Path, ".project.toml"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read .project.toml: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 1 {
+		for _, f := range config.SchemaFields() {
+			if value := config.FormatValue(f.Get(project)); value != "" && value != "false" && value != "0" {
+				fmt.Printf("%s = %s\n", f.Path, tomledit.Literal(f.Get(project)))
+			}
+		}
+		return
+	}
+
+	path := strings.ToLower(args[1])
+	if field, err := lookupField(path); err == nil {
+		if list, ok := field.Get(project).([]string); ok {
+			for _, item := range list {
+				fmt.Println(item)
+			}
+			return
+		}
+		fmt.Println(config.FormatValue(field.Get(project)))
+		return
+	}
+
+	// A table name prints all of its fields
+	var table []config.Field
+	for _, f := range config.SchemaFields() {
+		if f.Table == path {
+			table = append(table, f)
+		}
+	}
+	if len(table) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: unknown field '%s'\n", args[1])
+		os.Exit(1)
+	}
+	for _, f := range table {
+		fmt.Printf("%s = %s\n", f.Key, tomledit.Literal(f.Get(project)))
+	}
+}
+
+// fieldCompletions returns field paths and short names starting with prefix
+func fieldCompletions(prefix, suffix string) []string {
+	var names []string
+	for _, f := range config.SchemaFields() {
+		if strings.HasPrefix(f.Path, prefix) {
+			names = append(names, f.Path+suffix)
+		}
+	}
+	for alias := range fieldAliases {
+		if strings.HasPrefix(alias, prefix) {
+			names = append(names, alias+suffix)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validSetArgs completes project names, then field paths once one is given
+func validSetArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 && setFilter == "" {
+		return validProjectNames(cmd, args, toComplete)
+	}
+	if strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := fieldCompletions(toComplete, "=")
+	projectNames, _ := validProjectNames(cmd, args, toComplete)
+	return append(names, projectNames...), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// validGetArgs completes a project name, then a field path or table
+func validGetArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return validProjectNames(cmd, args, toComplete)
+	case 1:
+		return fieldCompletions(toComplete, ""), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
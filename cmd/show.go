@@ -6,7 +6,10 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/datakaicr/pk/pkg/billing"
 	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/relations"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +49,7 @@ func runShow(cmd *cobra.Command, args []string) {
 
 	// Find matching project
 	var found *config.Project
-	for _, p := range projects {
+	for _, p := range config.WithSubprojects(projects) {
 		if strings.ToLower(p.ProjectInfo.ID) == projectName ||
 			strings.ToLower(p.ProjectInfo.Name) == projectName {
 			found = p
@@ -60,10 +63,10 @@ func runShow(cmd *cobra.Command, args []string) {
 	}
 
 	// Print detailed info
-	printDetailedProject(found)
+	printDetailedProject(found, relations.Build(projects))
 }
 
-func printDetailedProject(p *config.Project) {
+func printDetailedProject(p *config.Project, graph *relations.Graph) {
 	// Header
 	fmt.Printf("\n")
 	fmt.Printf("═══════════════════════════════════════════════════════════════\n")
@@ -76,7 +79,23 @@ func printDetailedProject(p *config.Project) {
 	statusColor := getStatusColor(p.ProjectInfo.Status)
 	fmt.Printf("  Status:      %s%s\033[0m\n", statusColor, p.ProjectInfo.Status)
 	fmt.Printf("  Type:        %s\n", p.ProjectInfo.Type)
+	if len(p.ProjectInfo.Tags) > 0 {
+		fmt.Printf("  Tags:        %s\n", strings.Join(p.ProjectInfo.Tags, ", "))
+	}
 	fmt.Printf("  Path:        %s\n", p.Path)
+	if info, err := os.Stat(filepath.Join(p.Path, backup.ColdBundle)); err == nil {
+		fmt.Printf("  Storage:     compressed, %s (expanded by pk restore)\n", formatBytes(info.Size()))
+	}
+	if p.IsSubproject() {
+		fmt.Printf("  Parent:      %s\n", p.Parent)
+	}
+	if len(p.Subprojects) > 0 {
+		var subs []string
+		for _, s := range p.Subprojects {
+			subs = append(subs, fmt.Sprintf("%s (%s)", s.Name, s.Path))
+		}
+		fmt.Printf("  Subprojects: %s\n", strings.Join(subs, ", "))
+	}
 	fmt.Printf("\n")
 
 	// Ownership
@@ -102,6 +121,9 @@ func printDetailedProject(p *config.Project) {
 		if p.GetMyRole() != "" {
 			fmt.Printf("  Role:        %s\n", p.GetMyRole())
 		}
+		if p.Consultant.Billable {
+			fmt.Printf("  Billing:     %s\n", billingSummary(p))
+		}
 		fmt.Printf("\n")
 	}
 
@@ -146,6 +168,8 @@ func printDetailedProject(p *config.Project) {
 		fmt.Printf("\n")
 	}
 
+	printRelations(p, graph)
+
 	// Description
 	if p.Notes.Description != "" {
 		fmt.Printf("\033[1mDescription\033[0m\n")
@@ -155,3 +179,76 @@ func printDetailedProject(p *config.Project) {
 
 	fmt.Printf("═══════════════════════════════════════════════════════════════\n\n")
 }
+
+// printRelations lists the projects p refers to and the ones referring to it
+func printRelations(p *config.Project, graph *relations.Graph) {
+	outbound, inbound := graph.Outbound(p.ProjectInfo.ID), graph.Inbound(p.ProjectInfo.ID)
+	if len(outbound) == 0 && len(inbound) == 0 {
+		return
+	}
+
+	fmt.Printf("\033[1mRelations\033[0m\n")
+	for _, kind := range relations.Kinds {
+		var ids []string
+		for _, e := range outbound {
+			if e.Kind == kind {
+				ids = append(ids, relatedProject(graph, e.To))
+			}
+		}
+		printRelationLine(relations.Label(kind), ids)
+	}
+	for _, kind := range relations.Kinds {
+		var ids []string
+		for _, e := range inbound {
+			if e.Kind == kind {
+				ids = append(ids, relatedProject(graph, e.From))
+			}
+		}
+		printRelationLine(relations.InboundLabel(kind), ids)
+	}
+	fmt.Printf("\n")
+}
+
+// relatedProject formats a related project ID, flagging archived or missing ones
+func relatedProject(graph *relations.Graph, id string) string {
+	related := graph.Project(id)
+	switch {
+	case related == nil:
+		return id + " \033[31m(missing)\033[0m"
+	case related.ProjectInfo.Status == "archived":
+		return related.ProjectInfo.ID + " \033[90m(archived)\033[0m"
+	}
+	return related.ProjectInfo.ID
+}
+
+func printRelationLine(label string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	label = strings.ToUpper(label[:1]) + label[1:] + ":"
+	fmt.Printf("  %-13s%s\n", label, strings.Join(ids, ", "))
+}
+
+// billingSummary describes a billable project's rate, e.g. "150.00 USD/hour"
+func billingSummary(p *config.Project) string {
+	rateType := p.Consultant.RateType
+	if rateType == "" {
+		rateType = "hourly"
+	}
+	if p.Consultant.Rate == 0 {
+		return rateType + " (no rate set)"
+	}
+
+	currency := p.Consultant.Currency
+	if currency == "" {
+		currency = billing.DefaultCurrency
+	}
+
+	switch rateType {
+	case "hourly":
+		return fmt.Sprintf("%.2f %s/hour", p.Consultant.Rate, currency)
+	case "retainer":
+		return fmt.Sprintf("%.2f %s/month (retainer)", p.Consultant.Rate, currency)
+	}
+	return fmt.Sprintf("%.2f %s (%s)", p.Consultant.Rate, currency, rateType)
+}
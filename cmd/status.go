@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/lifecycle"
+	"github.com/datakaicr/pk/pkg/paths"
+	"github.com/datakaicr/pk/pkg/status"
+	"github.com/datakaicr/pk/pkg/tomledit"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <name> [state]",
+	Short: "Show or change a project's status",
+	Long: `Show a project's status, or move it to a new one.
+
+Statuses form a state machine: each status lists the statuses a project
+may move to next, and entering a status can have side effects:
+
+  set_completed     Set dates.completed to today (unless already set)
+  clear_completed   Clear dates.completed
+  move_to_archive   Move the project into ~/archive
+  move_to_projects  Move the project back into ~/projects
+
+Every change is recorded as a [[status_history]] entry in .project.toml
+and journaled for 'pk undo'. 'pk set status=...' follows the same rules
+but won't move projects.
+
+The default machine:
+
+  active        → paused, completed, archived   (clears completed, unarchives)
+  paused        → active, completed, archived   (unarchives)
+  completed     → active, archived              (sets completed)
+  archived      → active, paused                (sets completed, archives)
+  experimental  → active, paused, archived
+
+Define your own in ~/.config/pk/config.toml; the first one is the
+status of new projects:
+
+  [[status]]
+  name = "active"
+  color = "green"
+  next = ["paused", "archived"]
+  on_enter = ["clear_completed", "move_to_projects"]
+
+Examples:
+  pk status app               # Current status, allowed moves and history
+  pk status app paused
+  pk status app archived      # Same as 'pk archive app'`,
+	Args:              cobra.RangeArgs(1, 2),
+	Run:               runStatus,
+	ValidArgsFunction: validStatusArgs,
+}
+
+var statusAutoSync bool
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusAutoSync, "sync", true, "Auto-sync aliases when the project moves")
+}
+
+var (
+	loadedMachine *status.Machine
+	loadMachine   sync.Once
+)
+
+// statusMachine returns the configured status state machine
+// A broken [[status]] config falls back to the default with a warning.
+func statusMachine() *status.Machine {
+	loadMachine.Do(func() {
+		loadedMachine = status.Default()
+
+		resolver, err := paths.NewResolver()
+		if err != nil {
+			return
+		}
+		m, err := resolver.StatusMachine()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Invalid [[status]] config, using defaults: %v\n", err)
+			return
+		}
+		loadedMachine = m
+	})
+	return loadedMachine
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	found := findProject(args[0], projects)
+	if found == nil || found.ProjectInfo.Status == "scratch" {
+		fmt.Fprintf(os.Stderr, "Error: Project '%s' not found\n", args[0])
+		os.Exit(1)
+	}
+
+	m := statusMachine()
+
+	if len(args) == 1 {
+		printStatus(found, m)
+		return
+	}
+
+	to := args[1]
+	if err := checkNotSubproject(found); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := m.Check(found.ProjectInfo.Status, to); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", found.ProjectInfo.ID, err)
+		os.Exit(1)
+	}
+
+	if state, _ := m.State(to); state.Has(status.EffectArchive) {
+		warnDependents([]*config.Project{found}, projects)
+	}
+
+	tx := journal.Begin("status", found.ProjectInfo.ID, to)
+	newPath, err := changeStatus(tx, found, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\033[32m✓\033[0m %s: %s → %s%s\033[0m\n", found.ProjectInfo.ID,
+		found.ProjectInfo.Status, m.Color(to), to)
+
+	// The lookup above may have cached the old path in the background
+	cache.InvalidateCache()
+
+	if newPath != found.Path {
+		fmt.Printf("  Moved to: %s\n", newPath)
+		if statusAutoSync {
+			fmt.Printf("\nSyncing aliases...\n")
+			runSync(cmd, []string{})
+			tx.Record(journal.Step{Kind: journal.StepSync})
+		}
+	}
+
+	commitJournal(tx)
+	fmt.Println("  Undo with: pk undo")
+}
+
+// printStatus shows the current status, where it can go and its history
+func printStatus(p *config.Project, m *status.Machine) {
+	current := p.ProjectInfo.Status
+	if current == "" {
+		current = "(unset)"
+	}
+	fmt.Printf("%s: %s%s\033[0m\n", p.ProjectInfo.ID, m.Color(p.ProjectInfo.Status), current)
+
+	state, known := m.State(p.ProjectInfo.Status)
+	switch {
+	case !known:
+		fmt.Printf("  \033[33mNot a configured status\033[0m, can move to: %s\n", strings.Join(m.Names(), ", "))
+	case len(state.Next) == 0:
+		fmt.Println("  Final status")
+	default:
+		fmt.Printf("  Can move to: %s\n", strings.Join(state.Next, ", "))
+	}
+
+	if len(p.StatusHistory) > 0 {
+		fmt.Println("\nHistory:")
+		for _, change := range p.StatusHistory {
+			from := change.From
+			if from == "" {
+				from = "(unset)"
+			}
+			fmt.Printf("  %s  %s → %s\n", change.Date, from, change.To)
+		}
+	}
+}
+
+// changeStatus moves p to status to, applying the state's side effects and
+// journaling each step in tx. Returns the project's path afterwards.
+// A failure undoes this project's steps and leaves earlier ones in tx alone.
+func changeStatus(tx *journal.Tx, p *config.Project, to string) (string, error) {
+	state, _ := statusMachine().State(to)
+	savepoint := tx.Savepoint()
+
+	homeDir, _ := os.UserHomeDir()
+	projectsDir := filepath.Join(homeDir, "projects")
+	archiveDir := filepath.Join(homeDir, "archive")
+
+	path := p.Path
+	var moved string
+	var err error
+	switch {
+	case state.Has(status.EffectArchive) && !isUnder(p.Path, archiveDir):
+		moved, err = lifecycle.MoveToArchive(p, archiveDir)
+	case state.Has(status.EffectUnarchive) && isUnder(p.Path, archiveDir):
+		if backup.IsCompacted(p.Path) {
+			if err := backup.Expand(p.Path); err != nil {
+				return "", fmt.Errorf("failed to unpack cold storage: %w", err)
+			}
+			tx.Record(journal.Step{Kind: journal.StepExpand, ProjectID: p.ProjectInfo.ID, Path: p.Path, From: archiveDir})
+		}
+		moved, err = lifecycle.MoveToProjects(p, projectsDir)
+	}
+	if err != nil {
+		if rollbackErr := tx.RollbackTo(savepoint); rollbackErr != nil {
+			return "", fmt.Errorf("%w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return "", err
+	}
+	if moved != "" {
+		tx.Record(journal.Step{Kind: journal.StepMove, From: p.Path, To: moved})
+		tx.Record(journal.Step{Kind: journal.StepRelocate, ProjectID: p.ProjectInfo.ID, From: p.Path, To: moved})
+		path = moved
+	}
+
+	tomlPath := filepath.Join(path, ".project.toml")
+	err = tx.Write(tomlPath, func() error {
+		data, err := os.ReadFile(tomlPath)
+		if err != nil {
+			return err
+		}
+		project, err := config.DecodeProject(data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(tomlPath, applyStatus(data, project, to, time.Now().Format("2006-01-02")), 0644)
+	})
+	if err != nil {
+		if rollbackErr := tx.RollbackTo(savepoint); rollbackErr != nil {
+			return "", fmt.Errorf("failed to update .project.toml: %w (rollback incomplete: %v)", err, rollbackErr)
+		}
+		return "", fmt.Errorf("failed to update .project.toml, nothing was changed: %w", err)
+	}
+
+	return path, nil
+}
+
+// applyStatus rewrites project.status in .project.toml content, applies the
+// new state's date effects and appends a [[status_history]] entry.
+// project is the decoded content and is updated to match.
+func applyStatus(data []byte, project *config.Project, to, today string) []byte {
+	state, _ := statusMachine().State(to)
+	from := project.ProjectInfo.Status
+
+	data = tomledit.Set(data, "project", "status", tomledit.Literal(to))
+	project.SetStatus(to, today)
+
+	switch {
+	case state.Has(status.EffectSetCompleted) && project.Dates.Completed == "":
+		project.Dates.Completed = today
+		data = tomledit.Set(data, "dates", "completed", tomledit.Literal(today))
+	case state.Has(status.EffectClearCompleted) && project.Dates.Completed != "":
+		project.Dates.Completed = ""
+		data = tomledit.Set(data, "dates", "completed", tomledit.Literal(""))
+	}
+
+	return tomledit.AppendArrayTable(data, "status_history", [][2]string{
+		{"from", tomledit.Literal(from)},
+		{"to", tomledit.Literal(to)},
+		{"date", tomledit.Literal(today)},
+	})
+}
+
+// movesProject reports whether entering state would move a project at path
+func movesProject(state status.State, path string) bool {
+	homeDir, _ := os.UserHomeDir()
+	inArchive := isUnder(path, filepath.Join(homeDir, "archive"))
+	return (state.Has(status.EffectArchive) && !inArchive) || (state.Has(status.EffectUnarchive) && inArchive)
+}
+
+// isUnder reports whether path is inside dir
+func isUnder(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// validStatusArgs completes a project, then the statuses it can move to
+func validStatusArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return validProjectNames(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	m := statusMachine()
+	names := m.Names()
+	if projects, err := loadAllProjects(); err == nil {
+		if p := findProject(args[0], projects); p != nil {
+			if state, ok := m.State(p.ProjectInfo.Status); ok {
+				names = state.Next
+			}
+		}
+	}
+
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
@@ -62,7 +62,7 @@ func runSync(cmd *cobra.Command, args []string) {
 
 	// Generate aliases
 	fmt.Printf("Generating aliases...\n")
-	if err := shell.GenerateAliases(currentShell, projects); err != nil {
+	if err := shell.GenerateAliases(currentShell, config.WithSubprojects(projects)); err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating aliases: %v\n", err)
 		os.Exit(1)
 	}
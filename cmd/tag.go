@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Group projects with ad-hoc tags",
+	Long: `Tag projects with groups that don't fit type, owner or domain,
+e.g. "q4-focus" or "needs-upgrade". Tags live in the tags list of
+[project] in .project.toml.
+
+Tagged projects can be selected with --tag in 'pk list', 'pk session'
+and 'pk exec', and with tag=... in --filter. 'pk sync' groups their
+aliases into one section per tag.
+
+Subcommands:
+  pk tag add <tag> <name>...     # Tag projects
+  pk tag remove <tag> <name>...  # Untag projects
+  pk tag list [tag]              # List tags, or the projects with one`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <tag> <name>...",
+	Short: "Tag projects",
+	Long: `Add a tag to one or more projects.
+
+Tags are lowercase; letters, digits, '-', '_' and '.' are allowed and a
+leading '#' is dropped. Projects that already have the tag are left alone.
+` + filterHelp + `
+Changes are journaled, so 'pk undo' removes the tag again.
+
+Examples:
+  pk tag add q4-focus app api-gateway
+  pk tag add needs-upgrade --filter 'stack=python and status=active'`,
+	Args:              cobra.MinimumNArgs(1),
+	Run:               runTagAdd,
+	ValidArgsFunction: validTagArgs,
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:     "remove <tag> <name>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove a tag from projects",
+	Long: `Remove a tag from one or more projects.
+
+` + filterHelp + `
+
+Examples:
+  pk tag remove q4-focus app
+  pk tag remove q4-focus --filter tag=q4-focus   # Drop the tag everywhere`,
+	Args:              cobra.MinimumNArgs(1),
+	Run:               runTagRemove,
+	ValidArgsFunction: validTagArgs,
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list [tag]",
+	Short: "List tags, or the projects carrying one",
+	Long: `Without arguments, list every tag with the projects that carry it.
+With a tag, list only those projects.
+
+Examples:
+  pk tag list
+  pk tag list q4-focus`,
+	Args:              cobra.MaximumNArgs(1),
+	Run:               runTagList,
+	ValidArgsFunction: validTagNames,
+}
+
+var (
+	tagFilter   string
+	tagYes      bool
+	tagAutoSync bool
+)
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+
+	for _, c := range []*cobra.Command{tagAddCmd, tagRemoveCmd} {
+		addBulkFlags(c, &tagFilter, &tagYes)
+		c.Flags().BoolVar(&tagAutoSync, "sync", true, "Auto-sync aliases after tagging")
+	}
+}
+
+// parseTag normalizes a tag given on the command line
+func parseTag(raw string) (string, error) {
+	tag := config.NormalizeTag(raw)
+	if tag == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	for _, r := range tag {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r)) {
+			return "", fmt.Errorf("invalid tag '%s' (use letters, digits, '-', '_' and '.')", raw)
+		}
+	}
+	return tag, nil
+}
+
+func runTagAdd(cmd *cobra.Command, args []string) {
+	runTagChange(cmd, "+=", args)
+}
+
+func runTagRemove(cmd *cobra.Command, args []string) {
+	runTagChange(cmd, "-=", args)
+}
+
+// runTagChange adds (+=) or removes (-=) a tag on the selected projects
+// through the same plan and journal as 'pk set project.tags+=...'
+func runTagChange(cmd *cobra.Command, op string, args []string) {
+	tag, err := parseTag(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	names := args[1:]
+
+	homeDir, _ := os.UserHomeDir()
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	selected := requireSelection(selectProjects(names, tagFilter, projects))
+
+	field, _ := config.LookupField("project.tags")
+
+	plans := make(map[*config.Project]*setPlan)
+	planErrs := make(map[*config.Project]error)
+	for _, p := range selected {
+		// Tags compare case-insensitively, so match the spelling in the file
+		values := []string{tag}
+		if op == "-=" {
+			values = matchingTags(p.ProjectInfo.Tags, tag)
+		} else if p.HasTag(tag) {
+			values = nil
+		}
+		plans[p], planErrs[p] = planSet(p, []assignment{{field: field, op: op, value: values}})
+	}
+
+	action, verb := "Tag", "add"
+	if op == "-=" {
+		action, verb = "Untag", "remove"
+	}
+
+	describe := func(p *config.Project) string {
+		if planErrs[p] != nil {
+			return "\033[31m" + planErrs[p].Error() + "\033[0m"
+		}
+		if len(plans[p].changes) == 0 {
+			return "(no change)"
+		}
+		return formatTags(p.ProjectInfo.Tags) + " → " + formatTags(tagsAfter(p.ProjectInfo.Tags, op, tag))
+	}
+
+	if !confirmPlan(action, selected, describe, tagYes || !isBulk(names, tagFilter)) {
+		return
+	}
+
+	tx := journal.Begin("tag", append([]string{verb, tag}, bulkCommand(names, tagFilter)...)...)
+
+	var results bulkResults
+	for _, p := range selected {
+		plan, err := plans[p], planErrs[p]
+		if err != nil {
+			results.report(p, err, "")
+			continue
+		}
+		if len(plan.changes) == 0 {
+			results.report(p, nil, "unchanged")
+			continue
+		}
+		err = tx.Write(plan.tomlPath, func() error {
+			return os.WriteFile(plan.tomlPath, plan.after, 0644)
+		})
+		results.report(p, err, strings.Join(plan.changes, ", "))
+	}
+
+	commitJournal(tx)
+	if results.succeeded > 0 {
+		fmt.Println("\nUndo with: pk undo")
+	}
+	results.finish(cmd, tagAutoSync)
+}
+
+// tagsAfter previews a project's tags after adding or removing tag
+func tagsAfter(tags []string, op, tag string) []string {
+	if op == "-=" {
+		return removeAll(tags, matchingTags(tags, tag))
+	}
+	return appendMissing(tags, []string{tag})
+}
+
+// matchingTags returns the entries of tags that are the same tag as tag
+func matchingTags(tags []string, tag string) []string {
+	var matches []string
+	for _, t := range tags {
+		if config.NormalizeTag(t) == tag {
+			matches = append(matches, t)
+		}
+	}
+	return matches
+}
+
+// formatTags joins tags for display
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	return strings.Join(tags, ", ")
+}
+
+func runTagList(cmd *cobra.Command, args []string) {
+	homeDir, _ := os.UserHomeDir()
+	projects, err := cache.FindProjectsCached(
+		filepath.Join(homeDir, "projects"),
+		filepath.Join(homeDir, "archive"),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 1 {
+		tagged := filterByTags(projects, args)
+		if len(tagged) == 0 {
+			fmt.Printf("No projects tagged '%s'\n", config.NormalizeTag(args[0]))
+			return
+		}
+		for _, p := range tagged {
+			statusColor := getStatusColor(p.ProjectInfo.Status)
+			fmt.Printf("  %-25s %s%s\033[0m\n", p.ProjectInfo.ID, statusColor, p.ProjectInfo.Status)
+		}
+		return
+	}
+
+	byTag := projectsByTag(projects)
+	if len(byTag) == 0 {
+		fmt.Println("No tags yet")
+		fmt.Println("\nAdd one with: pk tag add <tag> <name>")
+		return
+	}
+
+	for _, tag := range sortedTags(byTag) {
+		var ids []string
+		for _, p := range byTag[tag] {
+			ids = append(ids, p.ProjectInfo.ID)
+		}
+		fmt.Printf("\033[36m%-20s\033[0m %2d  %s\n", tag, len(ids), strings.Join(ids, ", "))
+	}
+}
+
+// projectsByTag groups projects under each normalized tag they carry
+func projectsByTag(projects []*config.Project) map[string][]*config.Project {
+	byTag := make(map[string][]*config.Project)
+	for _, p := range projects {
+		seen := make(map[string]bool)
+		for _, t := range p.ProjectInfo.Tags {
+			tag := config.NormalizeTag(t)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			byTag[tag] = append(byTag[tag], p)
+		}
+	}
+	return byTag
+}
+
+// sortedTags returns the tags of a projectsByTag result in order
+func sortedTags(byTag map[string][]*config.Project) []string {
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// filterByTags keeps the projects carrying every one of tags
+func filterByTags(projects []*config.Project, tags []string) []*config.Project {
+	if len(tags) == 0 {
+		return projects
+	}
+
+	var filtered []*config.Project
+	for _, p := range projects {
+		all := true
+		for _, tag := range tags {
+			if !p.HasTag(tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// validTagArgs completes a tag, then project names
+func validTagArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return validTagNames(cmd, args, toComplete)
+	}
+	return validProjectNames(cmd, args, toComplete)
+}
@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/paths"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/tomledit"
+	"github.com/datakaicr/pk/pkg/worklog"
+	"github.com/spf13/cobra"
+)
+
+var timeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Track time spent per project from session activity",
+	Long: `Time tracking built from pk's activity log.
+
+Every switch into a project through 'pk session', 'pk sessions', 'pk jump',
+'pk z' or 'pk ui' is logged to ~/.local/state/pk/activity.jsonl. To log
+switching sessions with tmux itself and detaching as well, turn on the
+tmux hooks with 'pk time hooks on'.
+
+For idle detection add the tick to your tmux status line; it logs an idle
+event when no key was pressed for --idle and picks up again on the next
+keypress:
+
+  set -g status-right '#(pk time tick)'
+
+Examples:
+  pk time report                         # Last 7 days by project
+  pk time report --by client --from 2025-01-01 --to 2025-01-31
+  pk time report --by week --format csv > hours.csv`,
+}
+
+var timeReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize tracked hours",
+	Long: `Summarize tracked hours by project, client or ISO week.
+
+--from and --to are inclusive dates (YYYY-MM-DD); the default range is the
+last 7 days. Stretches without any logged event are cut at --max, so a
+missed detach (crash, reboot) can't add a whole night.
+
+Hours entered with 'pk log' are included; --source tracked or --source
+logged shows only one kind.
+
+Examples:
+  pk time report
+  pk time report --by client --from 2025-01-01 --to 2025-01-31
+  pk time report --by week --format json`,
+	Args: cobra.NoArgs,
+	Run:  runTimeReport,
+}
+
+var timeEventCmd = &cobra.Command{
+	Use:    "event <switch|detach> <session>",
+	Short:  "Log a tmux session event (used by tmux hooks)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	Run:    runTimeEvent,
+}
+
+var timeHooksCmd = &cobra.Command{
+	Use:   "hooks [on|off]",
+	Short: "Turn the tmux hooks that track session switches on or off",
+	Long: `Turn the global tmux hooks reporting to 'pk time' on or off.
+
+With the hooks on, tmux logs session switches, attaches and detaches made
+without pk. They are set at index 50 of client-session-changed,
+client-attached and client-detached, so hooks you set yourself (index 0)
+are left alone.
+
+'on' sets [time] tmux_hooks = true in ~/.config/pk/config.toml and installs
+the hooks in the running tmux server; every 'pk session' installs them
+again after a server restart. 'off' clears the setting and removes them.
+Without an argument, shows whether they are on.
+
+To remove the hooks by hand:
+
+  tmux set-hook -gu 'client-session-changed[50]'
+  tmux set-hook -gu 'client-attached[50]'
+  tmux set-hook -gu 'client-detached[50]'
+
+Examples:
+  pk time hooks on
+  pk time hooks off`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	Run:       runTimeHooks,
+}
+
+var timeTickCmd = &cobra.Command{
+	Use:   "tick",
+	Short: "Log idle/resume from tmux client activity (for status-right)",
+	Long: `Check tmux client activity and log idle or resume events.
+
+Meant to run from the tmux status line, which calls it every
+status-interval seconds. Prints nothing.
+
+  set -g status-right '#(pk time tick)'`,
+	Args: cobra.NoArgs,
+	Run:  runTimeTick,
+}
+
+var (
+	timeFrom   string
+	timeTo     string
+	timeBy     string
+	timeFormat string
+	timeMax    time.Duration
+	timeSource string
+	timeIdle   time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(timeCmd)
+	timeCmd.AddCommand(timeReportCmd)
+	timeCmd.AddCommand(timeEventCmd)
+	timeCmd.AddCommand(timeTickCmd)
+	timeCmd.AddCommand(timeHooksCmd)
+
+	timeReportCmd.Flags().StringVar(&timeFrom, "from", "", "First day to include (YYYY-MM-DD, default: 6 days ago)")
+	timeReportCmd.Flags().StringVar(&timeTo, "to", "", "Last day to include (YYYY-MM-DD, default: today)")
+	timeReportCmd.Flags().StringVar(&timeBy, "by", "project", "Group by: project, client or week")
+	timeReportCmd.Flags().StringVar(&timeFormat, "format", "table", "Output format: table, csv or json")
+	timeReportCmd.Flags().DurationVar(&timeMax, "max", 8*time.Hour, "Longest stretch counted without a logged event (0 = no limit)")
+	timeReportCmd.Flags().StringVar(&timeSource, "source", "all", "Hours to count: all, tracked (sessions) or logged (pk log)")
+	timeTickCmd.Flags().DurationVar(&timeIdle, "idle", 10*time.Minute, "Inactivity before time stops counting")
+}
+
+// recordVisit notes a switch into a project for frecency ranking and time tracking
+func recordVisit(p *config.Project, source string) {
+	cache.RecordAccess(p.ProjectInfo.ID, p.Path)
+	activity.Record(activity.SwitchIn, p.ProjectInfo.ID, source)
+}
+
+func runTimeHooks(cmd *cobra.Command, args []string) {
+	configPath, err := paths.GetConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
+		resolver, err := paths.NewResolver()
+		if err == nil && resolver.TmuxActivityHooks() {
+			fmt.Printf("tmux hooks are on ([time] tmux_hooks in %s)\n", configPath)
+		} else {
+			fmt.Printf("tmux hooks are off (turn on with 'pk time hooks on')\n")
+		}
+		return
+	}
+
+	var enable bool
+	switch args[0] {
+	case "on":
+		enable = true
+	case "off":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: expected 'on' or 'off', got '%s'\n", args[0])
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(configPath, tomledit.Set(data, "time", "tmux_hooks", tomledit.Literal(enable)), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to update %s: %v\n", configPath, err)
+		os.Exit(1)
+	}
+	fmt.Printf("\033[32m✓\033[0m Set [time] tmux_hooks = %t in %s\n", enable, configPath)
+
+	// The running server, if any, follows right away
+	if !session.ServerRunning() {
+		return
+	}
+	if enable {
+		err = session.InstallActivityHooks()
+	} else {
+		err = session.RemoveActivityHooks()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	if enable {
+		fmt.Printf("\033[32m✓\033[0m Installed %s\n", strings.Join(session.ActivityHookNames(), ", "))
+	} else {
+		fmt.Printf("\033[32m✓\033[0m Removed %s\n", strings.Join(session.ActivityHookNames(), ", "))
+	}
+}
+
+// timeReportRow is one line of a time report
+type timeReportRow struct {
+	Key      string  `json:"key"`
+	Client   string  `json:"client,omitempty"`
+	Billable bool    `json:"billable,omitempty"`
+	RateType string  `json:"rate_type,omitempty"`
+	Hours    float64 `json:"hours"`
+}
+
+func runTimeReport(cmd *cobra.Command, args []string) {
+	from, to, err := reportRange(timeFrom, timeTo, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	projects, _ := loadAllProjects()
+	byID := make(map[string]*config.Project)
+	for _, p := range projects {
+		byID[p.ProjectInfo.ID] = p
+	}
+
+	intervals, err := workIntervals(projects, from, to, timeMax, timeSource)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var key func(activity.Interval) string
+	switch timeBy {
+	case "project":
+		key = func(i activity.Interval) string { return i.ProjectID }
+	case "client":
+		key = func(i activity.Interval) string { return reportClient(byID[i.ProjectID]) }
+	case "week":
+		key = func(i activity.Interval) string { return activity.WeekKey(i.Start) }
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --by must be project, client or week\n")
+		os.Exit(1)
+	}
+
+	totals := activity.Totals(intervals, key)
+	if timeBy == "week" {
+		sort.Slice(totals, func(i, j int) bool { return totals[i].Key < totals[j].Key })
+	}
+
+	var rows []timeReportRow
+	for _, total := range totals {
+		row := timeReportRow{Key: total.Key, Hours: roundHours(total.Duration)}
+		if p := byID[total.Key]; timeBy == "project" && p != nil {
+			row.Client = p.GetClientName()
+			row.Billable = p.Consultant.Billable
+			row.RateType = p.Consultant.RateType
+		}
+		rows = append(rows, row)
+	}
+
+	switch timeFormat {
+	case "table":
+		printTimeTable(rows, from, to)
+	case "csv":
+		printTimeCSV(rows)
+	case "json":
+		printTimeJSON(rows, from, to)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --format must be table, csv or json\n")
+		os.Exit(1)
+	}
+}
+
+// workIntervals returns working time within [from, to), split by day
+// source selects tracked session time, manually logged time (pk log) or both.
+func workIntervals(projects []*config.Project, from, to time.Time, maxLength time.Duration, source string) ([]activity.Interval, error) {
+	var intervals []activity.Interval
+
+	switch source {
+	case "all", "tracked", "logged":
+	default:
+		return nil, fmt.Errorf("--source must be all, tracked or logged")
+	}
+
+	if source != "logged" {
+		events, err := activity.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read activity log: %w", err)
+		}
+		intervals = activity.Intervals(events, maxLength, time.Now())
+	}
+	if source != "tracked" {
+		intervals = append(intervals, worklog.Intervals(loadWorkLogs(projects))...)
+	}
+
+	return activity.SplitDays(activity.Clip(intervals, from, to)), nil
+}
+
+// reportRange parses inclusive --from/--to days into a [from, to) range
+func reportRange(fromFlag, toFlag string, now time.Time) (time.Time, time.Time, error) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	from := today.AddDate(0, 0, -6)
+	to := today
+	if fromFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromFlag, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid --from date '%s' (use YYYY-MM-DD)", fromFlag)
+		}
+		from = parsed
+	}
+	if toFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toFlag, time.Local)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid --to date '%s' (use YYYY-MM-DD)", toFlag)
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("--to is before --from")
+	}
+
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// reportClient names the client a project's time is billed to
+func reportClient(p *config.Project) string {
+	if p == nil {
+		return "(unknown project)"
+	}
+	if client := p.GetClientName(); client != "" {
+		return client
+	}
+	return "(no client)"
+}
+
+// roundHours converts a duration to hours with two decimals
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+func printTimeTable(rows []timeReportRow, from, to time.Time) {
+	fmt.Printf("Time by %s, %s to %s:\n\n", timeBy, from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+
+	if len(rows) == 0 {
+		fmt.Println("No tracked time in this range")
+		fmt.Println("\nTip: Time is tracked when you switch projects with 'pk session' or tmux")
+		return
+	}
+
+	total := 0.0
+	for _, row := range rows {
+		extra := ""
+		if row.Client != "" {
+			extra = "  " + row.Client
+		}
+		if row.Billable {
+			extra += "  \033[32mbillable\033[0m"
+			if row.RateType != "" {
+				extra += " (" + row.RateType + ")"
+			}
+		}
+		fmt.Printf("%-25s %7.2f h%s\n", row.Key, row.Hours, extra)
+		total += row.Hours
+	}
+	fmt.Printf("\n%-25s %7.2f h\n", "Total", total)
+}
+
+func printTimeCSV(rows []timeReportRow) {
+	w := csv.NewWriter(os.Stdout)
+	header := []string{timeBy, "hours"}
+	if timeBy == "project" {
+		header = append(header, "client", "billable", "rate_type")
+	}
+	w.Write(header)
+
+	for _, row := range rows {
+		record := []string{row.Key, strconv.FormatFloat(row.Hours, 'f', 2, 64)}
+		if timeBy == "project" {
+			record = append(record, row.Client, strconv.FormatBool(row.Billable), row.RateType)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+}
+
+func printTimeJSON(rows []timeReportRow, from, to time.Time) {
+	total := 0.0
+	for _, row := range rows {
+		total += row.Hours
+	}
+	if rows == nil {
+		rows = []timeReportRow{}
+	}
+
+	report := struct {
+		From       string          `json:"from"`
+		To         string          `json:"to"`
+		By         string          `json:"by"`
+		Rows       []timeReportRow `json:"rows"`
+		TotalHours float64         `json:"total_hours"`
+	}{
+		From:       from.Format("2006-01-02"),
+		To:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		By:         timeBy,
+		Rows:       rows,
+		TotalHours: total,
+	}
+
+	data, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(data))
+}
+
+func runTimeEvent(cmd *cobra.Command, args []string) {
+	event, sessionName := args[0], args[1]
+
+	switch event {
+	case "switch":
+		// Switching to a session outside pk stops the clock
+		if projectID := sessionProjectID(sessionName); projectID != "" {
+			activity.Record(activity.SwitchIn, projectID, "tmux")
+		} else {
+			activity.Record(activity.SwitchOut, "", "tmux")
+		}
+	case "detach":
+		activity.Record(activity.SwitchOut, sessionProjectID(sessionName), "tmux")
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown event '%s'\n", event)
+		os.Exit(1)
+	}
+}
+
+func runTimeTick(cmd *cobra.Command, args []string) {
+	lastInput, sessionName, ok := session.LastClientActivity()
+	if !ok {
+		return
+	}
+
+	last, err := activity.Last()
+	if err != nil {
+		return
+	}
+
+	event := activity.TickEvent(last, lastInput, sessionProjectID(sessionName), timeIdle, time.Now())
+	if event != nil {
+		activity.Append(*event)
+	}
+}
+
+// sessionProjectID resolves a session name to its project ID, or ""
+func sessionProjectID(sessionName string) string {
+	if projectID := session.SessionProjectID(sessionName); projectID != "" {
+		return projectID
+	}
+
+	projects, _ := loadAllProjects()
+	if project := projectForSession(sessionName, projects); project != nil {
+		return project.ProjectInfo.ID
+	}
+	return ""
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/trash"
+	"github.com/spf13/cobra"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or empty deleted projects",
+	Long: `Manage projects removed with 'pk delete' and 'pk scratch delete'.
+
+Deleted projects are moved to ~/.local/share/pk/trash together with a
+manifest holding the original path, a copy of .project.toml and the tmux
+session layout at deletion time. Nothing is removed for good until the
+trash is emptied.
+
+Examples:
+  pk trash list
+  pk trash restore old-project          # Latest deletion of old-project
+  pk trash empty --older-than 30d`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trashed projects",
+	Args:  cobra.NoArgs,
+	Run:   runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <name|trash-id>",
+	Short: "Move a trashed project back",
+	Long: `Move a trashed project back to where it was deleted from.
+
+A project name picks its most recent deletion; use the trash ID from
+'pk trash list' for an older one. Refuses if something already exists at
+the original path, unless --to gives another location.
+
+If the project had a tmux session when it was deleted, its layout is
+saved again so 'pk session restore <name>' can rebuild it.
+
+Examples:
+  pk trash restore old-project
+  pk trash restore old-project-20260901-101500
+  pk trash restore old-project --to ~/projects/old-project-2`,
+	Args:              cobra.ExactArgs(1),
+	Run:               runTrashRestore,
+	ValidArgsFunction: validTrashNames,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trashed projects",
+	Long: `Permanently delete projects from the trash.
+
+Without --older-than the whole trash is emptied.
+
+Examples:
+  pk trash empty
+  pk trash empty --older-than 30d
+  pk trash empty --older-than 12h --force`,
+	Args: cobra.NoArgs,
+	Run:  runTrashEmpty,
+}
+
+var (
+	trashRestoreTo string
+	trashOlderThan string
+	trashForce     bool
+	trashAutoSync  bool
+)
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+
+	trashRestoreCmd.Flags().StringVar(&trashRestoreTo, "to", "", "Restore to this path instead of the original one")
+	trashRestoreCmd.Flags().BoolVar(&trashAutoSync, "sync", true, "Auto-sync aliases after restoring")
+	trashEmptyCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only delete items trashed longer ago (e.g. 30d, 12h)")
+	trashEmptyCmd.Flags().BoolVar(&trashForce, "force", false, "Skip confirmation prompt")
+}
+
+func runTrashList(cmd *cobra.Command, args []string) {
+	items, err := trash.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read trash: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("Trash is empty")
+		return
+	}
+
+	fmt.Printf("Trash (%d):\n\n", len(items))
+	now := time.Now()
+	for _, item := range items {
+		extra := ""
+		if item.Session != nil {
+			extra = "  \033[36m[session saved]\033[0m"
+		}
+		fmt.Printf("%-40s %-8s %5s ago  %s%s\n",
+			item.ID, item.Kind, session.FormatAge(now.Sub(item.DeletedAt)), item.OriginalPath, extra)
+	}
+
+	fmt.Println("\nRestore with: pk trash restore <name|trash-id>")
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) {
+	item, err := trash.Find(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "\nUse 'pk trash list' to see trashed projects.\n")
+		os.Exit(1)
+	}
+
+	destPath := trashRestoreTo
+	if strings.HasPrefix(destPath, "~/") {
+		homeDir, _ := os.UserHomeDir()
+		destPath = homeDir + destPath[1:]
+	}
+
+	restored, err := trash.Restore(item, destPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if trashRestoreTo == "" {
+			fmt.Fprintf(os.Stderr, "Hint: Use --to to restore somewhere else\n")
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("\033[32m✓\033[0m Restored: %s\n", restored)
+
+	if item.Session != nil {
+		state := *item.Session
+		state.ProjectPath = restored
+		if err := session.SaveState(&state); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to save session layout: %v\n", err)
+		} else {
+			fmt.Printf("\033[32m✓\033[0m Session layout saved (rebuild with: pk session restore %s)\n", state.Name)
+		}
+	}
+
+	if item.Kind == trash.KindProject && trashAutoSync {
+		fmt.Println("Syncing aliases...")
+		runSync(cmd, []string{})
+	}
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) {
+	var olderThan time.Duration
+	if trashOlderThan != "" {
+		d, err := session.ParseIdle(trashOlderThan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		olderThan = d
+	}
+
+	items, err := trash.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read trash: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	count := 0
+	for _, item := range items {
+		if now.Sub(item.DeletedAt) >= olderThan {
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Println("Nothing to delete")
+		return
+	}
+
+	if !trashForce {
+		fmt.Printf("\033[33mWARNING: This will permanently delete %d item(s) from the trash.\033[0m\n", count)
+		fmt.Print("Continue? (y/N): ")
+
+		var response string
+		fmt.Scanln(&response)
+
+		if strings.ToLower(response) != "y" {
+			fmt.Println("Cancelled")
+			return
+		}
+	}
+
+	removed, err := trash.Empty(olderThan, now)
+	for _, item := range removed {
+		fmt.Printf("\033[32m✓\033[0m Deleted: %s\n", item.ID)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,619 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/context"
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/datakaicr/pk/pkg/picker"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Interactive project dashboard",
+	Long: `Full-screen dashboard of all projects.
+
+Shows every project with its pin slot, live tmux session indicator (●),
+status, owner and last access, plus a details pane for the selected
+project.
+
+Keys:
+  ↑/↓ j/k     Move              enter   Open tmux session
+  /           Fuzzy filter      e       Edit .project.toml
+  s o c t     Cycle status/owner/client/stack filter
+  esc         Clear filters     a       Archive (asks to confirm)
+  g           Toggle git status p       Pin / unpin
+  r           Reload            q       Quit
+
+Example:
+  pk ui`,
+	Args: cobra.NoArgs,
+	Run:  runUI,
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+// dashboard is the state of 'pk ui' between renders
+type dashboard struct {
+	projects []*config.Project // All projects, best frecency first
+	visible  []*config.Project // Projects passing the filters
+	sessions map[string]bool
+	pins     map[string]int
+	access   map[string]cache.AccessRecord
+
+	query     []rune
+	filtering bool // Typing goes to the query
+	status    string
+	owner     string
+	client    string
+	stack     string
+
+	cursor  int
+	offset  int
+	showGit bool
+	confirm bool // Waiting for y/n before archiving
+	message string
+	cards   map[string][]string // Rendered details per project ID
+}
+
+func runUI(cmd *cobra.Command, args []string) {
+	d := &dashboard{}
+	if err := d.load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	t, err := tui.Open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer t.Close()
+
+	for {
+		width, height := t.Size()
+		t.Draw(d.render(width, height))
+
+		key, err := t.ReadKey()
+		if err != nil {
+			return
+		}
+
+		d.message = ""
+		if open := d.handleKey(t, key, height); open != nil {
+			t.Close()
+			openProjectSession(open, "ui")
+			return
+		}
+		if d.quit(key) {
+			return
+		}
+
+		// Keep session indicators live
+		d.loadSessions()
+	}
+}
+
+// load reads projects, pins, access records and sessions, keeping the selection
+func (d *dashboard) load() error {
+	var selected string
+	if p := d.selected(); p != nil {
+		selected = p.ProjectInfo.ID
+	}
+
+	projects, err := loadAllProjects()
+	if err != nil {
+		return err
+	}
+
+	d.access, _ = cache.LoadAccessRecords()
+	if d.access == nil {
+		d.access = make(map[string]cache.AccessRecord)
+	}
+
+	// Best frecency first, never-accessed projects alphabetically
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].ProjectInfo.ID < projects[j].ProjectInfo.ID
+	})
+	cache.RankProjects(projects, d.access, time.Now())
+	d.projects = projects
+
+	d.pins = make(map[string]int)
+	pins, _ := cache.ListPins()
+	for _, pin := range pins {
+		d.pins[pin.ProjectID] = pin.Slot
+	}
+
+	d.cards = make(map[string][]string)
+	d.loadSessions()
+	d.applyFilters()
+
+	for i, p := range d.visible {
+		if p.ProjectInfo.ID == selected {
+			d.cursor = i
+		}
+	}
+	return nil
+}
+
+// loadSessions refreshes the set of running tmux sessions
+func (d *dashboard) loadSessions() {
+	d.sessions = make(map[string]bool)
+	names, _ := session.ListSessions()
+	for _, name := range names {
+		d.sessions[name] = true
+	}
+}
+
+// applyFilters recomputes the visible projects from field filters and the query
+func (d *dashboard) applyFilters() {
+	var candidates []*config.Project
+	for _, p := range d.projects {
+		if d.status != "" && p.ProjectInfo.Status != d.status {
+			continue
+		}
+		if d.owner != "" && p.GetOwner() != d.owner {
+			continue
+		}
+		if d.client != "" && p.GetClientName() != d.client {
+			continue
+		}
+		if d.stack != "" && !hasValue(p.Tech.Stack, d.stack) {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	texts := make([]string, len(candidates))
+	for i, p := range candidates {
+		texts[i] = strings.Join(append([]string{p.ProjectInfo.ID, p.ProjectInfo.Name, p.GetClientName()}, p.Tech.Stack...), " ")
+	}
+
+	d.visible = nil
+	for _, result := range picker.Filter(texts, string(d.query)) {
+		d.visible = append(d.visible, candidates[result.Index])
+	}
+
+	if d.cursor >= len(d.visible) {
+		d.cursor = len(d.visible) - 1
+	}
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+// selected returns the project under the cursor
+func (d *dashboard) selected() *config.Project {
+	if d.cursor < 0 || d.cursor >= len(d.visible) {
+		return nil
+	}
+	return d.visible[d.cursor]
+}
+
+// quit reports whether key leaves the dashboard
+func (d *dashboard) quit(key tui.Key) bool {
+	if key.Code == tui.KeyInterrupt {
+		return true
+	}
+	return !d.filtering && !d.confirm && string(key.Runes) == "q"
+}
+
+// handleKey applies a keypress; it returns a project when its session should open
+func (d *dashboard) handleKey(t *tui.Terminal, key tui.Key, height int) *config.Project {
+	if d.confirm {
+		d.confirm = false
+		if string(key.Runes) == "y" {
+			d.archiveSelected()
+		} else {
+			d.message = "Archive cancelled"
+		}
+		return nil
+	}
+
+	if d.filtering {
+		switch key.Code {
+		case tui.KeyEnter, tui.KeyEscape:
+			d.filtering = false
+		case tui.KeyBackspace:
+			if len(d.query) > 0 {
+				d.query = d.query[:len(d.query)-1]
+			}
+		case tui.KeyClearLine:
+			d.query = nil
+		case tui.KeyUp:
+			d.move(-1)
+			return nil
+		case tui.KeyDown:
+			d.move(1)
+			return nil
+		default:
+			d.query = append(d.query, key.Runes...)
+		}
+		d.cursor = 0
+		d.applyFilters()
+		return nil
+	}
+
+	switch key.Code {
+	case tui.KeyUp:
+		d.move(-1)
+	case tui.KeyDown:
+		d.move(1)
+	case tui.KeyPageUp:
+		d.move(-(height - 2))
+	case tui.KeyPageDown:
+		d.move(height - 2)
+	case tui.KeyEnter:
+		return d.selected()
+	case tui.KeyEscape:
+		d.query = nil
+		d.status, d.owner, d.client, d.stack = "", "", "", ""
+		d.applyFilters()
+	}
+
+	switch string(key.Runes) {
+	case "j":
+		d.move(1)
+	case "k":
+		d.move(-1)
+	case "/":
+		d.filtering = true
+	case "s":
+		d.status = nextValue(d.status, d.fieldValues(func(p *config.Project) []string { return []string{p.ProjectInfo.Status} }))
+		d.applyFilters()
+	case "o":
+		d.owner = nextValue(d.owner, d.fieldValues(func(p *config.Project) []string { return []string{p.GetOwner()} }))
+		d.applyFilters()
+	case "c":
+		d.client = nextValue(d.client, d.fieldValues(func(p *config.Project) []string { return []string{p.GetClientName()} }))
+		d.applyFilters()
+	case "t":
+		d.stack = nextValue(d.stack, d.fieldValues(func(p *config.Project) []string { return p.Tech.Stack }))
+		d.applyFilters()
+	case "g":
+		d.showGit = !d.showGit
+	case "p":
+		d.togglePin()
+	case "a":
+		if p := d.selected(); p != nil {
+			d.confirm = true
+		}
+	case "e":
+		d.editSelected(t)
+	case "r":
+		if err := d.load(); err != nil {
+			d.message = "Reload failed: " + err.Error()
+		} else {
+			d.message = "Reloaded"
+		}
+	}
+
+	return nil
+}
+
+// move shifts the cursor within the visible projects
+func (d *dashboard) move(delta int) {
+	d.cursor += delta
+	if d.cursor >= len(d.visible) {
+		d.cursor = len(d.visible) - 1
+	}
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+// fieldValues returns the sorted distinct non-empty values of a project field
+func (d *dashboard) fieldValues(field func(*config.Project) []string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, p := range d.projects {
+		for _, v := range field(p) {
+			if v != "" && !seen[v] {
+				seen[v] = true
+				values = append(values, v)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// nextValue cycles "" → values[0] → ... → values[n-1] → ""
+func nextValue(current string, values []string) string {
+	if current == "" {
+		if len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	for i, v := range values {
+		if v == current && i+1 < len(values) {
+			return values[i+1]
+		}
+	}
+	return ""
+}
+
+// togglePin pins the selected project to the first free slot or unpins it
+func (d *dashboard) togglePin() {
+	p := d.selected()
+	if p == nil {
+		return
+	}
+
+	id := p.ProjectInfo.ID
+	if slot, pinned := d.pins[id]; pinned {
+		if err := cache.RemovePin(slot); err != nil {
+			d.message = "Unpin failed: " + err.Error()
+			return
+		}
+		delete(d.pins, id)
+		d.message = fmt.Sprintf("Unpinned %s from slot %d", id, slot)
+	} else {
+		slot, err := cache.FreeSlot()
+		if err == nil {
+			err = cache.AddPin(slot, id, p.Path)
+		}
+		if err != nil {
+			d.message = "Pin failed: " + err.Error()
+			return
+		}
+		d.pins[id] = slot
+		d.message = fmt.Sprintf("Pinned %s to slot %d", id, slot)
+	}
+	delete(d.cards, id)
+}
+
+// archiveSelected moves the selected project to ~/archive
+func (d *dashboard) archiveSelected() {
+	p := d.selected()
+	if p == nil {
+		return
+	}
+
+	if err := checkNotSubproject(p); err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	projectsDir := filepath.Join(homeDir, "projects") + string(filepath.Separator)
+	if !strings.HasPrefix(p.Path, projectsDir) {
+		d.message = fmt.Sprintf("Only projects in ~/projects can be archived (%s)", p.ProjectInfo.ID)
+		return
+	}
+
+	tx := journal.Begin("archive", p.ProjectInfo.ID)
+	if _, err := archiveProject(tx, p, filepath.Join(homeDir, "archive")); err != nil {
+		d.message = "Archive failed: " + err.Error()
+		return
+	}
+	commitJournal(tx)
+
+	id := p.ProjectInfo.ID
+	if err := d.load(); err != nil {
+		d.message = "Reload failed: " + err.Error()
+		return
+	}
+	d.message = "Archived " + id + " (run 'pk sync' to update aliases, 'pk undo' to revert)"
+}
+
+// editSelected opens the selected project's .project.toml in $EDITOR
+func (d *dashboard) editSelected(t *tui.Terminal) {
+	p := d.selected()
+	if p == nil || p.ProjectInfo.Status == "scratch" {
+		d.message = "Nothing to edit"
+		return
+	}
+	if err := checkNotSubproject(p); err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	t.Suspend()
+	editorCmd := exec.Command(editorCommand(), filepath.Join(p.Path, ".project.toml"))
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	runErr := editorCmd.Run()
+	if err := t.Resume(); err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	// Edits bypass the project cache
+	cache.InvalidateCache()
+	if err := d.load(); err != nil {
+		d.message = "Reload failed: " + err.Error()
+		return
+	}
+	if runErr != nil {
+		d.message = "Editor failed: " + runErr.Error()
+		return
+	}
+	d.message = "Saved " + p.ProjectInfo.ID
+}
+
+// render draws the header, project list, details pane and footer
+func (d *dashboard) render(width, height int) string {
+	listWidth := width
+	split := 0
+	if width >= minDashboardWidth {
+		listWidth = width * 45 / 100
+		split = listWidth
+	}
+
+	listHeight := height - 2
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	}
+	if d.cursor >= d.offset+listHeight {
+		d.offset = d.cursor - listHeight + 1
+	}
+
+	left := []string{d.header()}
+	for i := d.offset; i < len(d.visible) && len(left) <= listHeight; i++ {
+		left = append(left, d.renderRow(d.visible[i], i == d.cursor))
+	}
+	if len(d.visible) == 0 {
+		left = append(left, "  \033[90mNo matching projects\033[0m")
+	}
+	for len(left) < height-1 {
+		left = append(left, "")
+	}
+	left = append(left, d.footer())
+
+	var right []string
+	if split > 0 {
+		if p := d.selected(); p != nil {
+			right = d.details(p)
+		}
+	}
+
+	return tui.Compose(left, right, width, height, split)
+}
+
+// minDashboardWidth is the terminal width below which the details pane is hidden
+const minDashboardWidth = 90
+
+// header shows counts, active filters and the query
+func (d *dashboard) header() string {
+	h := fmt.Sprintf("\033[1mpk\033[0m \033[33m%d/%d\033[0m", len(d.visible), len(d.projects))
+
+	for _, f := range []struct{ name, value string }{
+		{"status", d.status}, {"owner", d.owner}, {"client", d.client}, {"stack", d.stack},
+	} {
+		if f.value != "" {
+			h += fmt.Sprintf(" \033[36m%s:%s\033[0m", f.name, f.value)
+		}
+	}
+
+	if d.filtering {
+		h += " /" + string(d.query) + "\033[7m \033[0m"
+	} else if len(d.query) > 0 {
+		h += " /" + string(d.query)
+	}
+	return h
+}
+
+// footer shows the confirmation prompt, the last message or key help
+func (d *dashboard) footer() string {
+	switch {
+	case d.confirm:
+		if p := d.selected(); p != nil {
+			return fmt.Sprintf("\033[33mArchive %s? (y/n)\033[0m", p.ProjectInfo.ID)
+		}
+	case d.message != "":
+		return d.message
+	}
+	return "\033[90menter open · / filter · s/o/c/t status/owner/client/stack · e edit · a archive · p pin · g git · q quit\033[0m"
+}
+
+// renderRow draws one project line
+func (d *dashboard) renderRow(p *config.Project, selected bool) string {
+	marker := "  "
+	if selected {
+		marker = "\033[1;35m▌\033[0m "
+	}
+
+	pin := "   "
+	if slot, ok := d.pins[p.ProjectInfo.ID]; ok {
+		pin = fmt.Sprintf("[%d]", slot)
+	}
+
+	live := " "
+	if d.sessions[session.SanitizeSessionName(p.ProjectInfo.ID)] {
+		live = "\033[32m●\033[0m"
+	}
+
+	status := p.ProjectInfo.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	accessed := ""
+	if record, ok := d.access[p.ProjectInfo.ID]; ok {
+		accessed = formatAccessTime(record.LastAccessed)
+	}
+
+	id := tui.Pad(p.ProjectInfo.ID, 22)
+	if selected {
+		id = "\033[1m" + id + "\033[0m"
+	}
+
+	return fmt.Sprintf("%s%s %s %s %s%s\033[0m %s \033[90m%s\033[0m",
+		marker, pin, live, id,
+		getStatusColor(status), tui.Pad(status, 9),
+		tui.Pad(p.GetOwner(), 10), accessed)
+}
+
+// details returns the project card or its git status
+func (d *dashboard) details(p *config.Project) []string {
+	if d.showGit {
+		return gitStatusLines(p.Path)
+	}
+
+	if lines, ok := d.cards[p.ProjectInfo.ID]; ok {
+		return lines
+	}
+
+	var buf bytes.Buffer
+	writePreviewCard(&buf, p)
+	lines := strings.Split(tui.ExpandTabs(strings.TrimRight(buf.String(), "\n"), 8), "\n")
+	d.cards[p.ProjectInfo.ID] = lines
+	return lines
+}
+
+// gitStatusLines returns 'git status -sb' output for the details pane
+func gitStatusLines(path string) []string {
+	lines := []string{"\033[1mgit status\033[0m", ""}
+
+	output, err := exec.Command("git", "-C", path, "status", "-sb").CombinedOutput()
+	if err != nil {
+		return append(lines, "\033[90mNot a git repository\033[0m")
+	}
+	return append(lines, strings.Split(strings.TrimRight(string(output), "\n"), "\n")...)
+}
+
+// openProjectSession records access, switches context and opens the tmux session
+func openProjectSession(p *config.Project, source string) {
+	if err := session.CheckTmux(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	recordVisit(p, source)
+	context.Switch(p)
+
+	if err := session.CreateSession(p); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create session: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// hasValue reports whether values contains s
+func hasValue(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/journal"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [count]",
+	Short: "Reverse the last rename, archive, set, promote or delete",
+	Long: `Reverse the most recent operations recorded in the journal.
+
+rename, archive, set, promote and delete record each step they take (moves,
+.project.toml writes, pin and session renames, trashing, cold storage) in
+~/.local/state/pk/journal.jsonl. 'pk undo' reverses the steps of the
+last operation that hasn't been undone yet; a count undoes that many,
+newest first.
+
+Undo refuses to touch anything if the tree changed in a way that would
+lose work, e.g. .project.toml was edited after the rename, or the old
+location is taken. Permanent deletes (--permanent) can't be undone, and
+a git repository created by promote is left in place.
+
+Examples:
+  pk undo           # Undo the last operation
+  pk undo 3         # Undo the last three
+  pk history        # See what would be undone`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runUndo,
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List journaled operations",
+	Long: `List recent renames, archives, sets, promotions and deletes, newest first.
+
+Operations already reversed with 'pk undo' are marked.
+
+Examples:
+  pk history
+  pk history -n 50
+  pk history -v     # Show each step`,
+	Args: cobra.NoArgs,
+	Run:  runHistory,
+}
+
+var (
+	undoForce      bool
+	historyLimit   int
+	historyVerbose bool
+)
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(historyCmd)
+	undoCmd.Flags().BoolVar(&undoForce, "force", false, "Skip confirmation prompt")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of operations to show")
+	historyCmd.Flags().BoolVarP(&historyVerbose, "verbose", "v", false, "Show the steps of each operation")
+}
+
+// rollback reverses a half-done operation after a failed step
+func rollback(tx *journal.Tx) {
+	if err := tx.Rollback(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Rollback incomplete: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Check the paths above and fix them by hand.\n")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Rolled back, nothing was changed.\n")
+}
+
+// commitJournal records a finished operation so 'pk undo' can reverse it
+func commitJournal(tx *journal.Tx) {
+	if err := tx.Commit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to write journal, 'pk undo' won't see this: %v\n", err)
+	}
+}
+
+func runUndo(cmd *cobra.Command, args []string) {
+	count := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "Error: count must be a positive number\n")
+			os.Exit(1)
+		}
+		count = n
+	}
+
+	ops, err := journal.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	undoable := journal.Undoable(ops, count)
+	if len(undoable) == 0 {
+		fmt.Println("Nothing to undo")
+		return
+	}
+
+	if !undoForce {
+		fmt.Println("This will undo:")
+		for _, op := range undoable {
+			fmt.Printf("  #%-4d %s  pk %s\n", op.ID, op.Time.Format("2006-01-02 15:04"), op.Command)
+		}
+		fmt.Print("\nContinue? (y/N): ")
+
+		var response string
+		fmt.Scanln(&response)
+
+		if strings.ToLower(response) != "y" {
+			fmt.Println("Cancelled")
+			return
+		}
+	}
+
+	needsSync := false
+	for _, op := range undoable {
+		if err := journal.Undo(op); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not undo 'pk %s': %v\n", op.Command, err)
+			if needsSync {
+				runSync(cmd, []string{})
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("\033[32m✓\033[0m Undone: pk %s\n", op.Command)
+		needsSync = needsSync || op.Has(journal.StepSync)
+	}
+
+	if needsSync {
+		fmt.Println("Syncing aliases...")
+		runSync(cmd, []string{})
+	}
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	ops, err := journal.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(ops) == 0 {
+		fmt.Println("No operations recorded yet")
+		return
+	}
+
+	shown := 0
+	for i := len(ops) - 1; i >= 0 && shown < historyLimit; i-- {
+		op := ops[i]
+		shown++
+
+		marker := ""
+		if op.Undone {
+			marker = "  \033[90m(undone)\033[0m"
+		}
+		fmt.Printf("#%-4d %s  pk %s%s\n", op.ID, op.Time.Format("2006-01-02 15:04"), op.Command, marker)
+
+		if historyVerbose {
+			for _, step := range op.Steps {
+				fmt.Printf("        %s\n", describeStep(step))
+			}
+		}
+	}
+}
+
+// describeStep renders a journal step for 'pk history -v'
+func describeStep(step journal.Step) string {
+	switch step.Kind {
+	case journal.StepMove:
+		return fmt.Sprintf("move     %s → %s", step.From, step.To)
+	case journal.StepWrite:
+		if !step.Existed {
+			return fmt.Sprintf("create   %s", step.Path)
+		}
+		return fmt.Sprintf("write    %s", step.Path)
+	case journal.StepRelocate:
+		return fmt.Sprintf("relocate %s → %s", step.ProjectID, step.To)
+	case journal.StepRekey:
+		if step.ProjectID == step.NewID {
+			return fmt.Sprintf("relocate %s → %s", step.ProjectID, step.To)
+		}
+		return fmt.Sprintf("rekey    %s → %s (pins, access history)", step.ProjectID, step.NewID)
+	case journal.StepSession:
+		return fmt.Sprintf("session  %s → %s", step.From, step.To)
+	case journal.StepTrash:
+		return fmt.Sprintf("trash    %s (%s)", step.From, step.TrashID)
+	case journal.StepCompact:
+		return fmt.Sprintf("compact  %s", step.Path)
+	case journal.StepActivity:
+		return fmt.Sprintf("activity %s → %s (tracked time)", step.ProjectID, step.NewID)
+	case journal.StepExpand:
+		return fmt.Sprintf("expand   %s", step.Path)
+	case journal.StepSync:
+		return "sync     shell aliases"
+	}
+	return step.Kind
+}
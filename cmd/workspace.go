@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/paths"
+	"github.com/datakaicr/pk/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Export or recreate the projects of this machine",
+	Long: `Move a whole setup to another machine.
+
+'pk workspace export' lists every project with its root (projects, archive
+or scriptorium), path relative to the root, git remote and branch, its
+.project.toml and the pins. 'pk workspace apply' replays such a file:
+missing repositories are cloned, absent .project.toml files recreated and
+pins restored. Projects that already exist are never changed; where they
+differ from the file (location, remote, branch, projects not in the file)
+the difference is reported as drift.
+
+Examples:
+  pk workspace export > workspace.toml
+  pk workspace apply workspace.toml --dry-run
+  pk workspace apply workspace.toml --mirror /mnt/backup/git`,
+}
+
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print a workspace file for this machine",
+	Args:  cobra.NoArgs,
+	Run:   runWorkspaceExport,
+}
+
+var workspaceApplyCmd = &cobra.Command{
+	Use:   "apply <workspace.toml>",
+	Short: "Clone missing projects and restore pins from a workspace file",
+	Long: `Make this machine match a workspace file from 'pk workspace export'.
+
+Repositories are cloned from --mirror directories first (a bare mirror
+named <id>.git, <id> or after the repository, e.g. app.git), then from the
+repository URL. A clone from a mirror gets the URL as its origin. Projects
+without a repository can't be recreated and are reported as missing.
+
+Examples:
+  pk workspace apply workspace.toml
+  pk workspace apply workspace.toml --dry-run
+  pk workspace apply workspace.toml --mirror /mnt/backup/git --mirror ~/mirrors`,
+	Args: cobra.ExactArgs(1),
+	Run:  runWorkspaceApply,
+}
+
+var (
+	workspaceMirrors  []string
+	workspaceDryRun   bool
+	workspaceAutoSync bool
+)
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceExportCmd)
+	workspaceCmd.AddCommand(workspaceApplyCmd)
+
+	workspaceApplyCmd.Flags().StringSliceVar(&workspaceMirrors, "mirror", nil, "Directory of bare git mirrors to clone from (repeatable)")
+	workspaceApplyCmd.Flags().BoolVar(&workspaceDryRun, "dry-run", false, "Only report what would change")
+	workspaceApplyCmd.Flags().BoolVar(&workspaceAutoSync, "sync", true, "Auto-sync aliases after cloning")
+}
+
+// workspaceProjects returns the resolver's roots and the projects in them
+func workspaceProjects() (workspace.Roots, []*config.Project) {
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	projects, err := config.FindProjects(resolver.AllRoots()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	return workspace.ResolverRoots(resolver), projects
+}
+
+func runWorkspaceExport(cmd *cobra.Command, args []string) {
+	roots, projects := workspaceProjects()
+
+	pins, err := cache.ListPins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load pins: %v\n", err)
+	}
+
+	ws := workspace.Export(projects, roots, pins)
+	if err := workspace.Write(os.Stdout, ws); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d projects and %d pins\n", len(ws.Projects), len(ws.Pins))
+}
+
+func runWorkspaceApply(cmd *cobra.Command, args []string) {
+	ws, err := workspace.Load(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to read %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	roots, projects := workspaceProjects()
+	results := workspace.Apply(ws, roots, projects, workspace.Options{
+		Mirrors: workspaceMirrors,
+		DryRun:  workspaceDryRun,
+	})
+	cache.InvalidateCache()
+
+	counts := make(map[string]int)
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("\033[31m✗\033[0m %-8s %s: %v\n", r.Action, r.Project, r.Err)
+			continue
+		}
+		counts[r.Action]++
+		switch r.Action {
+		case workspace.ActionDrift, workspace.ActionMissing, workspace.ActionExtra:
+			fmt.Printf("\033[33m⚠\033[0m %-8s %s: %s\n", r.Action, r.Project, r.Detail)
+		default:
+			fmt.Printf("\033[32m✓\033[0m %-8s %s: %s\n", r.Action, r.Project, r.Detail)
+		}
+	}
+
+	if len(results) == 0 {
+		fmt.Println("This machine matches the workspace")
+		return
+	}
+
+	fmt.Printf("\n%d cloned, %d recreated, %d pins restored, %d drifted, %d missing, %d not in the workspace",
+		counts[workspace.ActionClone], counts[workspace.ActionRecreate], counts[workspace.ActionPin],
+		counts[workspace.ActionDrift], counts[workspace.ActionMissing], counts[workspace.ActionExtra])
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+
+	if workspaceDryRun {
+		fmt.Println("Dry run, nothing changed")
+	} else if workspaceAutoSync && counts[workspace.ActionClone] > 0 {
+		fmt.Printf("\nSyncing aliases...\n")
+		runSync(cmd, []string{})
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+var zList bool
+
+var zCmd = &cobra.Command{
+	Use:   "z <partial>...",
+	Short: "Jump to the best-ranked project matching a partial name",
+	Long: `Open the tmux session of the highest-ranked project matching all terms.
+
+Terms match case-insensitively anywhere in the project ID or name. Matches
+are ranked by frecency (see 'pk recent'); projects never opened are only
+chosen when nothing you have visited matches.
+
+Examples:
+  pk z api          # Best match for "api"
+  pk z api gate     # Must contain both "api" and "gate"
+  pk z api --list   # Print the match instead of opening it`,
+	Args:              cobra.MinimumNArgs(1),
+	Run:               runZ,
+	ValidArgsFunction: validAllProjectNames,
+}
+
+func init() {
+	rootCmd.AddCommand(zCmd)
+	zCmd.Flags().BoolVarP(&zList, "list", "l", false, "Print the best match without opening it")
+}
+
+func runZ(cmd *cobra.Command, args []string) {
+	projects, err := loadAllProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to find projects: %v\n", err)
+		os.Exit(1)
+	}
+
+	records, err := cache.LoadAccessRecords()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load access records: %v\n", err)
+		os.Exit(1)
+	}
+
+	project := cache.BestMatch(projects, records, args, time.Now())
+	if project == nil {
+		fmt.Fprintf(os.Stderr, "Error: No project matches '%s'\n", strings.Join(args, " "))
+		os.Exit(1)
+	}
+
+	if zList {
+		fmt.Printf("%s\t%s\n", project.ProjectInfo.ID, project.Path)
+		return
+	}
+
+	openProjectSession(project, "z")
+}
@@ -5,9 +5,11 @@ go 1.25.4
 require (
 	github.com/BurntSushi/toml v1.5.0
 	github.com/spf13/cobra v1.10.1
+	golang.org/x/term v0.40.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
+	golang.org/x/sys v0.41.0 // indirect
 )
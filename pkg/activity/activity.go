@@ -0,0 +1,359 @@
+package activity
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event types in the activity log
+const (
+	SwitchIn  = "in"   // Started working on a project
+	SwitchOut = "out"  // Stopped working (detach, kill)
+	Idle      = "idle" // No input since Event.Time
+)
+
+// Event is one line of the activity log
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	ProjectID string    `json:"project_id,omitempty"`
+	Source    string    `json:"source,omitempty"` // Command or tmux hook that produced the event
+}
+
+// Interval is a stretch of time attributed to one project
+type Interval struct {
+	ProjectID string
+	Start     time.Time
+	End       time.Time
+}
+
+// Duration returns the length of the interval
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// GetLogFile returns the path to the append-only activity log
+func GetLogFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	stateDir := filepath.Join(homeDir, ".local", "state", "pk")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(stateDir, "activity.jsonl"), nil
+}
+
+// Append adds an event to the activity log
+func Append(event Event) error {
+	logFile, err := GetLogFile()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Record appends an event of the given type stamped now
+func Record(eventType, projectID, source string) error {
+	return Append(Event{Time: time.Now(), Type: eventType, ProjectID: projectID, Source: source})
+}
+
+// Load reads every event in the activity log, oldest first
+// Malformed lines (e.g. a partial write) are skipped
+func Load() ([]Event, error) {
+	logFile, err := GetLogFile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// Rename points the events of project oldID and its subprojects at newID
+// The log is rewritten in order, keeping lines it can't parse. Returns how
+// many events changed.
+func Rename(oldID, newID string) (int, error) {
+	logFile, err := GetLogFile()
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	changed := 0
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		var event Event
+		if json.Unmarshal([]byte(line), &event) != nil {
+			continue
+		}
+		switch {
+		case event.ProjectID == oldID:
+			event.ProjectID = newID
+		case strings.HasPrefix(event.ProjectID, oldID+"/"):
+			event.ProjectID = newID + strings.TrimPrefix(event.ProjectID, oldID)
+		default:
+			continue
+		}
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+		lines[i] = string(encoded) + "\n"
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	tmp := logFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, logFile); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return changed, nil
+}
+
+// Last returns the most recently written event, or nil for an empty log
+// Only the tail of the file is read so frequent callers stay cheap.
+func Last() (*Event, error) {
+	logFile, err := GetLogFile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const tailSize = 4096
+	offset := info.Size() - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	tail, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(tail), "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var event Event
+		if json.Unmarshal([]byte(lines[i]), &event) == nil {
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+// Intervals turns time-ordered events into per-project working intervals
+// A switch-in runs until the next event that isn't a switch-in to the same
+// project. An interval still open at the end runs until now. Intervals are
+// cut at maxLength (0 = no limit) so a lost switch-out, e.g. after a crash,
+// doesn't count as a day of work.
+func Intervals(events []Event, maxLength time.Duration, now time.Time) []Interval {
+	var intervals []Interval
+	var open *Interval
+
+	closeAt := func(end time.Time) {
+		if open == nil {
+			return
+		}
+		if maxLength > 0 && end.Sub(open.Start) > maxLength {
+			end = open.Start.Add(maxLength)
+		}
+		if end.After(open.Start) {
+			open.End = end
+			intervals = append(intervals, *open)
+		}
+		open = nil
+	}
+
+	for _, event := range events {
+		// A repeated switch-in to the same project splits the stretch so the
+		// length cap applies per stretch; mergeAdjacent joins it back up
+		closeAt(event.Time)
+
+		if event.Type == SwitchIn && event.ProjectID != "" {
+			open = &Interval{ProjectID: event.ProjectID, Start: event.Time}
+		}
+	}
+
+	if open != nil && now.After(open.Start) {
+		closeAt(now)
+	}
+
+	return mergeAdjacent(intervals)
+}
+
+// mergeAdjacent joins back-to-back intervals of the same project
+func mergeAdjacent(intervals []Interval) []Interval {
+	var merged []Interval
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && merged[n-1].ProjectID == interval.ProjectID && merged[n-1].End.Equal(interval.Start) {
+			merged[n-1].End = interval.End
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Clip restricts intervals to [from, to), dropping those entirely outside
+func Clip(intervals []Interval, from, to time.Time) []Interval {
+	var clipped []Interval
+	for _, interval := range intervals {
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.End.After(to) {
+			interval.End = to
+		}
+		if interval.End.After(interval.Start) {
+			clipped = append(clipped, interval)
+		}
+	}
+	return clipped
+}
+
+// SplitDays cuts intervals at local midnight so each lies within one day
+func SplitDays(intervals []Interval) []Interval {
+	var split []Interval
+	for _, interval := range intervals {
+		for {
+			y, m, d := interval.Start.Date()
+			midnight := time.Date(y, m, d+1, 0, 0, 0, 0, interval.Start.Location())
+			if !interval.End.After(midnight) {
+				break
+			}
+			split = append(split, Interval{ProjectID: interval.ProjectID, Start: interval.Start, End: midnight})
+			interval.Start = midnight
+		}
+		split = append(split, interval)
+	}
+	return split
+}
+
+// Total is the time accumulated under one grouping key
+type Total struct {
+	Key      string
+	Duration time.Duration
+}
+
+// Totals sums interval durations by key, largest first
+func Totals(intervals []Interval, key func(Interval) string) []Total {
+	sums := make(map[string]time.Duration)
+	for _, interval := range intervals {
+		sums[key(interval)] += interval.Duration()
+	}
+
+	totals := make([]Total, 0, len(sums))
+	for k, d := range sums {
+		totals = append(totals, Total{Key: k, Duration: d})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Duration != totals[j].Duration {
+			return totals[i].Duration > totals[j].Duration
+		}
+		return totals[i].Key < totals[j].Key
+	})
+	return totals
+}
+
+// WeekKey returns the ISO week of t, e.g. "2025-W03"
+func WeekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// TickEvent decides what a periodic activity check should log
+// lastInput is the newest client input and current the project the most
+// recently active client is on. Going quiet for idleAfter logs Idle at the
+// moment input stopped; input after an idle (or out) logs a switch-in.
+func TickEvent(last *Event, lastInput time.Time, current string, idleAfter time.Duration, now time.Time) *Event {
+	idle := now.Sub(lastInput) >= idleAfter
+	resting := last == nil || last.Type == Idle || last.Type == SwitchOut
+
+	switch {
+	case idle && !resting:
+		// Never place the idle before the event it ends
+		at := lastInput
+		if at.Before(last.Time) {
+			at = last.Time
+		}
+		return &Event{Time: at, Type: Idle, Source: "tick"}
+	case !idle && resting && current != "":
+		return &Event{Time: now, Type: SwitchIn, ProjectID: current, Source: "tick"}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package activity
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC) // Monday
+
+func at(minutes int) time.Time {
+	return base.Add(time.Duration(minutes) * time.Minute)
+}
+
+func TestIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []Event
+		maxLength time.Duration
+		now       time.Time
+		expected  []Interval
+	}{
+		{
+			name: "switches between projects",
+			events: []Event{
+				{Time: at(0), Type: SwitchIn, ProjectID: "api"},
+				{Time: at(30), Type: SwitchIn, ProjectID: "web"},
+				{Time: at(45), Type: SwitchOut},
+			},
+			now:      at(120),
+			expected: []Interval{{"api", at(0), at(30)}, {"web", at(30), at(45)}},
+		},
+		{
+			name: "repeated switch-in to the same project is one stretch",
+			events: []Event{
+				{Time: at(0), Type: SwitchIn, ProjectID: "api"},
+				{Time: at(10), Type: SwitchIn, ProjectID: "api"},
+				{Time: at(20), Type: Idle},
+			},
+			now:      at(120),
+			expected: []Interval{{"api", at(0), at(20)}},
+		},
+		{
+			name: "idle ends the interval until the next switch-in",
+			events: []Event{
+				{Time: at(0), Type: SwitchIn, ProjectID: "api"},
+				{Time: at(15), Type: Idle},
+				{Time: at(60), Type: SwitchIn, ProjectID: "api"},
+			},
+			now:      at(90),
+			expected: []Interval{{"api", at(0), at(15)}, {"api", at(60), at(90)}},
+		},
+		{
+			name: "open interval is capped",
+			events: []Event{
+				{Time: at(0), Type: SwitchIn, ProjectID: "api"},
+			},
+			maxLength: time.Hour,
+			now:       at(600),
+			expected:  []Interval{{"api", at(0), at(60)}},
+		},
+		{
+			name: "out before any switch-in is ignored",
+			events: []Event{
+				{Time: at(0), Type: SwitchOut},
+				{Time: at(5), Type: Idle},
+			},
+			now: at(10),
+		},
+	}
+
+	for _, tt := range tests {
+		got := Intervals(tt.events, tt.maxLength, tt.now)
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: got %d intervals %v, want %v", tt.name, len(got), got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: interval %d = %v, want %v", tt.name, i, got[i], tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestClipAndSplitDays(t *testing.T) {
+	// 22:00 Monday to 02:00 Wednesday
+	interval := Interval{"api", at(13 * 60), at(41 * 60)}
+
+	days := SplitDays([]Interval{interval})
+	if len(days) != 3 {
+		t.Fatalf("SplitDays gave %d intervals, want 3: %v", len(days), days)
+	}
+	expectedHours := []float64{2, 24, 2}
+	for i, day := range days {
+		if day.Duration().Hours() != expectedHours[i] {
+			t.Errorf("day %d = %vh, want %vh", i, day.Duration().Hours(), expectedHours[i])
+		}
+	}
+
+	clipped := Clip([]Interval{interval}, at(14*60), at(15*60))
+	if len(clipped) != 1 || clipped[0].Duration() != time.Hour {
+		t.Errorf("Clip = %v, want one hour", clipped)
+	}
+
+	if outside := Clip([]Interval{interval}, at(50*60), at(60*60)); len(outside) != 0 {
+		t.Errorf("Clip outside range = %v, want none", outside)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	intervals := []Interval{
+		{"api", at(0), at(30)},
+		{"web", at(30), at(90)},
+		{"api", at(100), at(130)},
+		{"docs", at(130), at(140)},
+	}
+
+	totals := Totals(intervals, func(i Interval) string { return i.ProjectID })
+	expected := []Total{{"api", time.Hour}, {"web", time.Hour}, {"docs", 10 * time.Minute}}
+	if len(totals) != len(expected) {
+		t.Fatalf("Totals = %v, want %v", totals, expected)
+	}
+	for i := range expected {
+		if totals[i] != expected[i] {
+			t.Errorf("total %d = %v, want %v", i, totals[i], expected[i])
+		}
+	}
+}
+
+func TestWeekKey(t *testing.T) {
+	tests := []struct {
+		date     time.Time
+		expected string
+	}{
+		{base, "2025-W02"},
+		{time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC), "2025-W01"},
+		{time.Date(2021, 1, 3, 12, 0, 0, 0, time.UTC), "2020-W53"},
+	}
+
+	for _, tt := range tests {
+		if got := WeekKey(tt.date); got != tt.expected {
+			t.Errorf("WeekKey(%s) = %s, want %s", tt.date.Format("2006-01-02"), got, tt.expected)
+		}
+	}
+}
+
+func TestTickEvent(t *testing.T) {
+	idleAfter := 10 * time.Minute
+	working := &Event{Time: at(0), Type: SwitchIn, ProjectID: "api"}
+	resting := &Event{Time: at(0), Type: Idle}
+
+	tests := []struct {
+		name      string
+		last      *Event
+		lastInput time.Time
+		expected  *Event
+	}{
+		{"active while working", working, at(28), nil},
+		{"gone quiet", working, at(5), &Event{Time: at(5), Type: Idle, Source: "tick"}},
+		{"quiet since before the switch-in", working, at(-5), &Event{Time: at(0), Type: Idle, Source: "tick"}},
+		{"still idle", resting, at(5), nil},
+		{"back from idle", resting, at(29), &Event{Time: at(30), Type: SwitchIn, ProjectID: "web", Source: "tick"}},
+		{"first activity ever", nil, at(29), &Event{Time: at(30), Type: SwitchIn, ProjectID: "web", Source: "tick"}},
+	}
+
+	for _, tt := range tests {
+		got := TickEvent(tt.last, tt.lastInput, "web", idleAfter, at(30))
+		switch {
+		case got == nil && tt.expected == nil:
+		case got == nil || tt.expected == nil:
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.expected)
+		case !got.Time.Equal(tt.expected.Time) || got.Type != tt.expected.Type ||
+			got.ProjectID != tt.expected.ProjectID || got.Source != tt.expected.Source:
+			t.Errorf("%s: got %+v, want %+v", tt.name, *got, *tt.expected)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, event := range []Event{
+		{Time: at(0), Type: SwitchIn, ProjectID: "api", Source: "session"},
+		{Time: at(5), Type: SwitchIn, ProjectID: "api/web", Source: "tmux"},
+		{Time: at(10), Type: SwitchIn, ProjectID: "api-old", Source: "tmux"},
+		{Time: at(15), Type: Idle, Source: "tick"},
+	} {
+		if err := Append(event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	logFile, _ := GetLogFile()
+	f, _ := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{\"time\":\n")
+	f.Close()
+
+	changed, err := Rename("api", "gateway")
+	if err != nil || changed != 2 {
+		t.Fatalf("Rename = %d, %v; want 2 events changed", changed, err)
+	}
+
+	loaded, _ := Load()
+	var ids []string
+	for _, event := range loaded {
+		ids = append(ids, event.ProjectID)
+	}
+	if strings.Join(ids, ",") != "gateway,gateway/web,api-old," {
+		t.Errorf("project IDs after rename = %v", ids)
+	}
+	if data, _ := os.ReadFile(logFile); !strings.HasSuffix(string(data), "{\"time\":\n") {
+		t.Error("unreadable lines should be kept")
+	}
+
+	if changed, err := Rename("missing", "other"); err != nil || changed != 0 {
+		t.Errorf("Rename of an unknown project = %d, %v", changed, err)
+	}
+}
+
+func TestAppendLoadLast(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if last, err := Last(); err != nil || last != nil {
+		t.Fatalf("Last on empty log = %v, %v; want nil, nil", last, err)
+	}
+
+	// Written out of order: an idle is logged after the fact
+	events := []Event{
+		{Time: at(0), Type: SwitchIn, ProjectID: "api", Source: "session"},
+		{Time: at(20), Type: SwitchIn, ProjectID: "web", Source: "tmux"},
+		{Time: at(10), Type: Idle, Source: "tick"},
+	}
+	for _, event := range events {
+		if err := Append(event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	// A torn write must not break reading
+	logFile, _ := GetLogFile()
+	f, _ := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{\"time\":\n")
+	f.Close()
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded) != 3 || loaded[1].Type != Idle || loaded[2].ProjectID != "web" {
+		t.Errorf("Load = %+v, want events sorted by time", loaded)
+	}
+
+	last, err := Last()
+	if err != nil || last == nil || last.Type != Idle {
+		t.Errorf("Last = %+v, %v; want the idle event", last, err)
+	}
+}
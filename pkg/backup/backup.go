@@ -0,0 +1,463 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/worklog"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+// Version of the bundle layout written by Create
+const Version = 1
+
+// Entries of a bundle, in the order Create writes them
+// The manifest comes first so a bundle can be described without reading
+// the rest; the repository's local config, hooks and info/ (which a git
+// bundle leaves out) follow under git/, working tree files under files/.
+const (
+	entryManifest = "manifest.json"
+	entryMetadata = "project.toml"
+	entryRepo     = "repo.bundle"
+	gitPrefix     = "git/"
+	entryActivity = "activity.jsonl"
+	entryWorkLog  = "worklog.jsonl"
+	filesPrefix   = "files/"
+)
+
+// gitLocal lists what is kept from .git besides the refs and objects
+var gitLocal = []string{"config", "hooks", "info"}
+
+// Manifest describes a backup bundle
+type Manifest struct {
+	Version      int                 `json:"version"`
+	ProjectID    string              `json:"project_id"`
+	Name         string              `json:"name"`
+	Root         string              `json:"root"` // projects, archive or scriptorium
+	Path         string              `json:"path"` // Relative to the root
+	OriginalPath string              `json:"original_path"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Git          bool                `json:"git"`
+	Branch       string              `json:"branch,omitempty"` // Checked out branch, empty when detached
+	Head         string              `json:"head,omitempty"`   // Checked out commit, empty without history
+	Remotes      map[string]string   `json:"remotes,omitempty"`
+	Files        int                 `json:"files"`             // Working tree files stored under files/
+	Deleted      []string            `json:"deleted,omitempty"` // Tracked files deleted in the working tree
+	Pins         []int               `json:"pins,omitempty"`
+	Access       *cache.AccessRecord `json:"access,omitempty"`
+}
+
+// GetBackupDir returns the default directory for new bundles
+func GetBackupDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	backupDir := filepath.Join(homeDir, ".local", "share", "pk", "backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", err
+	}
+
+	return backupDir, nil
+}
+
+// Create writes a bundle of p into outDir and returns its path
+// A git project contributes a bundle of all refs plus its untracked (not
+// ignored) and changed files; any other project contributes every file.
+func Create(p *config.Project, roots workspace.Roots, outDir string) (string, *Manifest, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.tar.gz", config.FileSafeID(p.ProjectInfo.ID), now.Format("20060102-150405"))
+	bundlePath := filepath.Join(outDir, name)
+
+	m, err := create(p, roots, bundlePath, now)
+	if err != nil {
+		return "", nil, err
+	}
+	return bundlePath, m, nil
+}
+
+// create writes a bundle of p at bundlePath
+func create(p *config.Project, roots workspace.Roots, bundlePath string, now time.Time) (*Manifest, error) {
+	root, rel, ok := roots.Locate(p.Path)
+	if !ok {
+		return nil, fmt.Errorf("%s is not under a project root", p.Path)
+	}
+
+	m := &Manifest{
+		Version:      Version,
+		ProjectID:    p.ProjectInfo.ID,
+		Name:         p.ProjectInfo.Name,
+		Root:         root,
+		Path:         rel,
+		OriginalPath: p.Path,
+		CreatedAt:    now,
+	}
+
+	var files, gitFiles []string
+	var repoBundle string
+	if isRepo(p.Path) {
+		m.Git = true
+		m.Head = git(p.Path, "rev-parse", "--verify", "--quiet", "HEAD")
+		m.Branch = git(p.Path, "branch", "--show-current")
+		m.Remotes = remotes(p.Path)
+
+		var err error
+		if files, m.Deleted, err = changedFiles(p.Path, m.Head != ""); err != nil {
+			return nil, err
+		}
+		if gitFiles, err = localGitFiles(p.Path); err != nil {
+			return nil, err
+		}
+
+		if m.Head != "" {
+			tmp, err := os.CreateTemp(filepath.Dir(bundlePath), ".repo-*.bundle")
+			if err != nil {
+				return nil, err
+			}
+			tmp.Close()
+			repoBundle = tmp.Name()
+			defer os.Remove(repoBundle)
+
+			if out, err := exec.Command("git", "-C", p.Path, "bundle", "create", "--quiet", repoBundle, "--all").CombinedOutput(); err != nil {
+				return nil, fmt.Errorf("git bundle failed: %s", strings.TrimSpace(string(out)))
+			}
+		}
+	} else {
+		var err error
+		if files, err = allFiles(p.Path); err != nil {
+			return nil, err
+		}
+	}
+	m.Files = len(files)
+
+	if pins, err := cache.LoadPins(); err == nil {
+		for slot := 1; slot <= 5; slot++ {
+			if pin, ok := pins[slot]; ok && pin.ProjectID == m.ProjectID {
+				m.Pins = append(m.Pins, slot)
+			}
+		}
+	}
+	if records, err := cache.LoadAccessRecords(); err == nil {
+		if record, ok := records[m.ProjectID]; ok {
+			m.Access = &record
+		}
+	}
+
+	if err := writeBundle(bundlePath, p, m, repoBundle, gitFiles, files); err != nil {
+		os.Remove(bundlePath)
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeBundle writes the tar.gz at path
+func writeBundle(path string, p *config.Project, m *Manifest, repoBundle string, gitFiles, files []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := addData(tw, entryManifest, manifest); err != nil {
+		return err
+	}
+
+	if data, err := os.ReadFile(filepath.Join(p.Path, ".project.toml")); err == nil {
+		if err := addData(tw, entryMetadata, data); err != nil {
+			return err
+		}
+	}
+	if repoBundle != "" {
+		if err := addFile(tw, entryRepo, repoBundle); err != nil {
+			return err
+		}
+	}
+	for _, file := range gitFiles {
+		if err := addFile(tw, gitPrefix+file, filepath.Join(p.Path, ".git", filepath.FromSlash(file))); err != nil {
+			return err
+		}
+	}
+	if err := addData(tw, entryActivity, projectActivity(m.ProjectID)); err != nil {
+		return err
+	}
+	if err := addData(tw, entryWorkLog, sharedWorkLog(m.ProjectID)); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := addFile(tw, filesPrefix+file, filepath.Join(p.Path, filepath.FromSlash(file))); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addData writes an in-memory entry, skipping empty data
+func addData(tw *tar.Writer, name string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// addFile writes the file or symlink at path as name
+// Files that vanished since they were listed are skipped.
+func addFile(tw *tar.Writer, name, path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	} else if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if link != "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// changedFiles lists what a git bundle doesn't hold: untracked files that
+// aren't ignored (nested repositories whole) and files changed since HEAD,
+// then the tracked files deleted since HEAD
+func changedFiles(dir string, hasHead bool) ([]string, []string, error) {
+	lists := [][]string{{"ls-files", "-z", "--others", "--exclude-standard"}}
+	if hasHead {
+		lists = append(lists, []string{"diff", "-z", "--name-only", "--diff-filter=d", "HEAD"})
+	} else {
+		lists = append(lists, []string{"ls-files", "-z", "--cached"})
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, args := range lists {
+		out, err := gitList(dir, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range out {
+			if file == "" || seen[file] {
+				continue
+			}
+			seen[file] = true
+
+			// An untracked nested repository is listed as its directory
+			if strings.HasSuffix(file, "/") {
+				nested, err := allFiles(filepath.Join(dir, filepath.FromSlash(file)))
+				if err != nil {
+					return nil, nil, err
+				}
+				for _, f := range nested {
+					files = append(files, file+f)
+				}
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+
+	if !hasHead {
+		return files, nil, nil
+	}
+	deleted, err := gitList(dir, "diff", "-z", "--name-only", "--diff-filter=D", "HEAD")
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, deleted, nil
+}
+
+// gitList runs a git command printing NUL separated paths in dir
+func gitList(dir string, args ...string) ([]string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// localGitFiles lists the files of gitLocal in dir's .git directory,
+// relative to it. A .git file (linked worktree) has none.
+func localGitFiles(dir string) ([]string, error) {
+	gitDir := filepath.Join(dir, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	var files []string
+	for _, name := range gitLocal {
+		path := filepath.Join(gitDir, name)
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		below, err := allFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range below {
+			files = append(files, name+"/"+file)
+		}
+	}
+	return files, nil
+}
+
+// allFiles lists every file below dir, with forward slashes
+func allFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// projectActivity returns the project's activity events as JSON lines
+func projectActivity(projectID string) []byte {
+	events, err := activity.Load()
+	if err != nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		if event.ProjectID != projectID {
+			continue
+		}
+		if data, err := json.Marshal(event); err == nil {
+			buf.Write(append(data, '\n'))
+		}
+	}
+	return buf.Bytes()
+}
+
+// sharedWorkLogFile returns the project's log in the shared log directory
+func sharedWorkLogFile(projectID string) (string, error) {
+	logDir, err := worklog.GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(logDir, config.FileSafeID(projectID)+".jsonl"), nil
+}
+
+// sharedWorkLog returns the project's shared work log, if any
+func sharedWorkLog(projectID string) []byte {
+	path, err := sharedWorkLogFile(projectID)
+	if err != nil {
+		return nil
+	}
+	data, _ := os.ReadFile(path)
+	return data
+}
+
+// remotes returns the git remotes of dir by name
+func remotes(dir string) map[string]string {
+	result := make(map[string]string)
+	for _, name := range strings.Fields(git(dir, "remote")) {
+		if url := git(dir, "remote", "get-url", name); url != "" {
+			result[name] = url
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// isRepo reports whether dir is the top of a git checkout
+func isRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// git runs a git command in dir and returns its trimmed output, or "" on error
+func git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,284 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newRepo initializes a repository at dir with a committer identity
+func newRepo(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	runGit(t, dir, "config", "user.name", "pk")
+	runGit(t, dir, "config", "user.email", "pk@example.com")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// setup points HOME at a temp dir and returns it with roots below it
+func setup(t *testing.T) (string, workspace.Roots) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	return tmpDir, rootsIn(filepath.Join(tmpDir, "a"))
+}
+
+func rootsIn(base string) workspace.Roots {
+	return workspace.Roots{
+		workspace.RootProjects: filepath.Join(base, "projects"),
+		workspace.RootArchive:  filepath.Join(base, "archive"),
+	}
+}
+
+func loadProject(t *testing.T, dir string) *config.Project {
+	t.Helper()
+	p, err := config.LoadProject(filepath.Join(dir, ".project.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// setupRepo creates the git project "app" under roots on a feature branch,
+// with an extra branch, a remote, a changed tracked file, a deleted tracked
+// file, an untracked file and an ignored file
+func setupRepo(t *testing.T, roots workspace.Roots) string {
+	t.Helper()
+	app := filepath.Join(roots[workspace.RootProjects], "work", "app")
+	newRepo(t, app)
+	writeFile(t, filepath.Join(app, ".project.toml"), "[project]\nname = \"App\"\nid = \"app\"\nstatus = \"active\"\n")
+	writeFile(t, filepath.Join(app, "README.md"), "app\n")
+	writeFile(t, filepath.Join(app, ".gitignore"), "build/\n")
+	writeFile(t, filepath.Join(app, "docs", "old.md"), "obsolete\n")
+	runGit(t, app, "add", "README.md", ".gitignore", "docs/old.md")
+	runGit(t, app, "commit", "--quiet", "-m", "Initial commit")
+	runGit(t, app, "branch", "spike")
+	runGit(t, app, "checkout", "--quiet", "-b", "feature")
+	runGit(t, app, "remote", "add", "origin", "https://example.com/app.git")
+
+	writeFile(t, filepath.Join(app, "README.md"), "app, changed\n")
+	writeFile(t, filepath.Join(app, "notes", "todo.txt"), "untracked\n")
+	writeFile(t, filepath.Join(app, "build", "out.bin"), "ignored\n")
+	os.Remove(filepath.Join(app, "docs", "old.md"))
+	return app
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	tmpDir, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	if err := cache.AddPin(2, "app", app); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.RecordAccess("app", app); err != nil {
+		t.Fatal(err)
+	}
+	activity.Append(activity.Event{Time: time.Now().Add(-time.Hour), Type: activity.SwitchIn, ProjectID: "app"})
+	activity.Append(activity.Event{Time: time.Now().Add(-time.Hour), Type: activity.SwitchIn, ProjectID: "other"})
+	logFile, _ := sharedWorkLogFile("app")
+	writeFile(t, logFile, "{\"message\":\"first\"}\n")
+
+	bundle, m, err := Create(loadProject(t, app), roots, filepath.Join(tmpDir, "out"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if m.Root != workspace.RootProjects || m.Path != "work/app" || !m.Git || m.Branch != "feature" ||
+		m.Remotes["origin"] != "https://example.com/app.git" || len(m.Pins) != 1 || m.Pins[0] != 2 || m.Access == nil {
+		t.Errorf("manifest = %+v", m)
+	}
+	// .project.toml, README.md and notes/todo.txt; build/ is ignored
+	if m.Files != 3 {
+		t.Errorf("manifest lists %d files, want 3", m.Files)
+	}
+	if len(m.Deleted) != 1 || m.Deleted[0] != "docs/old.md" {
+		t.Errorf("manifest deleted = %v, want docs/old.md", m.Deleted)
+	}
+	if inspected, err := Inspect(bundle); err != nil || inspected.ProjectID != "app" {
+		t.Errorf("Inspect = %+v, %v", inspected, err)
+	}
+
+	// The project is gone and the log lost its entry
+	os.RemoveAll(app)
+	cache.ClearPins()
+	os.Remove(logFile)
+
+	fresh := rootsIn(filepath.Join(tmpDir, "b"))
+	restored, err := Restore(bundle, fresh, "", nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	target := filepath.Join(fresh[workspace.RootProjects], "work", "app")
+	if restored.Path != target {
+		t.Errorf("restored to %s, want %s", restored.Path, target)
+	}
+
+	if branch := runGit(t, target, "branch", "--show-current"); branch != "feature" {
+		t.Errorf("branch = %s, want feature", branch)
+	}
+	if branches := runGit(t, target, "branch", "--format=%(refname:short)"); branches != "feature\nmain\nspike" {
+		t.Errorf("branches = %q", branches)
+	}
+	if origin := runGit(t, target, "remote", "get-url", "origin"); origin != "https://example.com/app.git" {
+		t.Errorf("origin = %s", origin)
+	}
+	if got := readFile(t, filepath.Join(target, "README.md")); got != "app, changed\n" {
+		t.Errorf("README.md = %q, want the uncommitted change", got)
+	}
+	if got := readFile(t, filepath.Join(target, "notes", "todo.txt")); got != "untracked\n" {
+		t.Errorf("notes/todo.txt = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(target, "build")); !os.IsNotExist(err) {
+		t.Error("ignored files should not be backed up")
+	}
+	if _, err := os.Stat(filepath.Join(target, "docs", "old.md")); !os.IsNotExist(err) {
+		t.Error("a file deleted before the backup should stay deleted")
+	}
+	if p := loadProject(t, target); p.ProjectInfo.ID != "app" {
+		t.Errorf(".project.toml id = %s", p.ProjectInfo.ID)
+	}
+
+	if pin, err := cache.GetPin(2); err != nil || pin.ProjectPath != target {
+		t.Errorf("pin 2 = %+v, %v", pin, err)
+	}
+	if records, _ := cache.LoadAccessRecords(); records["app"].ProjectPath != target {
+		t.Errorf("access record = %+v", records["app"])
+	}
+	if got := readFile(t, logFile); got != "{\"message\":\"first\"}\n" {
+		t.Errorf("work log = %q", got)
+	}
+	// Events already in the log aren't added twice
+	if events, _ := activity.Load(); len(events) != 2 {
+		t.Errorf("activity has %d events, want 2", len(events))
+	}
+}
+
+func TestRestoreKeepsExistingData(t *testing.T) {
+	tmpDir, roots := setup(t)
+	app := setupRepo(t, roots)
+	cache.AddPin(1, "app", app)
+
+	bundle, _, err := Create(loadProject(t, app), roots, filepath.Join(tmpDir, "out"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	existing := []*config.Project{loadProject(t, app)}
+	if _, err := Restore(bundle, roots, "", existing); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Restore over the project = %v, want an error", err)
+	}
+	copyPath := filepath.Join(tmpDir, "copy")
+	if _, err := Restore(bundle, roots, copyPath, existing); err == nil || !strings.Contains(err.Error(), "project 'app' already exists") {
+		t.Errorf("Restore of a copy next to the project = %v, want an error", err)
+	}
+	if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+		t.Error("a refused restore should write nothing")
+	}
+	if pin, _ := cache.GetPin(1); pin == nil || pin.ProjectPath != app {
+		t.Errorf("pin 1 = %+v, want it to stay with the project", pin)
+	}
+
+	// Once the project is gone, slot 1 holds another one
+	os.RemoveAll(app)
+	other := filepath.Join(tmpDir, "other")
+	os.MkdirAll(other, 0755)
+	cache.AddPin(1, "other", other)
+
+	restored, err := Restore(bundle, roots, copyPath, nil)
+	if err != nil {
+		t.Fatalf("Restore --to: %v", err)
+	}
+	if len(restored.Pins) != 0 || len(restored.PinsSkipped) != 1 {
+		t.Errorf("pins = %v, skipped = %v", restored.Pins, restored.PinsSkipped)
+	}
+	if pin, _ := cache.GetPin(1); pin == nil || pin.ProjectID != "other" {
+		t.Errorf("pin 1 = %+v, want it to stay with other", pin)
+	}
+}
+
+func TestCreatePlainProject(t *testing.T) {
+	tmpDir, roots := setup(t)
+
+	notes := filepath.Join(roots[workspace.RootArchive], "notes")
+	writeFile(t, filepath.Join(notes, ".project.toml"), "[project]\nname = \"Notes\"\nid = \"notes\"\nstatus = \"archived\"\n")
+	writeFile(t, filepath.Join(notes, "a", "b.md"), "deep\n")
+	os.Symlink("a/b.md", filepath.Join(notes, "link.md"))
+
+	bundle, m, err := Create(loadProject(t, notes), roots, filepath.Join(tmpDir, "out"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if m.Git || m.Root != workspace.RootArchive || m.Files != 3 {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	os.RemoveAll(notes)
+	if _, err := Restore(bundle, roots, "", nil); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := readFile(t, filepath.Join(notes, "link.md")); got != "deep\n" {
+		t.Errorf("link.md = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(notes, ".git")); !os.IsNotExist(err) {
+		t.Error("a plain project should not become a repository")
+	}
+}
+
+func TestCreateOutsideRoots(t *testing.T) {
+	tmpDir, roots := setup(t)
+
+	dir := filepath.Join(tmpDir, "elsewhere")
+	writeFile(t, filepath.Join(dir, ".project.toml"), "[project]\nname = \"X\"\nid = \"x\"\n")
+	if _, _, err := Create(loadProject(t, dir), roots, tmpDir); err == nil {
+		t.Error("Create outside the roots should fail")
+	}
+}
+
+func TestInspectRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.tar.gz")
+	os.WriteFile(path, []byte("not a bundle"), 0644)
+
+	if _, err := Inspect(path); err == nil || !strings.Contains(err.Error(), "not a pk backup") {
+		t.Errorf("Inspect = %v, want 'not a pk backup'", err)
+	}
+}
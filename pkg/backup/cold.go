@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+// ColdBundle is the bundle a compacted project keeps next to its
+// .project.toml. The directory stays where it was, so the project is still
+// found and listed; its .project.toml is the index entry and wins over the
+// copy in the bundle when the project is expanded.
+const ColdBundle = "pk-archive.tar.gz"
+
+// IsCompacted reports whether dir holds a compacted project
+func IsCompacted(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ColdBundle))
+	return err == nil
+}
+
+// Compact packs p into its ColdBundle and removes everything else from its
+// directory except .project.toml. Ignored files are dropped. It returns the
+// size of the directory before and after.
+func Compact(p *config.Project, roots workspace.Roots) (int64, int64, error) {
+	if IsCompacted(p.Path) {
+		return 0, 0, fmt.Errorf("already compacted")
+	}
+	if err := checkCompactable(p.Path); err != nil {
+		return 0, 0, err
+	}
+	before, err := dirSize(p.Path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The bundle is written next to the directory so it isn't listed in itself
+	tmp := filepath.Join(filepath.Dir(p.Path), "."+filepath.Base(p.Path)+".pk-compact")
+	if _, err := create(p, roots, tmp, time.Now()); err != nil {
+		return 0, 0, err
+	}
+	if _, err := Inspect(tmp); err != nil {
+		os.Remove(tmp)
+		return 0, 0, err
+	}
+	bundle := filepath.Join(p.Path, ColdBundle)
+	if err := os.Rename(tmp, bundle); err != nil {
+		os.Remove(tmp)
+		return 0, 0, err
+	}
+
+	entries, err := os.ReadDir(p.Path)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".project.toml" || entry.Name() == ColdBundle {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(p.Path, entry.Name())); err != nil {
+			// Put the whole tree back rather than leave half of it
+			if expandErr := Expand(p.Path); expandErr != nil {
+				return 0, 0, fmt.Errorf("failed to clean up: %w (expanding back failed: %v)", err, expandErr)
+			}
+			os.Remove(bundle)
+			return 0, 0, fmt.Errorf("failed to clean up, left expanded: %w", err)
+		}
+	}
+
+	after, err := dirSize(p.Path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return before, after, nil
+}
+
+// checkCompactable refuses repositories whose state a bundle can't hold:
+// linked worktrees, LFS objects, submodules (their repositories live
+// outside the refs) and stashes below the newest one
+func checkCompactable(dir string) error {
+	if !isRepo(dir) {
+		return nil
+	}
+	if info, err := os.Stat(filepath.Join(dir, ".git")); err != nil || !info.IsDir() {
+		return fmt.Errorf("is a linked worktree or submodule checkout, not a repository")
+	}
+	if worktrees := git(dir, "worktree", "list", "--porcelain"); strings.Count(worktrees, "worktree ") > 1 {
+		return fmt.Errorf("has linked worktrees, which would lose their repository")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "lfs")); err == nil {
+		return fmt.Errorf("uses git LFS, whose objects a bundle can't hold")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); err == nil {
+		return fmt.Errorf("has git submodules, which a bundle can't hold")
+	}
+	if stashes := git(dir, "stash", "list"); strings.Count(stashes, "\n") > 0 {
+		return fmt.Errorf("has %d stashes; only the newest would survive (drop or commit the others)", strings.Count(stashes, "\n")+1)
+	}
+	return nil
+}
+
+// Expand unpacks the ColdBundle of the compacted project at dir in place
+// The current .project.toml is kept. Pins, access and history are left
+// alone: they never went away.
+func Expand(dir string) error {
+	bundle := filepath.Join(dir, ColdBundle)
+	f, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr, err := openBundle(f)
+	if err != nil {
+		return err
+	}
+	m, err := readManifest(tr)
+	if err != nil {
+		return err
+	}
+
+	// Unpack beside the directory, then swap the two
+	tmp := filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+".pk-expand")
+	os.RemoveAll(tmp)
+	if _, err := extract(tr, m, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	index, err := os.ReadFile(filepath.Join(dir, ".project.toml"))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(tmp, ".project.toml"), index, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	old := filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+".pk-old-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err := os.Rename(dir, old); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.Rename(old, dir)
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// dirSize returns the bytes used by the files below dir
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
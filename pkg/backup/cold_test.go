@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+func TestCompactAndExpand(t *testing.T) {
+	_, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	// Archived projects live in the archive root
+	archived := filepath.Join(roots[workspace.RootArchive], "app")
+	os.MkdirAll(roots[workspace.RootArchive], 0755)
+	if err := os.Rename(app, archived); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(archived, "build", "big.bin"), strings.Repeat("x", 1<<16))
+
+	before, after, err := Compact(loadProject(t, archived), roots)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if after >= before {
+		t.Errorf("compacted size %d, want less than %d", after, before)
+	}
+	entries, _ := os.ReadDir(archived)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != ".project.toml "+ColdBundle {
+		t.Errorf("compacted directory holds %v", names)
+	}
+	if !IsCompacted(archived) {
+		t.Error("IsCompacted = false after Compact")
+	}
+	if _, _, err := Compact(loadProject(t, archived), roots); err == nil {
+		t.Error("compacting twice should fail")
+	}
+
+	// The index entry is edited while the project is cold
+	index := "[project]\nname = \"App\"\nid = \"app\"\nstatus = \"archived\"\n"
+	writeFile(t, filepath.Join(archived, ".project.toml"), index)
+
+	if err := Expand(archived); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if IsCompacted(archived) {
+		t.Error("the bundle should be gone after Expand")
+	}
+	if got := readFile(t, filepath.Join(archived, ".project.toml")); got != index {
+		t.Errorf(".project.toml = %q, want the index entry", got)
+	}
+	if got := readFile(t, filepath.Join(archived, "README.md")); got != "app, changed\n" {
+		t.Errorf("README.md = %q", got)
+	}
+	if got := readFile(t, filepath.Join(archived, "notes", "todo.txt")); got != "untracked\n" {
+		t.Errorf("notes/todo.txt = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(archived, "build")); !os.IsNotExist(err) {
+		t.Error("ignored files should be dropped")
+	}
+	if branches := runGit(t, archived, "branch", "--format=%(refname:short)"); branches != "feature\nmain\nspike" {
+		t.Errorf("branches = %q", branches)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(roots[workspace.RootArchive], ".*"))
+	if len(leftovers) > 0 {
+		t.Errorf("left behind %v", leftovers)
+	}
+}
+
+func TestCompactKeepsNestedRepositories(t *testing.T) {
+	_, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	nested := filepath.Join(app, "vendor", "lib")
+	newRepo(t, nested)
+	writeFile(t, filepath.Join(nested, "lib.go"), "package lib\n")
+	runGit(t, nested, "add", "lib.go")
+	runGit(t, nested, "commit", "--quiet", "-m", "lib")
+
+	if _, _, err := Compact(loadProject(t, app), roots); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if err := Expand(app); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if log := runGit(t, nested, "log", "--format=%s"); log != "lib" {
+		t.Errorf("nested repository log = %q", log)
+	}
+}
+
+func TestCompactKeepsLocalGitState(t *testing.T) {
+	_, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	hook := filepath.Join(app, ".git", "hooks", "pre-commit")
+	writeFile(t, hook, "#!/bin/sh\nexit 0\n")
+	os.Chmod(hook, 0755)
+	writeFile(t, filepath.Join(app, ".git", "info", "exclude"), "scratch/\n")
+	runGit(t, app, "config", "user.email", "me@example.com")
+
+	if _, _, err := Compact(loadProject(t, app), roots); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if err := Expand(app); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+
+	if info, err := os.Stat(hook); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("pre-commit hook not restored as executable: %v", err)
+	}
+	if got := readFile(t, filepath.Join(app, ".git", "info", "exclude")); got != "scratch/\n" {
+		t.Errorf("info/exclude = %q", got)
+	}
+	if email := runGit(t, app, "config", "--local", "user.email"); email != "me@example.com" {
+		t.Errorf("user.email = %q", email)
+	}
+	if url := runGit(t, app, "remote", "get-url", "origin"); url == "" {
+		t.Error("origin remote lost")
+	}
+	if _, err := os.Stat(filepath.Join(app, "docs", "old.md")); !os.IsNotExist(err) {
+		t.Error("a file deleted before compacting came back")
+	}
+	if status := runGit(t, app, "status", "--porcelain"); !strings.Contains(status, " D docs/old.md") {
+		t.Errorf("status = %q, want docs/old.md deleted", status)
+	}
+}
+
+func TestCompactRefusesWorktreesAndLFS(t *testing.T) {
+	_, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	linked := filepath.Join(roots[workspace.RootProjects], "work", "app-spike")
+	runGit(t, app, "worktree", "add", "--quiet", linked, "spike")
+	writeFile(t, filepath.Join(linked, ".project.toml"), "[project]\nname = \"Spike\"\nid = \"app-spike\"\n")
+
+	if _, _, err := Compact(loadProject(t, app), roots); err == nil || !strings.Contains(err.Error(), "worktrees") {
+		t.Errorf("Compact of a repository with worktrees = %v", err)
+	}
+	if _, _, err := Compact(loadProject(t, linked), roots); err == nil || !strings.Contains(err.Error(), "linked worktree") {
+		t.Errorf("Compact of a linked worktree = %v", err)
+	}
+
+	runGit(t, app, "worktree", "remove", "--force", linked)
+	os.MkdirAll(filepath.Join(app, ".git", "lfs", "objects"), 0755)
+	if _, _, err := Compact(loadProject(t, app), roots); err == nil || !strings.Contains(err.Error(), "LFS") {
+		t.Errorf("Compact of an LFS repository = %v", err)
+	}
+	if IsCompacted(app) {
+		t.Error("a refused project should be left alone")
+	}
+}
+
+func TestCompactRefusesStashes(t *testing.T) {
+	_, roots := setup(t)
+	app := setupRepo(t, roots)
+
+	runGit(t, app, "stash", "--quiet")
+	writeFile(t, filepath.Join(app, "README.md"), "again\n")
+	runGit(t, app, "stash", "--quiet")
+
+	if _, _, err := Compact(loadProject(t, app), roots); err == nil || !strings.Contains(err.Error(), "2 stashes") {
+		t.Errorf("Compact = %v, want a stash error", err)
+	}
+	if IsCompacted(app) {
+		t.Error("a refused project should be left alone")
+	}
+}
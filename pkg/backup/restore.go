@@ -0,0 +1,395 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+// Restored describes what Restore put back
+type Restored struct {
+	Manifest    *Manifest
+	Path        string
+	Pins        []int // Slots pinned again
+	PinsSkipped []int // Slots now holding another project
+}
+
+// Inspect reads the manifest of a bundle
+func Inspect(bundlePath string) (*Manifest, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tr, err := openBundle(f)
+	if err != nil {
+		return nil, err
+	}
+	return readManifest(tr)
+}
+
+// Restore rebuilds the project in a bundle at its original place under
+// roots, or at to when given, along with its pins, access record, activity
+// and shared work log. Nothing is overwritten: the target must not exist,
+// no project in existing may have the bundle's ID, pins go back only into
+// free slots and history is merged.
+func Restore(bundlePath string, roots workspace.Roots, to string, existing []*config.Project) (*Restored, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tr, err := openBundle(f)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A second copy would share the ID, pins and aliases of the first
+	for _, p := range existing {
+		if p.ProjectInfo.ID == m.ProjectID {
+			return nil, fmt.Errorf("project '%s' already exists at %s (rename or delete it first)", m.ProjectID, p.Path)
+		}
+	}
+
+	target := to
+	if target == "" {
+		if roots[m.Root] == "" {
+			return nil, fmt.Errorf("bundle has unknown root '%s'", m.Root)
+		}
+		target = filepath.Join(roots[m.Root], filepath.FromSlash(m.Path))
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists", target)
+	}
+
+	restored := &Restored{Manifest: m, Path: target}
+	extras, err := extract(tr, m, target)
+	if err != nil {
+		os.RemoveAll(target)
+		return nil, err
+	}
+
+	// Project data: none of it is worth failing the restore for
+	restorePins(restored)
+	restoreAccess(m, target)
+	mergeActivity(extras[entryActivity])
+	mergeWorkLog(m.ProjectID, extras[entryWorkLog])
+
+	cache.InvalidateCache()
+	return restored, nil
+}
+
+// openBundle returns a tar reader over a gzipped bundle
+func openBundle(r io.Reader) (*tar.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a pk backup: %w", err)
+	}
+	return tar.NewReader(gz), nil
+}
+
+// readManifest reads the first entry of a bundle
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	header, err := tr.Next()
+	if err != nil || header.Name != entryManifest {
+		return nil, fmt.Errorf("not a pk backup: no %s", entryManifest)
+	}
+
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version > Version {
+		return nil, fmt.Errorf("bundle version %d is newer than this pk supports (%d)", m.Version, Version)
+	}
+	if m.ProjectID == "" || m.Path == "" {
+		return nil, fmt.Errorf("invalid manifest: no project id or path")
+	}
+	return &m, nil
+}
+
+// extract writes the repository, working tree files and .project.toml into
+// target and returns the entries holding project data
+func extract(tr *tar.Reader, m *Manifest, target string) (map[string][]byte, error) {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return nil, err
+	}
+
+	extras := make(map[string][]byte)
+	if m.Git && m.Head == "" {
+		// A repository without commits has no git bundle
+		if err := initRepo(m, target, ""); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case header.Name == entryRepo:
+			if err := restoreRepo(tr, m, target); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(header.Name, gitPrefix):
+			// Comes after the repository, so this overwrites what init wrote
+			if err := writeEntry(tr, header, filepath.Join(target, ".git"), strings.TrimPrefix(header.Name, gitPrefix)); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(header.Name, filesPrefix):
+			if err := writeEntry(tr, header, target, strings.TrimPrefix(header.Name, filesPrefix)); err != nil {
+				return nil, err
+			}
+		default:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			extras[header.Name] = data
+		}
+	}
+
+	// Files deleted since the last commit were checked out again above
+	for _, name := range m.Deleted {
+		path, err := entryPath(target, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	// The metadata copy only fills in for a .project.toml the files lack
+	if data := extras[entryMetadata]; len(data) > 0 {
+		tomlPath := filepath.Join(target, ".project.toml")
+		if _, err := os.Stat(tomlPath); os.IsNotExist(err) {
+			if err := os.WriteFile(tomlPath, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return extras, nil
+}
+
+// restoreRepo recreates the repository from a git bundle: every ref, the
+// checked out branch or commit, and the remotes
+func restoreRepo(r io.Reader, m *Manifest, target string) error {
+	tmp, err := os.CreateTemp("", "pk-restore-*.bundle")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	return initRepo(m, target, tmp.Name())
+}
+
+// initRepo creates the repository at target, fetching every ref from
+// bundle when there is one
+func initRepo(m *Manifest, target, bundle string) error {
+	steps := [][]string{{"init", "--quiet"}}
+	if bundle != "" {
+		steps = append(steps, []string{"fetch", "--quiet", "--update-head-ok", bundle, "refs/*:refs/*"})
+	}
+	if m.Branch != "" {
+		steps = append(steps, []string{"symbolic-ref", "HEAD", "refs/heads/" + m.Branch})
+	} else if m.Head != "" {
+		steps = append(steps, []string{"update-ref", "--no-deref", "HEAD", m.Head})
+	}
+	if bundle != "" {
+		steps = append(steps, []string{"reset", "--quiet", "--hard"})
+	}
+	for name, url := range m.Remotes {
+		steps = append(steps, []string{"remote", "add", name, url})
+	}
+
+	for _, args := range steps {
+		if out, err := exec.Command("git", append([]string{"-C", target}, args...)...).CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// entryPath returns where the working tree file name goes below target,
+// refusing names (or symlinked directories) that lead outside it
+func entryPath(target, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("bundle entry '%s' points outside the project", name)
+	}
+	path := filepath.Join(target, clean)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("bundle entry '%s' points outside the project", name)
+	}
+	return path, nil
+}
+
+// writeEntry writes one working tree file below target
+func writeEntry(r io.Reader, header *tar.Header, target, name string) error {
+	path, err := entryPath(target, name)
+	if err != nil {
+		return err
+	}
+	os.Remove(path) // A changed file replaces the checked out one
+
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		return os.Symlink(header.Linkname, path)
+	case tar.TypeReg:
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&os.ModePerm)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return nil
+}
+
+// restorePins pins the project again in slots that are still free
+func restorePins(restored *Restored) {
+	pins, err := cache.LoadPins()
+	if err != nil {
+		return
+	}
+
+	id := restored.Manifest.ProjectID
+	for _, slot := range restored.Manifest.Pins {
+		if pin, taken := pins[slot]; taken && pin.ProjectID != id {
+			restored.PinsSkipped = append(restored.PinsSkipped, slot)
+			continue
+		}
+		if cache.AddPin(slot, id, restored.Path) == nil {
+			restored.Pins = append(restored.Pins, slot)
+		}
+	}
+}
+
+// restoreAccess brings back the access record unless the project has a
+// newer one, which then only moves to target if its path is gone
+func restoreAccess(m *Manifest, target string) {
+	if m.Access == nil {
+		return
+	}
+	records, err := cache.LoadAccessRecords()
+	if err != nil {
+		return
+	}
+
+	record := *m.Access
+	if current, ok := records[m.ProjectID]; ok && !current.LastAccessed.Before(m.Access.LastAccessed) {
+		if _, err := os.Stat(current.ProjectPath); err == nil {
+			return
+		}
+		record = current
+	}
+	record.ProjectPath = target
+	records[m.ProjectID] = record
+	cache.SaveAccessRecords(records)
+}
+
+// mergeActivity appends events from the bundle that the log lacks
+func mergeActivity(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	existing, _ := activity.Load()
+	seen := make(map[string]bool)
+	for _, event := range existing {
+		seen[eventKey(event)] = true
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var event activity.Event
+		if json.Unmarshal(scanner.Bytes(), &event) != nil || seen[eventKey(event)] {
+			continue
+		}
+		activity.Append(event)
+	}
+}
+
+func eventKey(e activity.Event) string {
+	return e.Time.UTC().Format("2006-01-02T15:04:05.999999999") + " " + e.Type + " " + e.ProjectID
+}
+
+// mergeWorkLog appends shared work log lines from the bundle that the log
+// lacks
+func mergeWorkLog(projectID string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	path, err := sharedWorkLogFile(projectID)
+	if err != nil {
+		return
+	}
+
+	existing, _ := os.ReadFile(path)
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		seen[line] = true
+	}
+
+	var missing []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line != "" && !seen[line] {
+			missing = append(missing, line)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(strings.Join(missing, "\n") + "\n")
+}
@@ -0,0 +1,278 @@
+package billing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// DefaultCurrency applies when a project sets a rate without a currency
+const DefaultCurrency = "USD"
+
+// Period is the span being billed, From inclusive and To exclusive
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// covers reports whether p ran during the period: started before it ends
+// and not completed before it begins. Missing dates don't limit it.
+func (period Period) covers(p *config.Project) bool {
+	loc := period.From.Location()
+	if started, err := time.ParseInLocation("2006-01-02", p.Dates.Started, loc); err == nil && !started.Before(period.To) {
+		return false
+	}
+	if completed, err := time.ParseInLocation("2006-01-02", p.Dates.Completed, loc); err == nil && completed.Before(period.From) {
+		return false
+	}
+	return true
+}
+
+// Line is one project on a draft invoice
+type Line struct {
+	ProjectID   string  `json:"project_id"`
+	ProjectName string  `json:"project_name"`
+	Client      string  `json:"client"`
+	Deliverable string  `json:"deliverable_type,omitempty"`
+	RateType    string  `json:"rate_type"`
+	Hours       float64 `json:"hours"`
+	Rate        float64 `json:"rate"`
+	Currency    string  `json:"currency"`
+	Amount      float64 `json:"amount"`
+	Note        string  `json:"note,omitempty"`
+}
+
+// Invoice is a draft invoice for one bill-to party
+type Invoice struct {
+	BillTo  string             `json:"bill_to"`
+	Partner string             `json:"partner,omitempty"` // Set when billed through a partner
+	Clients []string           `json:"clients"`
+	Lines   []Line             `json:"lines"`
+	Hours   float64            `json:"hours"`
+	Totals  map[string]float64 `json:"totals"` // Amount per currency
+}
+
+// NewLine prices a project's hours in period according to its rate type
+// hourly bills hours × rate, retainer bills the rate once per period while
+// the project runs (its dates overlap the period; archiving sets completed) and
+// fixed lists hours without an amount (fixed fees are billed per milestone).
+// ok is false when there is nothing to put on an invoice.
+func NewLine(p *config.Project, hours float64, period Period) (Line, bool) {
+	line := Line{
+		ProjectID:   p.ProjectInfo.ID,
+		ProjectName: p.ProjectInfo.Name,
+		Client:      p.GetClientName(),
+		Deliverable: p.Consultant.DeliverableType,
+		RateType:    p.Consultant.RateType,
+		Hours:       round2(hours),
+		Rate:        p.Consultant.Rate,
+		Currency:    p.Consultant.Currency,
+	}
+	if line.RateType == "" {
+		line.RateType = "hourly"
+	}
+	if line.Currency == "" {
+		line.Currency = DefaultCurrency
+	}
+
+	switch line.RateType {
+	case "retainer":
+		if !period.covers(p) {
+			return line, false
+		}
+		line.Amount = line.Rate
+		if line.Rate == 0 {
+			line.Note = "no rate set"
+		}
+		return line, true
+	case "fixed":
+		line.Note = "fixed fee, bill per milestone"
+	default:
+		line.Amount = round2(line.Hours * line.Rate)
+		if line.Rate == 0 {
+			line.Note = "no rate set"
+		}
+	}
+
+	return line, line.Hours > 0
+}
+
+// BuildInvoices groups billable projects into draft invoices for period
+// Projects delivered through a partner are billed to the partner, others to
+// their client. hours maps project IDs to hours worked in the period.
+// Subprojects share their parent's [consultant] block, so they get no line
+// of their own: their hours go on the parent's.
+func BuildInvoices(projects []*config.Project, hours map[string]float64, period Period) []Invoice {
+	byBillTo := make(map[string]*Invoice)
+	for _, p := range projects {
+		if !p.Consultant.Billable || p.IsSubproject() {
+			continue
+		}
+		line, ok := NewLine(p, projectHours(hours, p.ProjectInfo.ID), period)
+		if !ok {
+			continue
+		}
+
+		billTo, partner := billingParty(p)
+		invoice, exists := byBillTo[billTo]
+		if !exists {
+			invoice = &Invoice{BillTo: billTo, Partner: partner, Totals: make(map[string]float64)}
+			byBillTo[billTo] = invoice
+		}
+
+		invoice.Lines = append(invoice.Lines, line)
+		invoice.Hours = round2(invoice.Hours + line.Hours)
+		invoice.Totals[line.Currency] = round2(invoice.Totals[line.Currency] + line.Amount)
+		if line.Client != "" && !contains(invoice.Clients, line.Client) {
+			invoice.Clients = append(invoice.Clients, line.Client)
+		}
+	}
+
+	invoices := make([]Invoice, 0, len(byBillTo))
+	for _, invoice := range byBillTo {
+		sort.Strings(invoice.Clients)
+		sort.Slice(invoice.Lines, func(i, j int) bool {
+			if invoice.Lines[i].Client != invoice.Lines[j].Client {
+				return invoice.Lines[i].Client < invoice.Lines[j].Client
+			}
+			return invoice.Lines[i].ProjectID < invoice.Lines[j].ProjectID
+		})
+		invoices = append(invoices, *invoice)
+	}
+	sort.Slice(invoices, func(i, j int) bool { return invoices[i].BillTo < invoices[j].BillTo })
+
+	return invoices
+}
+
+// projectHours returns the hours of the project id and its subprojects
+func projectHours(hours map[string]float64, id string) float64 {
+	total := hours[id]
+	for other, h := range hours {
+		if strings.HasPrefix(other, id+"/") {
+			total += h
+		}
+	}
+	return total
+}
+
+// billingParty returns who receives the invoice and the partner, if any
+func billingParty(p *config.Project) (string, string) {
+	if partner := p.GetPartner(); partner != "" {
+		return partner, partner
+	}
+	if client := p.GetClientName(); client != "" {
+		return client, ""
+	}
+	return "(no client)", ""
+}
+
+// WriteMarkdown renders draft invoices as a Markdown document
+func WriteMarkdown(w io.Writer, invoices []Invoice, period string) {
+	fmt.Fprintf(w, "# Draft invoices: %s\n", period)
+
+	if len(invoices) == 0 {
+		fmt.Fprintf(w, "\nNo billable work in this period.\n")
+		return
+	}
+
+	for _, invoice := range invoices {
+		fmt.Fprintf(w, "\n## %s\n\n", invoice.BillTo)
+		if invoice.Partner != "" && len(invoice.Clients) > 0 {
+			fmt.Fprintf(w, "Via partner for: %s\n\n", strings.Join(invoice.Clients, ", "))
+		}
+
+		fmt.Fprintf(w, "| Project | Client | Type | Hours | Rate | Amount | Note |\n")
+		fmt.Fprintf(w, "|---|---|---|---:|---:|---:|---|\n")
+		for _, line := range invoice.Lines {
+			amount := ""
+			if line.RateType != "fixed" {
+				amount = formatMoney(line.Amount, line.Currency)
+			}
+			fmt.Fprintf(w, "| %s | %s | %s | %.2f | %s | %s | %s |\n",
+				line.ProjectName, line.Client, line.RateType, line.Hours,
+				formatMoney(line.Rate, line.Currency), amount, line.Note)
+		}
+
+		fmt.Fprintf(w, "\n**Total: %s** (%.2f h)\n", formatTotals(invoice.Totals), invoice.Hours)
+	}
+}
+
+// WriteCSV renders one row per invoice line
+func WriteCSV(w io.Writer, invoices []Invoice) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"bill_to", "partner", "client", "project_id", "project_name",
+		"rate_type", "hours", "rate", "currency", "amount", "note"})
+
+	for _, invoice := range invoices {
+		for _, line := range invoice.Lines {
+			writer.Write([]string{invoice.BillTo, invoice.Partner, line.Client, line.ProjectID, line.ProjectName,
+				line.RateType, formatFloat(line.Hours), formatFloat(line.Rate), line.Currency,
+				formatFloat(line.Amount), line.Note})
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON renders the invoices with their billing period
+func WriteJSON(w io.Writer, invoices []Invoice, period string) error {
+	if invoices == nil {
+		invoices = []Invoice{}
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Period   string    `json:"period"`
+		Invoices []Invoice `json:"invoices"`
+	}{period, invoices}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// formatTotals joins per-currency totals, e.g. "1200.00 USD + 300.00 EUR"
+func formatTotals(totals map[string]float64) string {
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, len(currencies))
+	for i, currency := range currencies {
+		parts[i] = formatMoney(totals[currency], currency)
+	}
+	return strings.Join(parts, " + ")
+}
+
+func formatMoney(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
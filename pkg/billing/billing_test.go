@@ -0,0 +1,187 @@
+package billing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// september is the period the tests bill
+var september = Period{
+	From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local),
+	To:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+}
+
+func consultantProject(id, client, partner, rateType string, rate float64, currency string) *config.Project {
+	p := &config.Project{}
+	p.ProjectInfo.ID = id
+	p.ProjectInfo.Name = id
+	p.Consultant.ClientName = client
+	p.Consultant.Partner = partner
+	p.Consultant.Billable = true
+	p.Consultant.RateType = rateType
+	p.Consultant.Rate = rate
+	p.Consultant.Currency = currency
+	return p
+}
+
+func TestNewLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  *config.Project
+		hours    float64
+		ok       bool
+		amount   float64
+		currency string
+		note     string
+	}{
+		{"hourly", consultantProject("a", "Acme", "", "hourly", 150, "EUR"), 12.5, true, 1875, "EUR", ""},
+		{"hourly rounds hours", consultantProject("a", "Acme", "", "hourly", 100, ""), 1.004, true, 100, "USD", ""},
+		{"hourly without hours", consultantProject("a", "Acme", "", "hourly", 150, ""), 0, false, 0, "USD", ""},
+		{"unset rate type is hourly", consultantProject("a", "Acme", "", "", 10, ""), 3, true, 30, "USD", ""},
+		{"hourly without rate", consultantProject("a", "Acme", "", "hourly", 0, ""), 2, true, 0, "USD", "no rate set"},
+		{"retainer bills without hours", consultantProject("a", "Acme", "", "retainer", 5000, ""), 0, true, 5000, "USD", ""},
+		{"fixed lists hours only", consultantProject("a", "Acme", "", "fixed", 20000, ""), 8, true, 0, "USD", "fixed fee, bill per milestone"},
+		{"fixed without hours", consultantProject("a", "Acme", "", "fixed", 20000, ""), 0, false, 0, "USD", "fixed fee, bill per milestone"},
+	}
+
+	for _, tt := range tests {
+		line, ok := NewLine(tt.project, tt.hours, september)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if line.Amount != tt.amount || line.Currency != tt.currency || line.Note != tt.note {
+			t.Errorf("%s: got amount %v %s note %q, want %v %s note %q",
+				tt.name, line.Amount, line.Currency, line.Note, tt.amount, tt.currency, tt.note)
+		}
+	}
+}
+
+func TestNewLineRetainerPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		started   string
+		completed string
+		status    string
+		ok        bool
+	}{
+		{"no dates", "", "", "active", true},
+		{"started before", "2026-01-15", "", "active", true},
+		{"started during", "2026-09-30", "", "active", true},
+		{"starts after", "2026-10-01", "", "active", false},
+		{"completed during", "2026-01-15", "2026-09-01", "completed", true},
+		{"completed after", "2026-01-15", "2026-11-30", "completed", true},
+		{"completed before", "2026-01-15", "2026-08-31", "completed", false},
+		{"archived during", "2026-01-15", "2026-09-20", "archived", true},
+		{"archived before", "2026-01-15", "2026-08-20", "archived", false},
+		{"unreadable dates", "soon", "later", "active", true},
+	}
+
+	for _, tt := range tests {
+		p := consultantProject("a", "Acme", "", "retainer", 5000, "")
+		p.Dates.Started = tt.started
+		p.Dates.Completed = tt.completed
+		p.ProjectInfo.Status = tt.status
+
+		line, ok := NewLine(p, 0, september)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if ok && line.Amount != 5000 {
+			t.Errorf("%s: amount = %v, want 5000", tt.name, line.Amount)
+		}
+	}
+}
+
+func TestBuildInvoices(t *testing.T) {
+	internal := consultantProject("internal", "", "", "hourly", 100, "")
+	internal.Consultant.Billable = false
+
+	projects := []*config.Project{
+		consultantProject("acme-api", "Acme", "West Monroe", "hourly", 150, "USD"),
+		consultantProject("beta-etl", "Beta", "West Monroe", "hourly", 120, "EUR"),
+		consultantProject("gamma", "Gamma", "", "retainer", 3000, "USD"),
+		consultantProject("idle", "Delta", "", "hourly", 100, "USD"),
+		internal,
+	}
+	hours := map[string]float64{"acme-api": 10, "beta-etl": 5, "internal": 40}
+
+	invoices := BuildInvoices(projects, hours, september)
+	if len(invoices) != 2 {
+		t.Fatalf("got %d invoices, want 2: %+v", len(invoices), invoices)
+	}
+
+	gamma, partner := invoices[0], invoices[1]
+	if gamma.BillTo != "Gamma" || gamma.Totals["USD"] != 3000 {
+		t.Errorf("retainer invoice = %+v", gamma)
+	}
+
+	if partner.BillTo != "West Monroe" || partner.Partner != "West Monroe" {
+		t.Errorf("partner invoice bill-to = %q", partner.BillTo)
+	}
+	if strings.Join(partner.Clients, ",") != "Acme,Beta" {
+		t.Errorf("partner clients = %v", partner.Clients)
+	}
+	if partner.Hours != 15 || partner.Totals["USD"] != 1500 || partner.Totals["EUR"] != 600 {
+		t.Errorf("partner totals = %v h, %v", partner.Hours, partner.Totals)
+	}
+}
+
+func TestBuildInvoicesSubprojects(t *testing.T) {
+	parent := consultantProject("dk", "Acme", "", "hourly", 100, "USD")
+	sub := consultantProject("dk/api", "Acme", "", "hourly", 100, "USD")
+	sub.Parent = "dk"
+	retainer := consultantProject("ret", "Beta", "", "retainer", 3000, "USD")
+	retainerSub := consultantProject("ret/web", "Beta", "", "retainer", 3000, "USD")
+	retainerSub.Parent = "ret"
+
+	hours := map[string]float64{"dk": 2, "dk/api": 3, "dk/web": 1.5, "dkx": 40}
+	invoices := BuildInvoices([]*config.Project{parent, sub, retainer, retainerSub}, hours, september)
+	if len(invoices) != 2 {
+		t.Fatalf("got %d invoices, want 2: %+v", len(invoices), invoices)
+	}
+
+	acme, beta := invoices[0], invoices[1]
+	if len(acme.Lines) != 1 || acme.Lines[0].ProjectID != "dk" {
+		t.Fatalf("acme lines = %+v, want only the parent", acme.Lines)
+	}
+	if acme.Hours != 6.5 || acme.Totals["USD"] != 650 {
+		t.Errorf("acme = %v h, %v; want subproject hours on the parent line", acme.Hours, acme.Totals)
+	}
+	if len(beta.Lines) != 1 || beta.Totals["USD"] != 3000 {
+		t.Errorf("beta = %+v, want the retainer billed once", beta)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	projects := []*config.Project{consultantProject("acme-api", "Acme", "", "hourly", 150, "USD")}
+	invoices := BuildInvoices(projects, map[string]float64{"acme-api": 2}, september)
+
+	var md bytes.Buffer
+	WriteMarkdown(&md, invoices, "2026-09")
+	for _, want := range []string{"# Draft invoices: 2026-09", "## Acme", "| acme-api | Acme | hourly | 2.00 | 150.00 USD | 300.00 USD |  |", "**Total: 300.00 USD** (2.00 h)"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteCSV(&csvOut, invoices); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 2 || lines[1] != "Acme,,Acme,acme-api,acme-api,hourly,2.00,150.00,USD,300.00," {
+		t.Errorf("csv = %q", csvOut.String())
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteJSON(&jsonOut, nil, "2026-09"); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if !strings.Contains(jsonOut.String(), `"invoices": []`) {
+		t.Errorf("empty json = %s", jsonOut.String())
+	}
+}
@@ -5,17 +5,65 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/datakaicr/pk/pkg/config"
 	"github.com/datakaicr/pk/pkg/paths"
 )
 
-// AccessRecord tracks when a project was last accessed
+// maxAccessHistory bounds the visit timestamps kept per project
+const maxAccessHistory = 20
+
+// AccessRecord tracks how often and when a project was accessed
 type AccessRecord struct {
-	ProjectID    string    `json:"project_id"`
-	ProjectPath  string    `json:"project_path"`
-	LastAccessed time.Time `json:"last_accessed"`
+	ProjectID    string      `json:"project_id"`
+	ProjectPath  string      `json:"project_path"`
+	LastAccessed time.Time   `json:"last_accessed"`
+	Count        int         `json:"count,omitempty"`
+	History      []time.Time `json:"history,omitempty"` // Oldest first, at most maxAccessHistory
+}
+
+// Frecency scores a record zoxide-style: every visit counts, recent ones more
+// Visits older than the kept history are weighed like the oldest kept visit.
+// Records written before visit counts existed count as a single visit.
+func (r AccessRecord) Frecency(now time.Time) float64 {
+	history := r.History
+	if len(history) == 0 {
+		if r.LastAccessed.IsZero() {
+			return 0
+		}
+		history = []time.Time{r.LastAccessed}
+	}
+
+	score := 0.0
+	for _, visit := range history {
+		score += visitWeight(now.Sub(visit))
+	}
+
+	older := r.Count - len(history)
+	if older > maxAccessHistory {
+		older = maxAccessHistory
+	}
+	if older > 0 {
+		score += float64(older) * visitWeight(now.Sub(history[0]))
+	}
+
+	return score
+}
+
+// visitWeight decays a visit's contribution with its age
+func visitWeight(age time.Duration) float64 {
+	switch {
+	case age < time.Hour:
+		return 4
+	case age < 24*time.Hour:
+		return 2
+	case age < 7*24*time.Hour:
+		return 0.5
+	default:
+		return 0.25
+	}
 }
 
 // GetAccessFile returns the path to the access tracking file
@@ -117,16 +165,86 @@ func RecordAccess(projectID, projectPath string) error {
 		return err
 	}
 
-	records[projectID] = AccessRecord{
-		ProjectID:    projectID,
-		ProjectPath:  projectPath,
-		LastAccessed: time.Now(),
-	}
+	records[projectID] = recordVisit(records[projectID], projectID, projectPath, time.Now())
 
 	return SaveAccessRecords(records)
 }
 
-// GetRecentProjects returns projects sorted by access time (most recent first)
+// recordVisit adds a visit to a record, keeping a bounded history
+func recordVisit(record AccessRecord, projectID, projectPath string, now time.Time) AccessRecord {
+	// Carry a pre-history visit over so its weight isn't lost
+	if record.Count == 0 && !record.LastAccessed.IsZero() {
+		record.Count = 1
+		record.History = []time.Time{record.LastAccessed}
+	}
+
+	record.ProjectID = projectID
+	record.ProjectPath = projectPath
+	record.LastAccessed = now
+	record.Count++
+	record.History = append(record.History, now)
+	if len(record.History) > maxAccessHistory {
+		record.History = record.History[len(record.History)-maxAccessHistory:]
+	}
+
+	return record
+}
+
+// RankProjects sorts projects by frecency (best first)
+// Ties fall back to the latest access; never-accessed projects keep their
+// relative order at the end.
+func RankProjects(projects []*config.Project, records map[string]AccessRecord, now time.Time) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		recordI, okI := records[projects[i].ProjectInfo.ID]
+		recordJ, okJ := records[projects[j].ProjectInfo.ID]
+		if !okI || !okJ {
+			return okI && !okJ
+		}
+
+		scoreI, scoreJ := recordI.Frecency(now), recordJ.Frecency(now)
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return recordI.LastAccessed.After(recordJ.LastAccessed)
+	})
+}
+
+// BestMatch returns the highest-ranked project matching every query term
+// Terms match case-insensitively against the project ID or name. Projects
+// never accessed only win when nothing accessed matches.
+func BestMatch(projects []*config.Project, records map[string]AccessRecord, terms []string, now time.Time) *config.Project {
+	var matches []*config.Project
+	for _, p := range projects {
+		if matchesTerms(p, terms) {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// Among unvisited matches prefer the shortest ID (closest to the query)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(matches[i].ProjectInfo.ID) < len(matches[j].ProjectInfo.ID)
+	})
+	RankProjects(matches, records, now)
+	return matches[0]
+}
+
+// matchesTerms reports whether every term occurs in the project's ID or name
+func matchesTerms(p *config.Project, terms []string) bool {
+	id := strings.ToLower(p.ProjectInfo.ID)
+	name := strings.ToLower(p.ProjectInfo.Name)
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		if !strings.Contains(id, term) && !strings.Contains(name, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRecentProjects returns accessed projects ranked by frecency
 func GetRecentProjects(limit int) ([]*config.Project, error) {
 	// Load access records
 	records, err := LoadAccessRecords()
@@ -147,25 +265,16 @@ func GetRecentProjects(limit int) ([]*config.Project, error) {
 	if err != nil {
 		return nil, err
 	}
+	projects = config.WithSubprojects(projects)
 
-	// Sort projects by access time
-	sort.Slice(projects, func(i, j int) bool {
-		accessI, okI := records[projects[i].ProjectInfo.ID]
-		accessJ, okJ := records[projects[j].ProjectInfo.ID]
-
-		// Projects never accessed go to the end
-		if !okI && !okJ {
-			return false
+	// Rank by frecency; never-accessed projects are not recent
+	RankProjects(projects, records, time.Now())
+	for i, p := range projects {
+		if _, ok := records[p.ProjectInfo.ID]; !ok {
+			projects = projects[:i]
+			break
 		}
-		if !okI {
-			return false
-		}
-		if !okJ {
-			return true
-		}
-
-		return accessI.LastAccessed.After(accessJ.LastAccessed)
-	})
+	}
 
 	// Apply limit
 	if limit > 0 && limit < len(projects) {
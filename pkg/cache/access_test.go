@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
 )
 
 func TestRecordAccess(t *testing.T) {
@@ -175,4 +177,118 @@ func TestUpdateExistingAccess(t *testing.T) {
 	if len(records2) != 1 {
 		t.Errorf("Expected 1 record, got %d", len(records2))
 	}
+
+	if records2[projectID].Count != 2 || len(records2[projectID].History) != 2 {
+		t.Errorf("Expected 2 visits, got count %d with %d history entries",
+			records2[projectID].Count, len(records2[projectID].History))
+	}
+}
+
+func TestRecordVisitBoundsHistory(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// A record from before visit counts existed keeps its visit
+	record := AccessRecord{ProjectID: "app", LastAccessed: now.Add(-time.Hour)}
+	record = recordVisit(record, "app", "/p/app", now)
+	if record.Count != 2 || len(record.History) != 2 {
+		t.Fatalf("legacy record: count %d, history %d; want 2, 2", record.Count, len(record.History))
+	}
+
+	for i := 0; i < 30; i++ {
+		record = recordVisit(record, "app", "/p/app", now.Add(time.Duration(i)*time.Minute))
+	}
+	if record.Count != 32 {
+		t.Errorf("Count = %d, want 32", record.Count)
+	}
+	if len(record.History) != maxAccessHistory {
+		t.Errorf("len(History) = %d, want %d", len(record.History), maxAccessHistory)
+	}
+	if last := record.History[len(record.History)-1]; !last.Equal(now.Add(29 * time.Minute)) {
+		t.Errorf("newest visit = %v, want %v", last, now.Add(29*time.Minute))
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name     string
+		record   AccessRecord
+		expected float64
+	}{
+		{"never accessed", AccessRecord{}, 0},
+		{"legacy record", AccessRecord{LastAccessed: ago(2 * time.Hour)}, 2},
+		{"one recent visit", AccessRecord{Count: 1, History: []time.Time{ago(time.Minute)}}, 4},
+		{"decayed visits", AccessRecord{Count: 3, History: []time.Time{ago(30 * 24 * time.Hour), ago(3 * 24 * time.Hour), ago(3 * time.Hour)}}, 0.25 + 0.5 + 2},
+		{"visits beyond history", AccessRecord{Count: 5, History: []time.Time{ago(2 * 24 * time.Hour), ago(time.Minute)}}, 0.5 + 4 + 3*0.5},
+	}
+
+	for _, tt := range tests {
+		if got := tt.record.Frecency(now); got != tt.expected {
+			t.Errorf("%s: Frecency = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func testProject(id string) *config.Project {
+	p := &config.Project{Path: "/p/" + id}
+	p.ProjectInfo.ID = id
+	p.ProjectInfo.Name = id
+	return p
+}
+
+func TestRankProjects(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	records := map[string]AccessRecord{
+		// Visited once, just now
+		"fresh": {LastAccessed: ago(time.Minute), Count: 1, History: []time.Time{ago(time.Minute)}},
+		// Visited daily all week
+		"daily": {LastAccessed: ago(3 * time.Hour), Count: 6, History: []time.Time{
+			ago(4 * 24 * time.Hour), ago(3 * 24 * time.Hour), ago(2 * 24 * time.Hour), ago(26 * time.Hour), ago(5 * time.Hour), ago(3 * time.Hour),
+		}},
+		// Visited once, long ago
+		"stale": {LastAccessed: ago(60 * 24 * time.Hour), Count: 1, History: []time.Time{ago(60 * 24 * time.Hour)}},
+	}
+
+	projects := []*config.Project{testProject("unused"), testProject("stale"), testProject("fresh"), testProject("daily")}
+	RankProjects(projects, records, now)
+
+	expected := []string{"daily", "fresh", "stale", "unused"}
+	for i, id := range expected {
+		if projects[i].ProjectInfo.ID != id {
+			t.Errorf("position %d = %s, want %s", i, projects[i].ProjectInfo.ID, id)
+		}
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	records := map[string]AccessRecord{
+		"api-gateway": {LastAccessed: now, Count: 1, History: []time.Time{now}},
+		"api-client":  {LastAccessed: now.Add(-48 * time.Hour), Count: 1, History: []time.Time{now.Add(-48 * time.Hour)}},
+	}
+	projects := []*config.Project{testProject("api-client"), testProject("api-gateway"), testProject("web"), testProject("website")}
+
+	tests := []struct {
+		terms    []string
+		expected string
+	}{
+		{[]string{"api"}, "api-gateway"},
+		{[]string{"API", "cli"}, "api-client"},
+		{[]string{"web"}, "web"}, // Unvisited: shortest ID wins
+		{[]string{"missing"}, ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if p := BestMatch(projects, records, tt.terms, now); p != nil {
+			got = p.ProjectInfo.ID
+		}
+		if got != tt.expected {
+			t.Errorf("BestMatch(%v) = %q, want %q", tt.terms, got, tt.expected)
+		}
+	}
 }
@@ -131,6 +131,22 @@ func AddPin(slot int, projectID, projectPath string) error {
 	return SavePins(pins)
 }
 
+// FreeSlot returns the lowest unused slot (1-5)
+func FreeSlot() (int, error) {
+	pins, err := LoadPins()
+	if err != nil {
+		return 0, err
+	}
+
+	for slot := 1; slot <= 5; slot++ {
+		if _, used := pins[slot]; !used {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("all pin slots are in use")
+}
+
 // RemovePin removes a pin by slot number
 func RemovePin(slot int) error {
 	pins, err := LoadPins()
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestFreeSlot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		pinned  []int
+		want    int
+		wantErr bool
+	}{
+		{nil, 1, false},
+		{[]int{1, 2}, 3, false},
+		{[]int{2, 4}, 1, false},
+		{[]int{1, 2, 3, 4, 5}, 0, true},
+	}
+
+	for _, tt := range tests {
+		if err := ClearPins(); err != nil {
+			t.Fatalf("ClearPins failed: %v", err)
+		}
+		for _, slot := range tt.pinned {
+			if err := AddPin(slot, "project", "/nonexistent/project"); err != nil {
+				t.Fatalf("AddPin failed: %v", err)
+			}
+		}
+
+		got, err := FreeSlot()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FreeSlot() with pins %v error = %v, wantErr %v", tt.pinned, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FreeSlot() with pins %v = %d, want %d", tt.pinned, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RelocateProject points a project's pins and access record at a new path
+// Used after pk moves a project (archive, restore) so nothing has to be
+// healed by searching later.
+func RelocateProject(projectID, newPath string) error {
+	pins, err := LoadPins()
+	if err != nil {
+		return err
+	}
+
+	pinsChanged := false
+	for slot, pin := range pins {
+		if pin.ProjectID == projectID && pin.ProjectPath != newPath {
+			pin.ProjectPath = newPath
+			pins[slot] = pin
+			pinsChanged = true
+		}
+	}
+	if pinsChanged {
+		if err := SavePins(pins); err != nil {
+			return err
+		}
+	}
+
+	records, err := LoadAccessRecords()
+	if err != nil {
+		return err
+	}
+
+	if record, ok := records[projectID]; ok && record.ProjectPath != newPath {
+		record.ProjectPath = newPath
+		records[projectID] = record
+		if err := SaveAccessRecords(records); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameProject moves a project's pins and access record to a new ID and path
+// Its subprojects (IDs under oldID/, paths under oldPath) move along. The
+// access history (visit count and times) carries over and replaces any
+// stale record left under the new ID.
+func RenameProject(oldID, newID, oldPath, newPath string) error {
+	if oldID == newID && oldPath == newPath {
+		return RelocateProject(oldID, newPath)
+	}
+
+	pins, err := LoadPins()
+	if err != nil {
+		return err
+	}
+
+	pinsChanged := false
+	for slot, pin := range pins {
+		id, ok := renamedID(pin.ProjectID, oldID, newID)
+		if !ok {
+			continue
+		}
+		pin.ProjectID = id
+		pin.ProjectPath = movedPath(pin.ProjectPath, id == newID, oldPath, newPath)
+		pins[slot] = pin
+		pinsChanged = true
+	}
+	if pinsChanged {
+		if err := SavePins(pins); err != nil {
+			return err
+		}
+	}
+
+	records, err := LoadAccessRecords()
+	if err != nil {
+		return err
+	}
+
+	renamed := make(map[string]AccessRecord)
+	for key, record := range records {
+		id, ok := renamedID(key, oldID, newID)
+		if !ok {
+			continue
+		}
+		record.ProjectID = id
+		record.ProjectPath = movedPath(record.ProjectPath, id == newID, oldPath, newPath)
+		renamed[id] = record
+		delete(records, key)
+	}
+	if len(renamed) == 0 {
+		return nil
+	}
+	for id, record := range renamed {
+		records[id] = record
+	}
+
+	return SaveAccessRecords(records)
+}
+
+// renamedID returns id under newID when it is oldID or one of its
+// subprojects (oldID/name)
+func renamedID(id, oldID, newID string) (string, bool) {
+	if id == oldID {
+		return newID, true
+	}
+	if strings.HasPrefix(id, oldID+"/") {
+		return newID + strings.TrimPrefix(id, oldID), true
+	}
+	return "", false
+}
+
+// movedPath returns where path is once the project at oldPath moves to
+// newPath. The project itself always ends at newPath; subprojects keep
+// their place inside it.
+func movedPath(path string, project bool, oldPath, newPath string) string {
+	if project {
+		return newPath
+	}
+	if rel, err := filepath.Rel(oldPath, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join(newPath, rel)
+	}
+	return path
+}
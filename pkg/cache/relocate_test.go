@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRelocateProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	oldPath := filepath.Join(tmpDir, "archive", "app")
+	newPath := filepath.Join(tmpDir, "projects", "app")
+
+	if err := AddPin(1, "app", oldPath); err != nil {
+		t.Fatalf("AddPin: %v", err)
+	}
+	if err := AddPin(2, "other", filepath.Join(tmpDir, "projects", "other")); err != nil {
+		t.Fatalf("AddPin: %v", err)
+	}
+	if err := RecordAccess("app", oldPath); err != nil {
+		t.Fatalf("RecordAccess: %v", err)
+	}
+
+	if err := RelocateProject("app", newPath); err != nil {
+		t.Fatalf("RelocateProject: %v", err)
+	}
+
+	pins, _ := LoadPins()
+	if pins[1].ProjectPath != newPath {
+		t.Errorf("pin 1 path = %s, want %s", pins[1].ProjectPath, newPath)
+	}
+	if pins[2].ProjectPath != filepath.Join(tmpDir, "projects", "other") {
+		t.Errorf("pin 2 should be untouched, got %s", pins[2].ProjectPath)
+	}
+
+	records, _ := LoadAccessRecords()
+	if records["app"].ProjectPath != newPath {
+		t.Errorf("access path = %s, want %s", records["app"].ProjectPath, newPath)
+	}
+	if records["app"].Count != 1 {
+		t.Errorf("access count = %d, want 1 (relocating is not a visit)", records["app"].Count)
+	}
+}
+
+func TestRenameProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	oldPath := filepath.Join(tmpDir, "projects", "app")
+	newPath := filepath.Join(tmpDir, "projects", "api")
+
+	if err := AddPin(3, "app", oldPath); err != nil {
+		t.Fatalf("AddPin: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := RecordAccess("app", oldPath); err != nil {
+			t.Fatalf("RecordAccess: %v", err)
+		}
+	}
+
+	if err := RenameProject("app", "api", oldPath, newPath); err != nil {
+		t.Fatalf("RenameProject: %v", err)
+	}
+
+	pins, _ := LoadPins()
+	if pins[3].ProjectID != "api" || pins[3].ProjectPath != newPath {
+		t.Errorf("pin 3 = %+v, want api at %s", pins[3], newPath)
+	}
+
+	records, _ := LoadAccessRecords()
+	if _, ok := records["app"]; ok {
+		t.Error("access record should no longer be keyed by the old ID")
+	}
+	if record := records["api"]; record.Count != 3 || record.ProjectID != "api" || record.ProjectPath != newPath {
+		t.Errorf("access record = %+v, want 3 visits under api", record)
+	}
+
+	// Renaming back restores the original keys
+	if err := RenameProject("api", "app", newPath, oldPath); err != nil {
+		t.Fatalf("RenameProject back: %v", err)
+	}
+	if records, _ := LoadAccessRecords(); records["app"].Count != 3 {
+		t.Errorf("access history lost renaming back: %+v", records)
+	}
+}
+
+func TestRenameProjectSubprojects(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	oldPath := filepath.Join(tmpDir, "projects", "svc")
+	newPath := filepath.Join(tmpDir, "projects", "core")
+	AddPin(1, "svc/web", filepath.Join(oldPath, "apps", "web"))
+	AddPin(2, "svcx", filepath.Join(tmpDir, "projects", "svcx"))
+	RecordAccess("svc", oldPath)
+	RecordAccess("svc/web", filepath.Join(oldPath, "apps", "web"))
+
+	if err := RenameProject("svc", "core", oldPath, newPath); err != nil {
+		t.Fatalf("RenameProject: %v", err)
+	}
+
+	pins, _ := LoadPins()
+	if pins[1].ProjectID != "core/web" || pins[1].ProjectPath != filepath.Join(newPath, "apps", "web") {
+		t.Errorf("subproject pin = %+v", pins[1])
+	}
+	if pins[2].ProjectID != "svcx" {
+		t.Errorf("a project sharing the prefix should be untouched, got %+v", pins[2])
+	}
+	records, _ := LoadAccessRecords()
+	if _, ok := records["svc/web"]; ok {
+		t.Error("subproject access record should no longer be keyed by the old ID")
+	}
+	if record := records["core/web"]; record.ProjectPath != filepath.Join(newPath, "apps", "web") {
+		t.Errorf("subproject access record = %+v", record)
+	}
+
+	// --id-only: the directory stays, subprojects keep their paths
+	if err := RenameProject("core", "svc", newPath, newPath); err != nil {
+		t.Fatalf("RenameProject back: %v", err)
+	}
+	if pins, _ := LoadPins(); pins[1].ProjectID != "svc/web" || pins[1].ProjectPath != filepath.Join(newPath, "apps", "web") {
+		t.Errorf("subproject pin after an ID-only rename = %+v", pins[1])
+	}
+}
@@ -1,15 +1,18 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 // Project represents a .project.toml file
 type Project struct {
-	Path string `toml:"-"` // Full path to project directory (internal, not serialized)
+	Path   string `toml:"-"` // Full path to project directory (internal, not serialized)
+	Parent string `toml:"-"` // ID of the project declaring this one in [[subprojects]], if any
 
 	// ==========================================
 	// CORE SCHEMA (universal, always present)
@@ -17,10 +20,11 @@ type Project struct {
 
 	// [project] section
 	ProjectInfo struct {
-		Name   string `toml:"name"`
-		ID     string `toml:"id"`
-		Status string `toml:"status"`
-		Type   string `toml:"type"`
+		Name   string   `toml:"name"`
+		ID     string   `toml:"id"`
+		Status string   `toml:"status"`
+		Type   string   `toml:"type"`
+		Tags   []string `toml:"tags,omitempty"` // Ad-hoc groups, e.g. "q4-focus"
 	} `toml:"project"`
 
 	// [tech] section
@@ -49,40 +53,38 @@ type Project struct {
 	} `toml:"notes"`
 
 	// [tmux] section (optional)
-	Tmux struct {
-		Layout  string       `toml:"layout"`
-		Windows []TmuxWindow `toml:"windows"`
-	} `toml:"tmux"`
+	Tmux Tmux `toml:"tmux"`
 
 	// [context] section (optional)
-	Context struct {
-		AWSProfile        string `toml:"aws_profile"`
-		AzureSubscription string `toml:"azure_subscription"`
-		GCloudProject     string `toml:"gcloud_project"`
-		DatabricksProfile string `toml:"databricks_profile"`
-		SnowflakeAccount  string `toml:"snowflake_account"`
-		GitIdentity       string `toml:"git_identity"`
-	} `toml:"context"`
+	Context Context `toml:"context"`
+
+	// [hooks] section (optional) - session lifecycle commands
+	Hooks Hooks `toml:"hooks,omitempty"`
 
 	// [dev] section (optional) - internal development planning
 	Dev struct {
 		Roadmap string `toml:"roadmap"` // Path to roadmap file (e.g., ".dev/ROADMAP.md")
 	} `toml:"dev"`
 
+	// [relations] section (optional) - typed references to other project IDs
+	Relations Relations `toml:"relations,omitempty"`
+
 	// ==========================================
 	// CONSULTANT EXTENSION (optional)
 	// ==========================================
 
 	Consultant struct {
-		Ownership       string `toml:"ownership"`        // datakai | client | shared | open-source
-		ClientName      string `toml:"client_name"`      // "Acme Corp"
-		ClientType      string `toml:"client_type"`      // direct | partner | internal
-		Partner         string `toml:"partner"`          // "West Monroe"
-		MyRole          string `toml:"my_role"`          // lead | contributor | advisor
-		DeliverableType string `toml:"deliverable_type"` // product | consulting | support
-		LicenseModel    string `toml:"license_model"`    // proprietary | client-owned | open-source
-		Billable        bool   `toml:"billable"`
-		RateType        string `toml:"rate_type"` // fixed | hourly | retainer
+		Ownership       string  `toml:"ownership"`        // datakai | client | shared | open-source
+		ClientName      string  `toml:"client_name"`      // "Acme Corp"
+		ClientType      string  `toml:"client_type"`      // direct | partner | internal
+		Partner         string  `toml:"partner"`          // "West Monroe"
+		MyRole          string  `toml:"my_role"`          // lead | contributor | advisor
+		DeliverableType string  `toml:"deliverable_type"` // product | consulting | support
+		LicenseModel    string  `toml:"license_model"`    // proprietary | client-owned | open-source
+		Billable        bool    `toml:"billable"`
+		RateType        string  `toml:"rate_type"`          // fixed | hourly | retainer
+		Rate            float64 `toml:"rate,omitzero"`      // Per hour (hourly) or per month (retainer)
+		Currency        string  `toml:"currency,omitempty"` // ISO code, e.g. "USD"
 	} `toml:"consultant"`
 
 	// ==========================================
@@ -100,6 +102,12 @@ type Project struct {
 		Maturity           string   `toml:"maturity"`         // experimental | mvp | production | deprecated
 	} `toml:"datakai"`
 
+	// [[subprojects]] - areas of a monorepo with their own alias and session
+	Subprojects []Subproject `toml:"subprojects,omitempty"`
+
+	// [[status_history]] - status transitions, oldest first
+	StatusHistory []StatusChange `toml:"status_history,omitempty"`
+
 	// ==========================================
 	// LEGACY FIELDS (backward compatibility)
 	// Auto-migrated on load, removed on save
@@ -122,11 +130,67 @@ type Project struct {
 	migrated bool `toml:"-"`
 }
 
+// StatusChange records one status transition
+type StatusChange struct {
+	From string `toml:"from"`
+	To   string `toml:"to"`
+	Date string `toml:"date"` // YYYY-MM-DD
+}
+
 // TmuxWindow represents a window configuration
 type TmuxWindow struct {
-	Name    string `toml:"name"`
+	Name    string            `toml:"name"`
+	Command string            `toml:"command"`
+	Path    string            `toml:"path"`
+	Layout  string            `toml:"layout,omitempty"` // Applied to this window only (overrides [tmux] layout)
+	Focus   bool              `toml:"focus,omitempty"`  // Select this window once the session is built
+	Env     map[string]string `toml:"env,omitempty"`    // Extra environment for every pane in the window
+	Panes   []TmuxPane        `toml:"panes,omitempty"`  // First pane is the window itself, the rest are splits
+}
+
+// Tmux holds the session layout of [tmux]
+type Tmux struct {
+	Layout  string            `toml:"layout"`
+	Env     map[string]string `toml:"env,omitempty"` // Session-wide environment variables
+	Windows []TmuxWindow      `toml:"windows"`
+}
+
+// Context holds the cloud and git identities of [context]
+type Context struct {
+	AWSProfile        string `toml:"aws_profile"`
+	AzureSubscription string `toml:"azure_subscription"`
+	GCloudProject     string `toml:"gcloud_project"`
+	DatabricksProfile string `toml:"databricks_profile"`
+	SnowflakeAccount  string `toml:"snowflake_account"`
+	GitIdentity       string `toml:"git_identity"`
+}
+
+// Hooks holds shell commands run on session lifecycle events
+// Used by [hooks] in .project.toml and in ~/.config/pk/config.toml (global)
+type Hooks struct {
+	OnSessionStart string `toml:"on_session_start,omitempty"` // After a session is created
+	OnAttach       string `toml:"on_attach,omitempty"`        // Before switching/attaching to a session
+	OnDetach       string `toml:"on_detach,omitempty"`        // When a client detaches (via tmux hook)
+	OnKill         string `toml:"on_kill,omitempty"`          // Before a session is killed
+	Timeout        string `toml:"timeout,omitempty"`          // Per-command timeout, e.g. "30s" (default 30s)
+}
+
+// Relations are typed references from a project to other projects by ID
+type Relations struct {
+	DependsOn []string `toml:"depends_on,omitempty"` // Projects this one needs to build or run
+	Deploys   []string `toml:"deploys,omitempty"`    // Projects this one ships (e.g. an infra repo)
+	ForkOf    string   `toml:"fork_of,omitempty"`    // Upstream project this one was forked from
+	PartOf    string   `toml:"part_of,omitempty"`    // Parent project, e.g. a program or monorepo
+}
+
+// TmuxPane represents a pane inside a window
+// Each pane after the first splits the pane created before it
+type TmuxPane struct {
 	Command string `toml:"command"`
-	Path    string `toml:"path"`
+	Path    string `toml:"path,omitempty"`
+	Split   string `toml:"split,omitempty"` // horizontal (side by side, default) | vertical (stacked)
+	Size    string `toml:"size,omitempty"`  // Lines/columns ("20") or percentage ("30%")
+	Focus   bool   `toml:"focus,omitempty"` // Select this pane within its window
 }
 
 // LoadProject reads a .project.toml file
@@ -145,6 +209,60 @@ func LoadProject(path string) (*Project, error) {
 	return &project, nil
 }
 
+// DecodeProject parses .project.toml content without touching the disk
+// Used to validate edited content before it is written.
+func DecodeProject(data []byte) (*Project, error) {
+	var project Project
+	if _, err := toml.Decode(string(data), &project); err != nil {
+		return nil, err
+	}
+
+	project.migrateSchema()
+	return &project, nil
+}
+
+// SaveProject writes a project back to its .project.toml
+// Comments in the original file are not preserved
+func SaveProject(project *Project) error {
+	f, err := os.Create(filepath.Join(project.Path, ".project.toml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Write header comment
+	fmt.Fprintln(f, "# Project Metadata")
+	fmt.Fprintln(f, "")
+
+	encoder := toml.NewEncoder(f)
+	return encoder.Encode(project)
+}
+
+// SetStatus changes the status and records the transition in StatusHistory
+func (p *Project) SetStatus(status, date string) {
+	if p.ProjectInfo.Status == status {
+		return
+	}
+	p.StatusHistory = append(p.StatusHistory, StatusChange{From: p.ProjectInfo.Status, To: status, Date: date})
+	p.ProjectInfo.Status = status
+}
+
+// HasTag reports whether the project carries tag (case-insensitive)
+func (p *Project) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range p.ProjectInfo.Tags {
+		if NormalizeTag(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTag lowercases a tag and drops a leading '#'
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(tag), "#"))
+}
+
 // GetOwner returns the project owner (backward compatibility)
 func (p *Project) GetOwner() string {
 	if p.Consultant.Ownership != "" {
@@ -196,6 +314,28 @@ func (p *Project) GetPartners() []string {
 	return p.LegacyOwnership.Partners
 }
 
+// RepositoryWebURL returns a browsable URL for the repository link
+// SSH remotes (git@host:user/repo.git, ssh://git@host/...) are rewritten to https
+func (p *Project) RepositoryWebURL() string {
+	url := strings.TrimSpace(p.Links.Repository)
+	if url == "" {
+		return ""
+	}
+
+	url = strings.TrimSuffix(url, ".git")
+	switch {
+	case strings.HasPrefix(url, "git@"):
+		url = "https://" + strings.Replace(strings.TrimPrefix(url, "git@"), ":", "/", 1)
+	case strings.HasPrefix(url, "ssh://"):
+		url = strings.TrimPrefix(url, "ssh://")
+		url = "https://" + strings.TrimPrefix(url, "git@")
+	case !strings.Contains(url, "://"):
+		url = "https://" + url
+	}
+
+	return url
+}
+
 // migrateSchema converts old schema format to new
 func (p *Project) migrateSchema() {
 	// Check if migration is needed
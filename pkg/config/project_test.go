@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -48,8 +49,8 @@ started = "2025-01-15"
 		t.Errorf("Expected status 'active', got '%s'", project.ProjectInfo.Status)
 	}
 
-	if project.Ownership.Primary != "test-owner" {
-		t.Errorf("Expected owner 'test-owner', got '%s'", project.Ownership.Primary)
+	if project.GetOwner() != "test-owner" {
+		t.Errorf("Expected owner 'test-owner', got '%s'", project.GetOwner())
 	}
 
 	if project.Path != tmpDir {
@@ -57,6 +58,91 @@ started = "2025-01-15"
 	}
 }
 
+func TestSaveProjectOmitsUnset(t *testing.T) {
+	project := &Project{Path: t.TempDir()}
+	project.ProjectInfo.ID = "app"
+	if err := SaveProject(project); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(project.Path, ".project.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unset := range []string{"rate =", "[hooks]", "[relations]"} {
+		if strings.Contains(string(data), unset) {
+			t.Errorf("saved project contains %q:\n%s", unset, data)
+		}
+	}
+
+	project.Consultant.Rate = 120
+	project.Hooks.OnAttach = "direnv reload"
+	project.Relations.DependsOn = []string{"db"}
+	if err := SaveProject(project); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadProject(filepath.Join(project.Path, ".project.toml"))
+	if err != nil || reloaded.Consultant.Rate != 120 || reloaded.Hooks.OnAttach != "direnv reload" ||
+		len(reloaded.Relations.DependsOn) != 1 {
+		t.Errorf("project after save = %+v, %v", reloaded, err)
+	}
+}
+
+func TestLoadProjectTmuxPanes(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectToml := filepath.Join(tmpDir, ".project.toml")
+	content := `[project]
+id = "panes"
+
+[tmux]
+env = { STAGE = "dev" }
+
+[[tmux.windows]]
+name = "code"
+layout = "main-vertical"
+focus = true
+env = { PORT = "3000" }
+panes = [
+    { command = "nvim" },
+    { command = "go test ./...", split = "horizontal", size = "40%" },
+    { command = "tail -f app.log", split = "vertical", focus = true },
+]
+
+[[tmux.windows]]
+name = "shell"
+`
+
+	if err := os.WriteFile(projectToml, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	project, err := LoadProject(projectToml)
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+
+	if project.Tmux.Env["STAGE"] != "dev" {
+		t.Errorf("Expected session env STAGE=dev, got %v", project.Tmux.Env)
+	}
+
+	if len(project.Tmux.Windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(project.Tmux.Windows))
+	}
+
+	code := project.Tmux.Windows[0]
+	if code.Layout != "main-vertical" || !code.Focus || code.Env["PORT"] != "3000" {
+		t.Errorf("Unexpected window settings: %+v", code)
+	}
+
+	if len(code.Panes) != 3 {
+		t.Fatalf("Expected 3 panes, got %d", len(code.Panes))
+	}
+
+	if code.Panes[1].Size != "40%" || code.Panes[2].Split != "vertical" || !code.Panes[2].Focus {
+		t.Errorf("Unexpected pane settings: %+v", code.Panes)
+	}
+}
+
 func TestLoadProjectMalformed(t *testing.T) {
 	tmpDir := t.TempDir()
 	projectToml := filepath.Join(tmpDir, ".project.toml")
@@ -145,3 +231,48 @@ func TestFindProjectsNonexistent(t *testing.T) {
 		t.Errorf("Expected 0 projects from nonexistent dir, got %d", len(projects))
 	}
 }
+
+func TestRepositoryWebURL(t *testing.T) {
+	tests := []struct {
+		repository string
+		expected   string
+	}{
+		{"", ""},
+		{"https://github.com/datakaicr/pk", "https://github.com/datakaicr/pk"},
+		{"https://github.com/datakaicr/pk.git", "https://github.com/datakaicr/pk"},
+		{"git@github.com:datakaicr/pk.git", "https://github.com/datakaicr/pk"},
+		{"ssh://git@gitlab.com/team/app.git", "https://gitlab.com/team/app"},
+		{"github.com/datakaicr/pk", "https://github.com/datakaicr/pk"},
+	}
+
+	for _, tt := range tests {
+		var p Project
+		p.Links.Repository = tt.repository
+		if got := p.RepositoryWebURL(); got != tt.expected {
+			t.Errorf("RepositoryWebURL(%q) = %q, want %q", tt.repository, got, tt.expected)
+		}
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	var p Project
+	p.ProjectInfo.Tags = []string{"q4-focus", "Needs-Upgrade"}
+
+	tests := []struct {
+		tag      string
+		expected bool
+	}{
+		{"q4-focus", true},
+		{"#q4-focus", true},
+		{"needs-upgrade", true},
+		{"Q4-FOCUS", true},
+		{"q4", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := p.HasTag(tt.tag); got != tt.expected {
+			t.Errorf("HasTag(%q) = %v, want %v", tt.tag, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldKind is the TOML type of a settable .project.toml field
+type FieldKind string
+
+// Field kinds
+const (
+	KindString FieldKind = "string"
+	KindBool   FieldKind = "bool"
+	KindNumber FieldKind = "number"
+	KindList   FieldKind = "list" // Array of strings
+)
+
+// Field describes one scalar or string-list field of .project.toml,
+// addressed by its dotted path, e.g. "consultant.billable"
+type Field struct {
+	Path  string
+	Table string
+	Key   string
+	Kind  FieldKind
+	index []int
+}
+
+// legacyTables are read for migration only and never written by pk
+var legacyTables = map[string]bool{"ownership": true, "client": true}
+
+// SchemaFields returns every settable field of Project, sorted by path
+// Arrays of tables ([[tmux.windows]]) and maps (env) are not included.
+func SchemaFields() []Field {
+	var fields []Field
+	projectType := reflect.TypeOf(Project{})
+
+	for i := 0; i < projectType.NumField(); i++ {
+		table := projectType.Field(i)
+		tableName := tomlName(table)
+		if tableName == "" || legacyTables[tableName] || table.Type.Kind() != reflect.Struct {
+			continue
+		}
+
+		for j := 0; j < table.Type.NumField(); j++ {
+			field := table.Type.Field(j)
+			key := tomlName(field)
+			if key == "" {
+				continue
+			}
+
+			var kind FieldKind
+			switch field.Type.Kind() {
+			case reflect.String:
+				kind = KindString
+			case reflect.Bool:
+				kind = KindBool
+			case reflect.Float64, reflect.Int:
+				kind = KindNumber
+			case reflect.Slice:
+				if field.Type.Elem().Kind() != reflect.String {
+					continue
+				}
+				kind = KindList
+			default:
+				continue
+			}
+
+			fields = append(fields, Field{
+				Path:  tableName + "." + key,
+				Table: tableName,
+				Key:   key,
+				Kind:  kind,
+				index: []int{i, j},
+			})
+		}
+	}
+
+	sort.Slice(fields, func(a, b int) bool { return fields[a].Path < fields[b].Path })
+	return fields
+}
+
+// LookupField finds a field by dotted path
+func LookupField(path string) (Field, bool) {
+	for _, f := range SchemaFields() {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// tomlName returns the TOML key of a struct field, or "" if it isn't serialized
+func tomlName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// Get returns the field's value in p: string, bool, float64 or []string
+func (f Field) Get(p *Project) interface{} {
+	v := reflect.ValueOf(p).Elem().FieldByIndex(f.index)
+	if f.Kind == KindList {
+		return append([]string{}, v.Interface().([]string)...)
+	}
+	if v.Kind() == reflect.Int {
+		return float64(v.Int())
+	}
+	return v.Interface()
+}
+
+// Set stores a value of the field's kind (as returned by Parse) in p
+func (f Field) Set(p *Project, value interface{}) {
+	v := reflect.ValueOf(p).Elem().FieldByIndex(f.index)
+	if v.Kind() == reflect.Int {
+		v.SetInt(int64(value.(float64)))
+		return
+	}
+	v.Set(reflect.ValueOf(value))
+}
+
+// Parse converts a command-line value to the field's kind
+// Lists are comma-separated; dates in [dates] must be YYYY-MM-DD.
+func (f Field) Parse(raw string) (interface{}, error) {
+	switch f.Kind {
+	case KindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got '%s'", f.Path, raw)
+		}
+		return b, nil
+	case KindNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got '%s'", f.Path, raw)
+		}
+		return n, nil
+	case KindList:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+
+	if f.Table == "dates" && raw != "" {
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, fmt.Errorf("%s must be a date (YYYY-MM-DD), got '%s'", f.Path, raw)
+		}
+	}
+	return raw, nil
+}
+
+// FormatValue renders a field value for display; lists are comma-separated
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupField(t *testing.T) {
+	tests := []struct {
+		path string
+		kind FieldKind
+		ok   bool
+	}{
+		{"project.status", KindString, true},
+		{"consultant.billable", KindBool, true},
+		{"consultant.rate", KindNumber, true},
+		{"tech.stack", KindList, true},
+		{"datakai.protocols", KindList, true},
+		{"hooks.on_attach", KindString, true},
+		{"tmux.windows", "", false}, // Array of tables
+		{"tmux.env", "", false},     // Map
+		{"ownership.primary", "", false},
+		{"project.nope", "", false},
+	}
+
+	for _, tt := range tests {
+		f, ok := LookupField(tt.path)
+		if ok != tt.ok || f.Kind != tt.kind {
+			t.Errorf("LookupField(%s) = %v %v, want %v %v", tt.path, f.Kind, ok, tt.kind, tt.ok)
+		}
+	}
+}
+
+func TestFieldGetSet(t *testing.T) {
+	p := &Project{}
+	tests := []struct {
+		path string
+		raw  string
+		want interface{}
+	}{
+		{"consultant.client_name", "Acme Corp", "Acme Corp"},
+		{"consultant.billable", "true", true},
+		{"consultant.rate", "150.5", 150.5},
+		{"tech.stack", "go, rust,,sql", []string{"go", "rust", "sql"}},
+		{"dates.started", "2025-01-15", "2025-01-15"},
+	}
+
+	for _, tt := range tests {
+		f, _ := LookupField(tt.path)
+		value, err := f.Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("Parse(%s, %s): %v", tt.path, tt.raw, err)
+		}
+		f.Set(p, value)
+		if got := f.Get(p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	if !p.Consultant.Billable || p.Tech.Stack[1] != "rust" {
+		t.Errorf("Set did not write through to the project: %+v", p.Consultant)
+	}
+}
+
+func TestFieldParseErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		raw  string
+	}{
+		{"consultant.billable", "yes please"},
+		{"consultant.rate", "lots"},
+		{"dates.completed", "last week"},
+	}
+
+	for _, tt := range tests {
+		f, _ := LookupField(tt.path)
+		if _, err := f.Parse(tt.raw); err == nil {
+			t.Errorf("Parse(%s, %s) should fail", tt.path, tt.raw)
+		}
+	}
+}
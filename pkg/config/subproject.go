@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Subproject is an area of a monorepo that is listed, aliased, pinned and
+// opened as a session on its own, configured as [[subprojects]]:
+//
+//	[[subprojects]]
+//	name = "dojo"
+//	path = "apps/dojo"
+//	context = {aws_profile = "dojo-dev"}
+type Subproject struct {
+	Name    string  `toml:"name"`              // Alias; the ID becomes "<parent id>/<name>"
+	Path    string  `toml:"path"`              // Directory relative to the project
+	Tmux    Tmux    `toml:"tmux,omitempty"`    // Session layout (default: a single window)
+	Context Context `toml:"context,omitempty"` // Overrides the parent's [context] field by field
+}
+
+// SubprojectID joins a parent ID and a subproject name, e.g. "dk/dojo"
+func SubprojectID(parentID, name string) string {
+	return parentID + "/" + name
+}
+
+// FileSafeID escapes the "/" of subproject IDs so an ID can name a file
+func FileSafeID(id string) string {
+	return strings.ReplaceAll(id, "/", "%2F")
+}
+
+// IsSubproject reports whether p was expanded from a parent's [[subprojects]]
+func (p *Project) IsSubproject() bool {
+	return p.Parent != ""
+}
+
+// Alias returns the shell alias name of a project: the ID, or the bare
+// name for subprojects
+func (p *Project) Alias() string {
+	if p.IsSubproject() {
+		return strings.TrimPrefix(p.ProjectInfo.ID, p.Parent+"/")
+	}
+	return p.ProjectInfo.ID
+}
+
+// CheckSubprojects reports [[subprojects]] entries that can't be used
+func (p *Project) CheckSubprojects() []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, s := range p.Subprojects {
+		err := p.checkSubproject(s)
+		if err == nil && seen[strings.ToLower(s.Name)] {
+			err = fmt.Errorf("subproject '%s' is defined twice", s.Name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		seen[strings.ToLower(s.Name)] = true
+	}
+	return errs
+}
+
+// checkSubproject validates one [[subprojects]] entry
+func (p *Project) checkSubproject(s Subproject) error {
+	if s.Name == "" || strings.ContainsAny(s.Name, "/\\ \t") {
+		return fmt.Errorf("subproject name '%s' is empty or has slashes or spaces", s.Name)
+	}
+
+	clean := filepath.Clean(s.Path)
+	if s.Path == "" || filepath.IsAbs(s.Path) || clean == "." || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("subproject '%s' path '%s' must be relative and inside the project", s.Name, s.Path)
+	}
+
+	dir := filepath.Join(p.Path, clean)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("subproject '%s' directory %s doesn't exist", s.Name, dir)
+	}
+	return nil
+}
+
+// ExpandSubprojects returns p's usable subprojects as projects of their own
+// They share the parent's metadata, merge its [context] with their own
+// overrides and use only their own [tmux] layout. Entries that
+// CheckSubprojects rejects are skipped.
+func (p *Project) ExpandSubprojects() []*Project {
+	var subs []*Project
+	seen := make(map[string]bool)
+	for _, s := range p.Subprojects {
+		if p.checkSubproject(s) != nil || seen[strings.ToLower(s.Name)] {
+			continue
+		}
+		seen[strings.ToLower(s.Name)] = true
+
+		sub := &Project{
+			Path:       filepath.Join(p.Path, filepath.Clean(s.Path)),
+			Parent:     p.ProjectInfo.ID,
+			Tech:       p.Tech,
+			Dates:      p.Dates,
+			Links:      p.Links,
+			Tmux:       s.Tmux,
+			Context:    p.Context.Merge(s.Context),
+			Consultant: p.Consultant,
+			DataKai:    p.DataKai,
+		}
+		sub.ProjectInfo = p.ProjectInfo
+		sub.ProjectInfo.ID = SubprojectID(p.ProjectInfo.ID, s.Name)
+		sub.ProjectInfo.Name = p.ProjectInfo.Name + " / " + s.Name
+		sub.ProjectInfo.Tags = append([]string{}, p.ProjectInfo.Tags...)
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// WithSubprojects returns projects with each one's subprojects after it
+func WithSubprojects(projects []*Project) []*Project {
+	result := make([]*Project, 0, len(projects))
+	for _, p := range projects {
+		result = append(result, p)
+		result = append(result, p.ExpandSubprojects()...)
+	}
+	return result
+}
+
+// Merge returns c with every field set in override replaced
+func (c Context) Merge(override Context) Context {
+	if override.AWSProfile != "" {
+		c.AWSProfile = override.AWSProfile
+	}
+	if override.AzureSubscription != "" {
+		c.AzureSubscription = override.AzureSubscription
+	}
+	if override.GCloudProject != "" {
+		c.GCloudProject = override.GCloudProject
+	}
+	if override.DatabricksProfile != "" {
+		c.DatabricksProfile = override.DatabricksProfile
+	}
+	if override.SnowflakeAccount != "" {
+		c.SnowflakeAccount = override.SnowflakeAccount
+	}
+	if override.GitIdentity != "" {
+		c.GitIdentity = override.GitIdentity
+	}
+	return c
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const monorepoToml = `[project]
+name = "DataKai"
+id = "dk"
+status = "active"
+tags = ["core"]
+
+[context]
+aws_profile = "dk"
+git_identity = "work"
+
+[[subprojects]]
+name = "dojo"
+path = "apps/dojo"
+context = {aws_profile = "dojo-dev"}
+
+[subprojects.tmux]
+layout = "main-vertical"
+windows = [{name = "web", command = "npm run dev"}]
+
+[[subprojects]]
+name = "vision"
+path = "docs/vision"
+
+[[subprojects]]
+name = "gone"
+path = "apps/gone"
+
+[[subprojects]]
+name = "escape"
+path = "../elsewhere"
+`
+
+func writeMonorepo(t *testing.T) *Project {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "dk")
+	for _, dir := range []string{"apps/dojo", "docs/vision"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".project.toml"), []byte(monorepoToml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := LoadProject(filepath.Join(root, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	return project
+}
+
+func TestExpandSubprojects(t *testing.T) {
+	project := writeMonorepo(t)
+
+	subs := project.ExpandSubprojects()
+	if len(subs) != 2 {
+		t.Fatalf("expanded %d subprojects, want 2 (missing and escaping paths skipped)", len(subs))
+	}
+
+	dojo, vision := subs[0], subs[1]
+	if dojo.ProjectInfo.ID != "dk/dojo" || dojo.Alias() != "dojo" || !dojo.IsSubproject() {
+		t.Errorf("dojo = %s (alias %s, parent %q)", dojo.ProjectInfo.ID, dojo.Alias(), dojo.Parent)
+	}
+	if dojo.Path != filepath.Join(project.Path, "apps", "dojo") {
+		t.Errorf("dojo path = %s", dojo.Path)
+	}
+	if dojo.ProjectInfo.Status != "active" || !dojo.HasTag("core") {
+		t.Errorf("dojo should share the parent's status and tags: %+v", dojo.ProjectInfo)
+	}
+
+	// Context is inherited field by field, tmux only from the subproject
+	if dojo.Context.AWSProfile != "dojo-dev" || dojo.Context.GitIdentity != "work" {
+		t.Errorf("dojo context = %+v", dojo.Context)
+	}
+	if vision.Context.AWSProfile != "dk" {
+		t.Errorf("vision context = %+v, want the parent's", vision.Context)
+	}
+	if dojo.Tmux.Layout != "main-vertical" || len(dojo.Tmux.Windows) != 1 || len(vision.Tmux.Windows) != 0 {
+		t.Errorf("tmux: dojo %+v, vision %+v", dojo.Tmux, vision.Tmux)
+	}
+
+	if project.IsSubproject() || project.Alias() != "dk" {
+		t.Errorf("parent should not be a subproject")
+	}
+	if all := WithSubprojects([]*Project{project}); len(all) != 3 || all[0] != project || all[2].ProjectInfo.ID != "dk/vision" {
+		t.Errorf("WithSubprojects = %d projects, want dk, dk/dojo, dk/vision", len(all))
+	}
+}
+
+func TestCheckSubprojects(t *testing.T) {
+	project := writeMonorepo(t)
+	project.Subprojects = append(project.Subprojects, Subproject{Name: "dojo", Path: "apps/dojo"}, Subproject{Name: "a b", Path: "apps"})
+
+	var got []string
+	for _, err := range project.CheckSubprojects() {
+		got = append(got, err.Error())
+	}
+	joined := strings.Join(got, "\n")
+	for _, want := range []string{"'gone' directory", "'escape' path '../elsewhere'", "'dojo' is defined twice", "name 'a b'"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("CheckSubprojects missing %q:\n%s", want, joined)
+		}
+	}
+	if len(got) != 4 {
+		t.Errorf("CheckSubprojects = %d problems, want 4:\n%s", len(got), joined)
+	}
+}
+
+func TestSaveProjectKeepsSubprojects(t *testing.T) {
+	project := writeMonorepo(t)
+	if err := SaveProject(project); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadProject(filepath.Join(project.Path, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject after save failed: %v", err)
+	}
+	if len(reloaded.Subprojects) != 4 || reloaded.Subprojects[0].Context.AWSProfile != "dojo-dev" ||
+		reloaded.Subprojects[0].Tmux.Windows[0].Command != "npm run dev" {
+		t.Errorf("subprojects after save = %+v", reloaded.Subprojects)
+	}
+}
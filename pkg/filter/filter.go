@@ -0,0 +1,334 @@
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// Fields maps filter field names to the project values they compare against
+// List fields (tag, stack, domain) match when any element matches.
+var Fields = map[string]func(*config.Project) []string{
+	"id":          func(p *config.Project) []string { return []string{p.ProjectInfo.ID} },
+	"name":        func(p *config.Project) []string { return []string{p.ProjectInfo.Name} },
+	"status":      func(p *config.Project) []string { return []string{p.ProjectInfo.Status} },
+	"type":        func(p *config.Project) []string { return []string{p.ProjectInfo.Type} },
+	"tag":         func(p *config.Project) []string { return p.ProjectInfo.Tags },
+	"owner":       func(p *config.Project) []string { return []string{p.GetOwner()} },
+	"client":      func(p *config.Project) []string { return []string{p.GetClientName()} },
+	"partner":     func(p *config.Project) []string { return []string{p.GetPartner()} },
+	"role":        func(p *config.Project) []string { return []string{p.GetMyRole()} },
+	"billable":    func(p *config.Project) []string { return []string{strconv.FormatBool(p.Consultant.Billable)} },
+	"rate_type":   func(p *config.Project) []string { return []string{p.Consultant.RateType} },
+	"deliverable": func(p *config.Project) []string { return []string{p.Consultant.DeliverableType} },
+	"stack":       func(p *config.Project) []string { return p.Tech.Stack },
+	"domain":      func(p *config.Project) []string { return p.Tech.Domain },
+	"started":     func(p *config.Project) []string { return []string{p.Dates.Started} },
+	"completed":   func(p *config.Project) []string { return []string{p.Dates.Completed} },
+	"path":        func(p *config.Project) []string { return []string{p.Path} },
+}
+
+// FieldNames returns the known field names, sorted
+func FieldNames() []string {
+	names := make([]string, 0, len(Fields))
+	for name := range Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Expr is a parsed filter expression
+type Expr interface {
+	Match(p *config.Project) bool
+}
+
+// Parse compiles a filter such as
+//
+//	client="Acme Corp" and (status=completed or status=paused)
+//
+// Operators: = and != (case-insensitive), ~ and !~ (contains), < <= > >=
+// (numeric when both sides are numbers, otherwise string order, which suits
+// YYYY-MM-DD dates). Conditions combine with and, or, not and parentheses.
+func Parse(input string) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in filter", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// Select returns the projects matching expr, keeping their order
+func Select(projects []*config.Project, expr Expr) []*config.Project {
+	var selected []*config.Project
+	for _, p := range projects {
+		if expr.Match(p) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+type andExpr struct{ left, right Expr }
+type orExpr struct{ left, right Expr }
+type notExpr struct{ inner Expr }
+
+type compareExpr struct {
+	field string
+	op    string
+	value string
+}
+
+func (e andExpr) Match(p *config.Project) bool { return e.left.Match(p) && e.right.Match(p) }
+func (e orExpr) Match(p *config.Project) bool  { return e.left.Match(p) || e.right.Match(p) }
+func (e notExpr) Match(p *config.Project) bool { return !e.inner.Match(p) }
+
+func (e compareExpr) Match(p *config.Project) bool {
+	values := Fields[e.field](p)
+	if len(values) == 0 {
+		// An empty list compares like an empty value
+		values = []string{""}
+	}
+
+	// Negated operators hold when no value matches
+	switch e.op {
+	case "!=":
+		return !anyValue(values, func(v string) bool { return strings.EqualFold(v, e.value) })
+	case "!~":
+		return !anyValue(values, func(v string) bool { return contains(v, e.value) })
+	}
+
+	return anyValue(values, func(v string) bool {
+		switch e.op {
+		case "=":
+			return strings.EqualFold(v, e.value)
+		case "~":
+			return contains(v, e.value)
+		default:
+			if v == "" {
+				return false
+			}
+			c := compare(v, e.value)
+			switch e.op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			case ">=":
+				return c >= 0
+			}
+		}
+		return false
+	})
+}
+
+func anyValue(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// compare orders numerically when both sides are numbers
+func compare(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// Token kinds
+const (
+	tokWord = iota
+	tokString
+	tokOp
+	tokOpen
+	tokClose
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokOpen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokClose, ")"})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			var b strings.Builder
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' && end+1 < len(runes) {
+					end++
+				}
+				b.WriteRune(runes[end])
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, token{tokString, b.String()})
+			i = end + 1
+		case strings.ContainsRune("=!~<>", r):
+			op := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=~", runes[i+1]) && r != '=' && r != '~' {
+				op += string(runes[i+1])
+			}
+			switch op {
+			case "=", "!=", "~", "!~", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("unknown operator '%s' in filter", op)
+			}
+			tokens = append(tokens, token{tokOp, op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=!~<>\"'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[start:i])})
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokWord &&
+		strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("filter ends early")
+	}
+
+	if p.peekKeyword("not") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	}
+
+	if p.tokens[p.pos].kind == tokOpen {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokClose {
+			return nil, fmt.Errorf("missing ')' in filter")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	if p.pos+3 > len(p.tokens) {
+		return nil, fmt.Errorf("incomplete condition in filter (use field=value)")
+	}
+
+	field, op, value := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	if field.kind != tokWord {
+		return nil, fmt.Errorf("expected a field name, got '%s'", field.text)
+	}
+	name := strings.ToLower(field.text)
+	if _, ok := Fields[name]; !ok {
+		return nil, fmt.Errorf("unknown field '%s' (known: %s)", field.text, strings.Join(FieldNames(), ", "))
+	}
+	if op.kind != tokOp {
+		return nil, fmt.Errorf("expected an operator after '%s', got '%s'", field.text, op.text)
+	}
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, fmt.Errorf("expected a value after '%s%s'", field.text, op.text)
+	}
+
+	p.pos += 3
+	return compareExpr{field: name, op: op.text, value: value.text}, nil
+}
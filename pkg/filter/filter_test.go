@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func testProjects() []*config.Project {
+	newProject := func(id, status, client string, billable bool, started string, stack ...string) *config.Project {
+		p := &config.Project{}
+		p.ProjectInfo.ID = id
+		p.ProjectInfo.Name = id
+		p.ProjectInfo.Status = status
+		p.Consultant.ClientName = client
+		p.Consultant.Billable = billable
+		p.Dates.Started = started
+		p.Tech.Stack = stack
+		return p
+	}
+
+	projects := []*config.Project{
+		newProject("acme-api", "completed", "Acme Corp", true, "2025-01-10", "go", "postgres"),
+		newProject("acme-etl", "active", "Acme Corp", true, "2025-06-01", "python"),
+		newProject("beta-web", "completed", "Beta", false, "2024-11-20", "typescript"),
+		newProject("internal", "paused", "", false, ""),
+	}
+	projects[0].ProjectInfo.Tags = []string{"q4-focus"}
+	projects[2].ProjectInfo.Tags = []string{"q4-focus", "needs-upgrade"}
+	return projects
+}
+
+func TestParseAndSelect(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{`client="Acme Corp" and status=completed`, "acme-api"},
+		{`client='acme corp'`, "acme-api,acme-etl"},
+		{`status=completed or status=paused`, "acme-api,beta-web,internal"},
+		{`not status=completed`, "acme-etl,internal"},
+		{`client!="Acme Corp"`, "beta-web,internal"},
+		{`client=""`, "internal"},
+		{`id~acme and (status=active or billable=false)`, "acme-etl"},
+		{`stack=go`, "acme-api"},
+		{`tag=q4-focus and tag!=needs-upgrade`, "acme-api"},
+		{`tag=""`, "acme-etl,internal"},
+		{`stack!~py`, "acme-api,beta-web,internal"},
+		{`started>=2025-01-01`, "acme-api,acme-etl"},
+		{`started<2025-01-01`, "beta-web"},
+		{`STATUS = Completed AND billable = true`, "acme-api"},
+	}
+
+	projects := testProjects()
+	for _, tt := range tests {
+		expr, err := Parse(tt.filter)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.filter, err)
+			continue
+		}
+
+		var ids []string
+		for _, p := range Select(projects, expr) {
+			ids = append(ids, p.ProjectInfo.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.expected {
+			t.Errorf("%s => %s, want %s", tt.filter, got, tt.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"status",
+		"status=",
+		"colour=red",
+		`client="Acme`,
+		"(status=active",
+		"status=active and",
+		"status=active extra",
+		"status<>active",
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
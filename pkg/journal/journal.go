@@ -0,0 +1,427 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/session"
+	"github.com/datakaicr/pk/pkg/trash"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+// Step kinds
+const (
+	StepMove     = "move"     // Directory or file moved From → To
+	StepWrite    = "write"    // File at Path written (Before holds the old content)
+	StepRelocate = "relocate" // Pins and access record of ProjectID pointed From → To
+	StepRekey    = "rekey"    // Pins and access record moved from ProjectID at From to NewID at To
+	StepSession  = "session"  // tmux session renamed From → To (NewID is the marked project)
+	StepTrash    = "trash"    // Directory moved into the trash as TrashID
+	StepSync     = "sync"     // Shell aliases regenerated
+	StepCompact  = "compact"  // Project at Path packed into its cold storage bundle
+	StepExpand   = "expand"   // Project at Path unpacked from its cold storage bundle (From is its archive root)
+	StepActivity = "activity" // Activity log events of ProjectID and its subprojects pointed at NewID
+)
+
+// maxOperations is how many operations the journal keeps
+const maxOperations = 200
+
+// Step is one recorded change and what is needed to reverse it
+type Step struct {
+	Kind      string `json:"kind"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Existed   bool   `json:"existed,omitempty"` // StepWrite: the file existed before
+	Before    []byte `json:"before,omitempty"`  // StepWrite: previous content
+	After     string `json:"after,omitempty"`   // StepWrite: sha256 of the written content
+	ProjectID string `json:"project_id,omitempty"`
+	NewID     string `json:"new_id,omitempty"`
+	TrashID   string `json:"trash_id,omitempty"`
+}
+
+// Operation is one mutating command and its steps in the order they ran
+type Operation struct {
+	ID      int       `json:"id"`
+	Command string    `json:"command"`
+	Time    time.Time `json:"time"`
+	Steps   []Step    `json:"steps"`
+	Undone  bool      `json:"undone,omitempty"`
+}
+
+// Has reports whether the operation contains a step of the given kind
+func (op *Operation) Has(kind string) bool {
+	for _, step := range op.Steps {
+		if step.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJournalFile returns the path to the operation journal
+func GetJournalFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	stateDir := filepath.Join(homeDir, ".local", "state", "pk")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(stateDir, "journal.jsonl"), nil
+}
+
+// Load returns all journaled operations, oldest first
+func Load() ([]Operation, error) {
+	journalFile, err := GetJournalFile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(journalFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var ops []Operation
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var op Operation
+		if json.Unmarshal(scanner.Bytes(), &op) == nil && op.ID > 0 {
+			ops = append(ops, op)
+		}
+	}
+	return ops, scanner.Err()
+}
+
+// save rewrites the journal, keeping the newest maxOperations
+func save(ops []Operation) error {
+	journalFile, err := GetJournalFile()
+	if err != nil {
+		return err
+	}
+
+	if len(ops) > maxOperations {
+		ops = ops[len(ops)-maxOperations:]
+	}
+
+	var b strings.Builder
+	for _, op := range ops {
+		data, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	tmpFile := journalFile + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, journalFile)
+}
+
+// Undoable returns up to n operations that can still be undone, newest first
+func Undoable(ops []Operation, n int) []Operation {
+	var result []Operation
+	for i := len(ops) - 1; i >= 0 && len(result) < n; i-- {
+		if !ops[i].Undone {
+			result = append(result, ops[i])
+		}
+	}
+	return result
+}
+
+// Undo reverses an operation and marks it undone in the journal
+// Every step is checked first so nothing is touched when the tree has
+// changed in a way that makes the operation impossible to reverse.
+func Undo(op Operation) error {
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		if err := check(op.Steps[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		if err := reverse(op.Steps[i]); err != nil {
+			return fmt.Errorf("undo stopped at step %d (%s): %w", i+1, op.Steps[i].Kind, err)
+		}
+	}
+
+	ops, err := Load()
+	if err != nil {
+		return err
+	}
+	for i := range ops {
+		if ops[i].ID == op.ID {
+			ops[i].Undone = true
+		}
+	}
+	return save(ops)
+}
+
+// check reports why a step can't be reversed, if it can't
+func check(step Step) error {
+	switch step.Kind {
+	case StepMove:
+		if _, err := os.Stat(step.To); err != nil {
+			return fmt.Errorf("%s no longer exists", step.To)
+		}
+		if _, err := os.Stat(step.From); !os.IsNotExist(err) {
+			return fmt.Errorf("%s already exists", step.From)
+		}
+	case StepWrite:
+		data, err := os.ReadFile(step.Path)
+		if err != nil {
+			// The file may sit in a directory a later move step puts back
+			return nil
+		}
+		if hash(data) != step.After {
+			return fmt.Errorf("%s was changed since, not undoing", step.Path)
+		}
+	case StepTrash:
+		if _, err := trash.Find(step.TrashID); err != nil {
+			return err
+		}
+		if _, err := os.Stat(step.From); !os.IsNotExist(err) {
+			return fmt.Errorf("%s already exists", step.From)
+		}
+	case StepCompact:
+		if !backup.IsCompacted(step.Path) {
+			return fmt.Errorf("%s is no longer compacted", step.Path)
+		}
+	case StepExpand:
+		if _, err := os.Stat(filepath.Join(step.Path, ".project.toml")); err != nil {
+			// The project may sit in a directory a later move step puts back
+			return nil
+		}
+		if backup.IsCompacted(step.Path) {
+			return fmt.Errorf("%s is already compacted", step.Path)
+		}
+	}
+	return nil
+}
+
+// reverse applies the inverse of a step
+func reverse(step Step) error {
+	switch step.Kind {
+	case StepMove:
+		if err := os.MkdirAll(filepath.Dir(step.From), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(step.To, step.From); err != nil {
+			return err
+		}
+		cache.InvalidateCache()
+	case StepWrite:
+		if !step.Existed {
+			if err := os.Remove(step.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		return os.WriteFile(step.Path, step.Before, 0644)
+	case StepRelocate:
+		return cache.RelocateProject(step.ProjectID, step.From)
+	case StepRekey:
+		return cache.RenameProject(step.NewID, step.ProjectID, step.To, step.From)
+	case StepSession:
+		// Sessions come and go; only rename one that is still running
+		if session.SessionExists(step.To) && !session.SessionExists(step.From) {
+			return session.RenameProjectSession(step.To, step.From, step.ProjectID)
+		}
+	case StepTrash:
+		item, err := trash.Find(step.TrashID)
+		if err != nil {
+			return err
+		}
+		_, err = trash.Restore(item, step.From)
+		return err
+	case StepCompact:
+		return backup.Expand(step.Path)
+	case StepActivity:
+		_, err := activity.Rename(step.NewID, step.ProjectID)
+		return err
+	case StepExpand:
+		p, err := config.LoadProject(filepath.Join(step.Path, ".project.toml"))
+		if err != nil {
+			return err
+		}
+		_, _, err = backup.Compact(p, workspace.Roots{workspace.RootArchive: step.From})
+		return err
+	}
+	// StepSync has no inverse of its own; callers sync again after undoing
+	return nil
+}
+
+// Tx records the steps of one command so they can be rolled back on
+// failure or undone later
+type Tx struct {
+	op Operation
+}
+
+// Begin starts recording an operation, e.g. Begin("rename", "app", "api")
+func Begin(command string, args ...string) *Tx {
+	return &Tx{op: Operation{
+		Command: strings.Join(append([]string{command}, args...), " "),
+		Time:    time.Now(),
+	}}
+}
+
+// Record adds a step that already happened
+func (tx *Tx) Record(step Step) {
+	tx.op.Steps = append(tx.op.Steps, step)
+}
+
+// Move renames a directory and records the move
+func (tx *Tx) Move(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+
+	cache.InvalidateCache()
+	tx.Record(Step{Kind: StepMove, From: from, To: to})
+	return nil
+}
+
+// Rekey moves a project's pins and access record to a new ID and path
+func (tx *Tx) Rekey(oldID, newID, from, to string) error {
+	if err := cache.RenameProject(oldID, newID, from, to); err != nil {
+		return err
+	}
+
+	tx.Record(Step{Kind: StepRekey, ProjectID: oldID, NewID: newID, From: from, To: to})
+	return nil
+}
+
+// RenameActivity points the activity log events of a project and its
+// subprojects at a new ID. Returns how many events changed; nothing is
+// recorded when none did.
+func (tx *Tx) RenameActivity(oldID, newID string) (int, error) {
+	changed, err := activity.Rename(oldID, newID)
+	if err != nil || changed == 0 {
+		return changed, err
+	}
+
+	tx.Record(Step{Kind: StepActivity, ProjectID: oldID, NewID: newID})
+	return changed, nil
+}
+
+// RenameSession renames a project's running tmux session
+func (tx *Tx) RenameSession(from, to, oldID, newID string) error {
+	if err := session.RenameProjectSession(from, to, newID); err != nil {
+		return err
+	}
+
+	tx.Record(Step{Kind: StepSession, From: from, To: to, ProjectID: oldID, NewID: newID})
+	return nil
+}
+
+// Relocate points a project's pins and access record at a new path
+func (tx *Tx) Relocate(projectID, from, to string) error {
+	if err := cache.RelocateProject(projectID, to); err != nil {
+		return err
+	}
+
+	tx.Record(Step{Kind: StepRelocate, ProjectID: projectID, From: from, To: to})
+	return nil
+}
+
+// Write snapshots path, runs write and records the change
+// If write fails the previous content is put back.
+func (tx *Tx) Write(path string, write func() error) error {
+	before, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := write(); err != nil {
+		if existed {
+			os.WriteFile(path, before, 0644)
+		} else {
+			os.Remove(path)
+		}
+		return err
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tx.Record(Step{Kind: StepWrite, Path: path, Existed: existed, Before: before, After: hash(after)})
+	return nil
+}
+
+// Savepoint marks the current position so a later part of the operation
+// can be rolled back on its own (e.g. one project of a bulk archive)
+func (tx *Tx) Savepoint() int {
+	return len(tx.op.Steps)
+}
+
+// RollbackTo reverses the steps recorded after savepoint, newest first
+// It keeps going past failures and returns them joined.
+func (tx *Tx) RollbackTo(savepoint int) error {
+	var errs []error
+	for i := len(tx.op.Steps) - 1; i >= savepoint; i-- {
+		if err := reverse(tx.op.Steps[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.op.Steps = tx.op.Steps[:savepoint]
+	return errors.Join(errs...)
+}
+
+// Rollback reverses all recorded steps, newest first
+func (tx *Tx) Rollback() error {
+	return tx.RollbackTo(0)
+}
+
+// Commit appends the operation to the journal
+func (tx *Tx) Commit() error {
+	if len(tx.op.Steps) == 0 {
+		return nil
+	}
+
+	ops, err := Load()
+	if err != nil {
+		return err
+	}
+
+	tx.op.ID = 1
+	if len(ops) > 0 {
+		tx.op.ID = ops[len(ops)-1].ID + 1
+	}
+
+	return save(append(ops, tx.op))
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
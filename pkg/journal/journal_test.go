@@ -0,0 +1,297 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/backup"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/trash"
+	"github.com/datakaicr/pk/pkg/workspace"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	projectPath := filepath.Join(tmpDir, "projects", "app")
+	if err := os.MkdirAll(projectPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectPath, ".project.toml"), []byte("id = \"app\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
+func TestRollback(t *testing.T) {
+	tmpDir := setup(t)
+	from := filepath.Join(tmpDir, "projects", "app")
+	to := filepath.Join(tmpDir, "projects", "api")
+
+	tx := Begin("rename", "app", "api")
+	if err := tx.Move(from, to); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	// A failed write puts the old content back before the rollback
+	tomlPath := filepath.Join(to, ".project.toml")
+	err := tx.Write(tomlPath, func() error {
+		os.WriteFile(tomlPath, []byte("half"), 0644)
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatal("Write should return the write error")
+	}
+	if data, _ := os.ReadFile(tomlPath); string(data) != "id = \"app\"\n" {
+		t.Errorf("content after failed write = %q", data)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(from, ".project.toml")); err != nil {
+		t.Errorf("rollback should move the project back: %v", err)
+	}
+
+	// Rolled back operations are not journaled
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if ops, _ := Load(); len(ops) != 0 {
+		t.Errorf("journal has %d operations, want 0", len(ops))
+	}
+}
+
+func TestUndo(t *testing.T) {
+	tmpDir := setup(t)
+	from := filepath.Join(tmpDir, "projects", "app")
+	to := filepath.Join(tmpDir, "archive", "app")
+	tomlPath := filepath.Join(to, ".project.toml")
+
+	if err := cache.AddPin(1, "app", from); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin("archive", "app")
+	if err := tx.Move(from, to); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Relocate("app", from, to); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Write(tomlPath, func() error {
+		return os.WriteFile(tomlPath, []byte("id = \"app\"\nstatus = \"archived\"\n"), 0644)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tx.Record(Step{Kind: StepSync})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, _ := Load()
+	undoable := Undoable(ops, 5)
+	if len(undoable) != 1 || undoable[0].ID != 1 || undoable[0].Command != "archive app" || !undoable[0].Has(StepSync) {
+		t.Fatalf("Undoable = %+v", undoable)
+	}
+
+	// Edited after the operation: refuse without touching anything
+	os.WriteFile(tomlPath, []byte("edited"), 0644)
+	if err := Undo(undoable[0]); err == nil {
+		t.Fatal("Undo should refuse when a written file changed")
+	}
+	if _, err := os.Stat(to); err != nil {
+		t.Error("refused undo must not move anything")
+	}
+	os.WriteFile(tomlPath, []byte("id = \"app\"\nstatus = \"archived\"\n"), 0644)
+
+	if err := Undo(undoable[0]); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(from, ".project.toml")); string(data) != "id = \"app\"\n" {
+		t.Errorf("undo should restore the original file, got %q", data)
+	}
+	if pins, _ := cache.LoadPins(); pins[1].ProjectPath != from {
+		t.Errorf("pin path = %s, want %s", pins[1].ProjectPath, from)
+	}
+
+	ops, _ = Load()
+	if len(ops) != 1 || !ops[0].Undone || len(Undoable(ops, 5)) != 0 {
+		t.Errorf("operation should be marked undone: %+v", ops)
+	}
+}
+
+func TestUndoTrash(t *testing.T) {
+	tmpDir := setup(t)
+	projectPath := filepath.Join(tmpDir, "projects", "app")
+
+	item, err := trash.Move(trash.Item{Kind: trash.KindProject, ProjectID: "app", OriginalPath: projectPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin("delete", "app")
+	tx.Record(Step{Kind: StepTrash, TrashID: item.ID, From: projectPath})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, _ := Load()
+	if err := Undo(ops[0]); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectPath, ".project.toml")); err != nil {
+		t.Errorf("undo should bring the project back from the trash: %v", err)
+	}
+}
+
+func TestUndoCompact(t *testing.T) {
+	tmpDir := setup(t)
+	projectPath := filepath.Join(tmpDir, "projects", "app")
+	os.WriteFile(filepath.Join(projectPath, ".project.toml"), []byte("[project]\nid = \"app\"\n"), 0644)
+	os.WriteFile(filepath.Join(projectPath, "notes.md"), []byte("notes\n"), 0644)
+
+	p, err := config.LoadProject(filepath.Join(projectPath, ".project.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := workspace.Roots{workspace.RootProjects: filepath.Join(tmpDir, "projects")}
+	if _, _, err := backup.Compact(p, roots); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin("archive compact", "app")
+	tx.Record(Step{Kind: StepCompact, ProjectID: "app", Path: projectPath})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, _ := Load()
+	if err := Undo(ops[0]); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(projectPath, "notes.md")); err != nil || string(data) != "notes\n" {
+		t.Errorf("notes.md = %q, %v; undo should unpack the project", data, err)
+	}
+	if backup.IsCompacted(projectPath) {
+		t.Error("the cold storage bundle should be gone")
+	}
+}
+
+func TestUndoExpand(t *testing.T) {
+	tmpDir := setup(t)
+	archiveDir := filepath.Join(tmpDir, "archive")
+	projectPath := filepath.Join(archiveDir, "app")
+	os.MkdirAll(projectPath, 0755)
+	os.WriteFile(filepath.Join(projectPath, ".project.toml"), []byte("[project]\nid = \"app\"\n"), 0644)
+	os.WriteFile(filepath.Join(projectPath, "notes.md"), []byte("notes\n"), 0644)
+
+	p, err := config.LoadProject(filepath.Join(projectPath, ".project.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := backup.Compact(p, workspace.Roots{workspace.RootArchive: archiveDir}); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Expand(projectPath); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin("status", "app", "active")
+	tx.Record(Step{Kind: StepExpand, ProjectID: "app", Path: projectPath, From: archiveDir})
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, _ := Load()
+	if err := Undo(ops[0]); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if !backup.IsCompacted(projectPath) {
+		t.Error("undo should pack the project again")
+	}
+	if _, err := os.Stat(filepath.Join(projectPath, "notes.md")); !os.IsNotExist(err) {
+		t.Error("notes.md should be back in the bundle")
+	}
+}
+
+func TestUndoRenameActivity(t *testing.T) {
+	setup(t)
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for _, id := range []string{"app", "app/web", "other"} {
+		activity.Append(activity.Event{Time: start, Type: activity.SwitchIn, ProjectID: id})
+	}
+
+	tx := Begin("rename", "app", "api")
+	if changed, err := tx.RenameActivity("app", "api"); err != nil || changed != 2 {
+		t.Fatalf("RenameActivity = %d, %v; want 2 events changed", changed, err)
+	}
+	if changed, err := tx.RenameActivity("missing", "gone"); err != nil || changed != 0 {
+		t.Fatalf("RenameActivity of an unknown project = %d, %v", changed, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The log keeps growing after the rename; undo must not mind
+	activity.Append(activity.Event{Time: start.Add(time.Hour), Type: activity.SwitchIn, ProjectID: "api"})
+
+	ops, _ := Load()
+	if len(ops[0].Steps) != 1 {
+		t.Errorf("steps = %+v, want only the renamed activity", ops[0].Steps)
+	}
+	if err := Undo(ops[0]); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	events, _ := activity.Load()
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ProjectID)
+	}
+	if strings.Join(ids, ",") != "app,app/web,other,app" {
+		t.Errorf("project IDs after undo = %v", ids)
+	}
+}
+
+func TestRollbackTo(t *testing.T) {
+	tmpDir := setup(t)
+	for _, name := range []string{"one", "two"} {
+		os.MkdirAll(filepath.Join(tmpDir, "projects", name), 0755)
+	}
+
+	tx := Begin("archive", "one", "two")
+	if err := tx.Move(filepath.Join(tmpDir, "projects", "one"), filepath.Join(tmpDir, "archive", "one")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second project fails halfway; only its own steps are reversed
+	savepoint := tx.Savepoint()
+	if err := tx.Move(filepath.Join(tmpDir, "projects", "two"), filepath.Join(tmpDir, "archive", "two")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.RollbackTo(savepoint); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "projects", "two")); err != nil {
+		t.Error("second project should be back in projects")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "archive", "one")); err != nil {
+		t.Error("first project should stay archived")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if ops, _ := Load(); len(ops) != 1 || len(ops[0].Steps) != 1 {
+		t.Errorf("journal = %+v, want one operation with the first move", ops)
+	}
+}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// Archive moves a project into archiveDir and marks it archived
+// Returns the new project path
+func Archive(project *config.Project, archiveDir string) (string, error) {
+	destPath, err := MoveToArchive(project, archiveDir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := MarkArchived(filepath.Join(destPath, ".project.toml")); err != nil {
+		return destPath, fmt.Errorf("archived, but failed to update .project.toml: %w", err)
+	}
+
+	return destPath, nil
+}
+
+// MoveToArchive moves the project directory into archiveDir
+func MoveToArchive(project *config.Project, archiveDir string) (string, error) {
+	destPath := filepath.Join(archiveDir, filepath.Base(project.Path))
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		return "", fmt.Errorf("project already exists in archive: %s", destPath)
+	}
+
+	return move(project, destPath)
+}
+
+// MarkArchived sets status "archived" and the completion date in a .project.toml
+// The transition is recorded in [[status_history]].
+func MarkArchived(path string) error {
+	today := time.Now().Format("2006-01-02")
+	return updateProjectFile(path, func(project *config.Project) {
+		project.SetStatus("archived", today)
+		project.Dates.Completed = today
+	})
+}
+
+// move renames a project directory to destPath and points caches at it
+func move(project *config.Project, destPath string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(destPath), err)
+	}
+
+	if err := os.Rename(project.Path, destPath); err != nil {
+		return "", fmt.Errorf("failed to move project: %w", err)
+	}
+
+	// Project moved, cached paths are stale
+	cache.InvalidateCache()
+	cache.RelocateProject(project.ProjectInfo.ID, destPath)
+
+	return destPath, nil
+}
+
+// updateProjectFile decodes a .project.toml, applies change and writes it back
+func updateProjectFile(path string, change func(*config.Project)) error {
+	// Read current TOML
+	var project config.Project
+	if _, err := toml.DecodeFile(path, &project); err != nil {
+		return err
+	}
+
+	change(&project)
+
+	// Write back to file
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := toml.NewEncoder(f)
+	return encoder.Encode(&project)
+}
@@ -0,0 +1,66 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	projectPath := filepath.Join(tmpDir, "projects", "app")
+	if err := os.MkdirAll(projectPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[project]\nname = \"App\"\nid = \"app\"\nstatus = \"active\"\n"
+	if err := os.WriteFile(filepath.Join(projectPath, ".project.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProject(filepath.Join(projectPath, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+
+	archiveDir := filepath.Join(tmpDir, "archive")
+	destPath, err := Archive(project, archiveDir)
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	if destPath != filepath.Join(archiveDir, "app") {
+		t.Errorf("destPath = %s, want %s", destPath, filepath.Join(archiveDir, "app"))
+	}
+	if _, err := os.Stat(projectPath); !os.IsNotExist(err) {
+		t.Error("project should no longer exist in ~/projects")
+	}
+
+	archived, err := config.LoadProject(filepath.Join(destPath, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject after archive failed: %v", err)
+	}
+	if archived.ProjectInfo.Status != "archived" {
+		t.Errorf("status = %q, want archived", archived.ProjectInfo.Status)
+	}
+	if archived.Dates.Completed == "" {
+		t.Error("completion date should be set")
+	}
+	if h := archived.StatusHistory; len(h) != 1 || h[0].From != "active" || h[0].To != "archived" || h[0].Date != archived.Dates.Completed {
+		t.Errorf("status history = %+v, want one active → archived entry", h)
+	}
+
+	// Archiving onto an existing directory must fail without moving anything
+	if err := os.MkdirAll(projectPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Archive(&config.Project{Path: projectPath}, archiveDir); err == nil {
+		t.Error("Archive should fail when the destination exists")
+	}
+	if _, err := os.Stat(projectPath); err != nil {
+		t.Error("project should stay in place when archiving fails")
+	}
+}
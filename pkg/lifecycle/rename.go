@@ -0,0 +1,71 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// MarkRenamed updates a .project.toml after a rename
+// Empty newID or newName keep the current value. When the directory moved
+// from oldDir to newDir, [tmux] window and pane paths inside it follow.
+func MarkRenamed(path, newID, newName, oldDir, newDir string) error {
+	homeDir, _ := os.UserHomeDir()
+	return updateProjectFile(path, func(project *config.Project) {
+		applyRename(project, newID, newName, oldDir, newDir, homeDir)
+	})
+}
+
+func applyRename(project *config.Project, newID, newName, oldDir, newDir, homeDir string) {
+	if newID != "" {
+		project.ProjectInfo.ID = newID
+	}
+	if newName != "" {
+		project.ProjectInfo.Name = newName
+	}
+	if oldDir == newDir {
+		return
+	}
+
+	for i := range project.Tmux.Windows {
+		window := &project.Tmux.Windows[i]
+		window.Path = RebasePath(window.Path, oldDir, newDir, homeDir)
+		for j := range window.Panes {
+			window.Panes[j].Path = RebasePath(window.Panes[j].Path, oldDir, newDir, homeDir)
+		}
+	}
+}
+
+// RebasePath points a path inside oldDir at the same place inside newDir
+// Absolute and ~/ paths are rewritten (keeping the ~/ form); relative paths
+// and paths outside oldDir are returned unchanged.
+func RebasePath(path, oldDir, newDir, homeDir string) string {
+	tilde := false
+	expanded := path
+	if homeDir != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+		tilde = true
+		expanded = filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+	}
+
+	if !filepath.IsAbs(expanded) {
+		return path
+	}
+
+	rel, err := filepath.Rel(oldDir, filepath.Clean(expanded))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	rebased := filepath.Join(newDir, rel)
+	if tilde {
+		if homeRel, err := filepath.Rel(homeDir, rebased); err == nil && !strings.HasPrefix(homeRel, "..") {
+			if homeRel == "." {
+				return "~"
+			}
+			return "~/" + homeRel
+		}
+	}
+	return rebased
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestRebasePath(t *testing.T) {
+	home := "/home/dev"
+	oldDir := "/home/dev/projects/app"
+	newDir := "/home/dev/projects/api"
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/home/dev/projects/app", "/home/dev/projects/api"},
+		{"/home/dev/projects/app/src", "/home/dev/projects/api/src"},
+		{"~/projects/app/web", "~/projects/api/web"},
+		{"~/projects/app", "~/projects/api"},
+		{"/home/dev/projects/apple", "/home/dev/projects/apple"},
+		{"/home/dev/projects/other", "/home/dev/projects/other"},
+		{"~/notes", "~/notes"},
+		{"src", "src"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := RebasePath(tt.path, oldDir, newDir, home); got != tt.expected {
+			t.Errorf("RebasePath(%q) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestMarkRenamed(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	oldDir := filepath.Join(tmpDir, "projects", "app")
+	newDir := filepath.Join(tmpDir, "projects", "api")
+	if err := os.MkdirAll(newDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `[project]
+name = "My App"
+id = "app"
+status = "active"
+
+[tmux]
+[[tmux.windows]]
+name = "code"
+path = "` + oldDir + `/src"
+
+[[tmux.windows.panes]]
+command = "make watch"
+path = "~/projects/app/web"
+
+[[tmux.windows]]
+name = "notes"
+path = "docs"
+`
+	tomlPath := filepath.Join(newDir, ".project.toml")
+	if err := os.WriteFile(tomlPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Display name is kept unless a new one is given
+	if err := MarkRenamed(tomlPath, "api", "", oldDir, newDir); err != nil {
+		t.Fatalf("MarkRenamed: %v", err)
+	}
+
+	project, err := config.LoadProject(tomlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.ProjectInfo.ID != "api" || project.ProjectInfo.Name != "My App" {
+		t.Errorf("id/name = %q/%q, want api/My App", project.ProjectInfo.ID, project.ProjectInfo.Name)
+	}
+	if got := project.Tmux.Windows[0].Path; got != filepath.Join(newDir, "src") {
+		t.Errorf("window path = %s", got)
+	}
+	if got := project.Tmux.Windows[0].Panes[0].Path; got != "~/projects/api/web" {
+		t.Errorf("pane path = %s", got)
+	}
+	if got := project.Tmux.Windows[1].Path; got != "docs" {
+		t.Errorf("relative window path = %s, want docs", got)
+	}
+
+	if err := MarkRenamed(tomlPath, "", "API", newDir, newDir); err != nil {
+		t.Fatalf("MarkRenamed: %v", err)
+	}
+	project, _ = config.LoadProject(tomlPath)
+	if project.ProjectInfo.ID != "api" || project.ProjectInfo.Name != "API" {
+		t.Errorf("id/name = %q/%q, want api/API", project.ProjectInfo.ID, project.ProjectInfo.Name)
+	}
+}
@@ -0,0 +1,56 @@
+package lifecycle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// DefaultRestoreStatus is the status a restored project gets unless chosen
+const DefaultRestoreStatus = "active"
+
+// Restore moves an archived project back into projectsDir with a new status
+// Refuses when projectsDir already has a directory of the same name.
+// Returns the new project path.
+func Restore(project *config.Project, projectsDir, status string) (string, error) {
+	if status == "" {
+		status = DefaultRestoreStatus
+	}
+	if status == "archived" {
+		return "", fmt.Errorf("cannot restore a project to status 'archived'")
+	}
+
+	destPath, err := MoveToProjects(project, projectsDir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := MarkRestored(filepath.Join(destPath, ".project.toml"), status); err != nil {
+		return destPath, fmt.Errorf("restored, but failed to update .project.toml: %w", err)
+	}
+
+	return destPath, nil
+}
+
+// MoveToProjects moves an archived project directory into projectsDir
+func MoveToProjects(project *config.Project, projectsDir string) (string, error) {
+	destPath := filepath.Join(projectsDir, filepath.Base(project.Path))
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		return "", fmt.Errorf("a project already exists at %s", destPath)
+	}
+
+	return move(project, destPath)
+}
+
+// MarkRestored sets the status and clears the completion date in a .project.toml
+// The transition is recorded in [[status_history]].
+func MarkRestored(path, status string) error {
+	today := time.Now().Format("2006-01-02")
+	return updateProjectFile(path, func(project *config.Project) {
+		project.SetStatus(status, today)
+		project.Dates.Completed = ""
+	})
+}
@@ -0,0 +1,77 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	archivedPath := filepath.Join(tmpDir, "archive", "app")
+	if err := os.MkdirAll(archivedPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[project]\nname = \"App\"\nid = \"app\"\nstatus = \"archived\"\n\n[dates]\nstarted = \"2024-01-01\"\ncompleted = \"2025-06-30\"\n"
+	if err := os.WriteFile(filepath.Join(archivedPath, ".project.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.AddPin(1, "app", archivedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := config.LoadProject(filepath.Join(archivedPath, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+
+	projectsDir := filepath.Join(tmpDir, "projects")
+
+	if _, err := Restore(project, projectsDir, "archived"); err == nil {
+		t.Error("Restore should refuse status 'archived'")
+	}
+
+	// A directory of the same name blocks the restore
+	if err := os.MkdirAll(filepath.Join(projectsDir, "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(project, projectsDir, ""); err == nil {
+		t.Error("Restore should fail when the destination exists")
+	}
+	if _, err := os.Stat(archivedPath); err != nil {
+		t.Error("project should stay archived when restoring fails")
+	}
+	os.Remove(filepath.Join(projectsDir, "app"))
+
+	destPath, err := Restore(project, projectsDir, "paused")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if destPath != filepath.Join(projectsDir, "app") {
+		t.Errorf("destPath = %s, want %s", destPath, filepath.Join(projectsDir, "app"))
+	}
+
+	restored, err := config.LoadProject(filepath.Join(destPath, ".project.toml"))
+	if err != nil {
+		t.Fatalf("LoadProject after restore failed: %v", err)
+	}
+	if restored.ProjectInfo.Status != "paused" {
+		t.Errorf("status = %q, want paused", restored.ProjectInfo.Status)
+	}
+	if restored.Dates.Completed != "" {
+		t.Errorf("completed = %q, want it cleared", restored.Dates.Completed)
+	}
+	if restored.Dates.Started != "2024-01-01" {
+		t.Errorf("started = %q, want it kept", restored.Dates.Started)
+	}
+
+	pins, _ := cache.LoadPins()
+	if pins[1].ProjectPath != destPath {
+		t.Errorf("pin path = %s, want %s", pins[1].ProjectPath, destPath)
+	}
+}
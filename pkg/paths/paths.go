@@ -6,9 +6,11 @@ import (
 	"path/filepath"
 
 	"github.com/BurntSushi/toml"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/status"
 )
 
-// Config holds user-configurable paths
+// Config holds user-configurable paths and global settings
 type Config struct {
 	Paths struct {
 		Projects    string `toml:"projects"`
@@ -16,6 +18,17 @@ type Config struct {
 		Scratch     string `toml:"scratch"`
 		Scriptorium string `toml:"scriptorium"`
 	} `toml:"paths"`
+
+	// Global session hooks (run before project hooks)
+	Hooks config.Hooks `toml:"hooks"`
+
+	// Project status state machine ([[status]]), defaults to status.Default
+	Statuses []status.State `toml:"status"`
+
+	// Time tracking settings
+	Time struct {
+		TmuxHooks bool `toml:"tmux_hooks"` // Install global tmux hooks reporting to 'pk time'
+	} `toml:"time"`
 }
 
 // Resolver handles path resolution with config and defaults
@@ -41,7 +54,7 @@ func NewResolver() (*Resolver, error) {
 	}
 
 	// Try to load config
-	configPath := filepath.Join(homeDir, ".config", "pk", "config.toml")
+	configPath := configFile(homeDir)
 	if _, err := os.Stat(configPath); err == nil {
 		// Config exists, load it
 		var cfg Config
@@ -93,6 +106,19 @@ func (r *Resolver) resolvePath(name, defaultPath string) string {
 	return configured
 }
 
+// GetConfigFile returns the path to config.toml, which may not exist
+func GetConfigFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return configFile(homeDir), nil
+}
+
+func configFile(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "pk", "config.toml")
+}
+
 // Projects returns the projects directory path
 func (r *Resolver) Projects() string {
 	return r.projects
@@ -113,6 +139,28 @@ func (r *Resolver) Scriptorium() string {
 	return r.scriptorium
 }
 
+// Hooks returns the global session hooks from config.toml
+func (r *Resolver) Hooks() config.Hooks {
+	if r.config == nil {
+		return config.Hooks{}
+	}
+	return r.config.Hooks
+}
+
+// TmuxActivityHooks reports whether [time] tmux_hooks is enabled
+func (r *Resolver) TmuxActivityHooks() bool {
+	return r.config != nil && r.config.Time.TmuxHooks
+}
+
+// StatusMachine returns the configured status state machine
+// Without [[status]] entries in config.toml the default machine is used.
+func (r *Resolver) StatusMachine() (*status.Machine, error) {
+	if r.config == nil || len(r.config.Statuses) == 0 {
+		return status.Default(), nil
+	}
+	return status.New(r.config.Statuses)
+}
+
 // AllRoots returns all root directories
 func (r *Resolver) AllRoots() []string {
 	return []string{
@@ -0,0 +1,172 @@
+package picker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scoring constants modelled on fzf's v1 algorithm
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = 8 // Match right after a separator or at the start
+	bonusCamel        = 7 // Uppercase after lowercase (camelCase)
+	bonusConsecutive  = 4 // Match directly after the previous match
+	bonusFirstFactor  = 2 // Bonus multiplier for the first pattern character
+)
+
+// Result is an item that matched a query
+type Result struct {
+	Index     int   // Position of the item in the input
+	Score     int   // Higher is better
+	Positions []int // Rune offsets of matched characters in the item text
+}
+
+// Match scores text against a single pattern term
+// Matching is case-insensitive unless the pattern contains uppercase (smart case)
+func Match(pattern, text string) (int, []int, bool) {
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	t := []rune(text)
+	caseSensitive := strings.IndexFunc(pattern, unicode.IsUpper) >= 0
+	eq := func(a, b rune) bool {
+		if caseSensitive {
+			return a == b
+		}
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+
+	// Forward pass: find the end of the first full subsequence match
+	pi, end := 0, -1
+	for i := 0; i < len(t) && pi < len(p); i++ {
+		if eq(t[i], p[pi]) {
+			pi++
+			if pi == len(p) {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// Backward pass: shrink to the shortest window ending at end
+	pi, start := len(p)-1, end
+	for i := end; i >= 0; i-- {
+		if eq(t[i], p[pi]) {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	// Score the window
+	score := 0
+	positions := make([]int, 0, len(p))
+	pi = 0
+	inGap, prevMatched := false, false
+	for i := start; i <= end; i++ {
+		if pi < len(p) && eq(t[i], p[pi]) {
+			bonus := bonusAt(t, i)
+			if pi == 0 {
+				bonus *= bonusFirstFactor
+			}
+			if prevMatched && bonus < bonusConsecutive {
+				bonus = bonusConsecutive
+			}
+			score += scoreMatch + bonus
+			positions = append(positions, i)
+			pi++
+			inGap, prevMatched = false, true
+			continue
+		}
+
+		if inGap {
+			score += scoreGapExtension
+		} else {
+			score += scoreGapStart
+		}
+		inGap, prevMatched = true, false
+	}
+
+	return score, positions, true
+}
+
+// bonusAt rewards matches at word boundaries
+func bonusAt(t []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+
+	prev, cur := t[i-1], t[i]
+	switch {
+	case isSeparator(prev) && !isSeparator(cur):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+// isSeparator reports whether r delimits words in project IDs and paths
+func isSeparator(r rune) bool {
+	switch r {
+	case ' ', '\t', '-', '_', '.', '/', '[', ']', '(', ')', ':':
+		return true
+	}
+	return false
+}
+
+// Filter matches every text against query and returns results best first
+// Space-separated terms must all match; their scores are summed
+// Ties go to the shorter text, then to the earlier item. An empty query
+// keeps the input order.
+func Filter(texts []string, query string) []Result {
+	terms := strings.Fields(query)
+
+	var results []Result
+	if len(terms) == 0 {
+		for i := range texts {
+			results = append(results, Result{Index: i})
+		}
+		return results
+	}
+
+	for i, text := range texts {
+		result := Result{Index: i}
+		matched := true
+		for _, term := range terms {
+			score, positions, ok := Match(term, text)
+			if !ok {
+				matched = false
+				break
+			}
+			result.Score += score
+			result.Positions = append(result.Positions, positions...)
+		}
+		if matched {
+			sort.Ints(result.Positions)
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if la, lb := len(texts[a.Index]), len(texts[b.Index]); la != lb {
+			return la < lb
+		}
+		return a.Index < b.Index
+	})
+
+	return results
+}
@@ -0,0 +1,84 @@
+package picker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		text      string
+		matched   bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"pk", "pk", true, []int{0, 1}},
+		{"dkos", "datakai-os", true, []int{0, 4, 8, 9}},
+		{"DK", "datakai", false, nil}, // Smart case: uppercase pattern is case-sensitive
+		{"dk", "DataKai", true, []int{0, 4}},
+		{"xyz", "pk", false, nil},
+		{"ab", "a-xab", true, []int{3, 4}}, // Shortest window wins
+	}
+
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.text)
+		if ok != tt.matched {
+			t.Errorf("Match(%q, %q) matched = %v, want %v", tt.pattern, tt.text, ok, tt.matched)
+			continue
+		}
+		if ok && !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("Match(%q, %q) positions = %v, want %v", tt.pattern, tt.text, positions, tt.positions)
+		}
+	}
+}
+
+func TestMatchScoring(t *testing.T) {
+	tests := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"api", "api-gateway", "rapid"},     // Word start beats mid-word
+		{"dm", "data-model", "admin"},       // Boundaries beat gaps
+		{"ses", "sessions", "sales-export"}, // Consecutive beats scattered
+		{"gw", "apiGateway", "aglow"},       // camelCase boundary
+	}
+
+	for _, tt := range tests {
+		better, _, ok1 := Match(tt.pattern, tt.better)
+		worse, _, ok2 := Match(tt.pattern, tt.worse)
+		if !ok1 || !ok2 {
+			t.Errorf("%q should match both %q and %q", tt.pattern, tt.better, tt.worse)
+			continue
+		}
+		if better <= worse {
+			t.Errorf("%q: score(%q)=%d should beat score(%q)=%d",
+				tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	texts := []string{"spark-jobs", "pkg-tools", "api-kit", "pk", "conduit"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"spark-jobs", "pkg-tools", "api-kit", "pk", "conduit"}},
+		{"pk", []string{"pk", "pkg-tools", "api-kit", "spark-jobs"}},
+		{"pk tools", []string{"pkg-tools"}},
+		{"zzz", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, r := range Filter(texts, tt.query) {
+			got = append(got, texts[r.Index])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package picker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/tui"
+)
+
+// Item is a selectable picker line
+type Item struct {
+	Key  string // Returned when the item is chosen
+	Text string // Displayed and matched (tabs are expanded)
+}
+
+// Options configure the picker
+type Options struct {
+	Prompt  string
+	Header  string
+	Tabstop int                     // Column width for tab-separated text (default 8)
+	Preview func(key string) string // Optional; rendered beside the list
+}
+
+// minPreviewWidth is the terminal width below which the preview is hidden
+const minPreviewWidth = 80
+
+// state is the picker model between renders
+type state struct {
+	items   []Item
+	texts   []string
+	opts    Options
+	query   []rune
+	results []Result
+	cursor  int
+	offset  int
+	preview map[string][]string
+}
+
+// Run shows the picker on the controlling terminal and returns the chosen key
+// An empty key means the user cancelled
+func Run(items []Item, opts Options) (string, error) {
+	t, err := tui.Open()
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+
+	if opts.Tabstop <= 0 {
+		opts.Tabstop = 8
+	}
+
+	s := &state{
+		items:   items,
+		opts:    opts,
+		preview: make(map[string][]string),
+	}
+	for _, item := range items {
+		s.texts = append(s.texts, tui.ExpandTabs(item.Text, opts.Tabstop))
+	}
+	s.filter()
+
+	for {
+		width, height := t.Size()
+		t.Draw(s.render(width, height))
+
+		key, err := t.ReadKey()
+		if err != nil {
+			return "", err
+		}
+
+		listHeight := height - 2
+		switch key.Code {
+		case tui.KeyEscape, tui.KeyInterrupt:
+			return "", nil
+		case tui.KeyEnter:
+			if len(s.results) == 0 {
+				continue
+			}
+			return s.items[s.results[s.cursor].Index].Key, nil
+		case tui.KeyUp:
+			s.move(-1)
+		case tui.KeyDown:
+			s.move(1)
+		case tui.KeyPageUp:
+			s.move(-listHeight)
+		case tui.KeyPageDown:
+			s.move(listHeight)
+		case tui.KeyBackspace:
+			if len(s.query) > 0 {
+				s.query = s.query[:len(s.query)-1]
+				s.filter()
+			}
+		case tui.KeyClearLine:
+			s.query = nil
+			s.filter()
+		case tui.KeyDeleteWord:
+			s.query = []rune(deleteWord(string(s.query)))
+			s.filter()
+		default:
+			if len(key.Runes) > 0 {
+				s.query = append(s.query, key.Runes...)
+				s.filter()
+			}
+		}
+	}
+}
+
+// filter re-runs the query and resets the selection
+func (s *state) filter() {
+	s.results = Filter(s.texts, string(s.query))
+	s.cursor, s.offset = 0, 0
+}
+
+// move shifts the selection, clamped to the result list
+func (s *state) move(delta int) {
+	s.cursor += delta
+	if s.cursor >= len(s.results) {
+		s.cursor = len(s.results) - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+}
+
+// render draws a full frame: prompt, counter/header, list and preview
+func (s *state) render(width, height int) string {
+	listWidth := width
+	showPreview := s.opts.Preview != nil && width >= minPreviewWidth
+	if showPreview {
+		listWidth = width / 2
+	}
+
+	// Keep the selection visible
+	listHeight := height - 2
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+listHeight {
+		s.offset = s.cursor - listHeight + 1
+	}
+
+	rows := make([]string, 0, height)
+	rows = append(rows, fmt.Sprintf("\033[1m%s\033[0m%s\033[7m \033[0m", s.opts.Prompt, string(s.query)))
+
+	counter := fmt.Sprintf("  \033[33m%d/%d\033[0m", len(s.results), len(s.items))
+	if s.opts.Header != "" {
+		counter += "  \033[90m" + s.opts.Header + "\033[0m"
+	}
+	rows = append(rows, counter)
+
+	for i := s.offset; i < len(s.results) && len(rows) < height; i++ {
+		rows = append(rows, s.renderItem(s.results[i], i == s.cursor, listWidth))
+	}
+
+	split := 0
+	var previewLines []string
+	if showPreview {
+		split = listWidth
+		if len(s.results) > 0 {
+			previewLines = s.previewFor(s.items[s.results[s.cursor].Index].Key)
+		}
+	}
+
+	return tui.Compose(rows, previewLines, width, height, split)
+}
+
+// renderItem draws a list row with matched characters highlighted
+func (s *state) renderItem(result Result, selected bool, width int) string {
+	matched := make(map[int]bool, len(result.Positions))
+	for _, pos := range result.Positions {
+		matched[pos] = true
+	}
+
+	var b strings.Builder
+	base := ""
+	if selected {
+		base = "\033[1m"
+		b.WriteString("\033[1;35m▌\033[0m ")
+	} else {
+		b.WriteString("  ")
+	}
+	b.WriteString(base)
+
+	for i, r := range []rune(s.texts[result.Index]) {
+		if i >= width-2 {
+			break
+		}
+		if matched[i] {
+			b.WriteString("\033[32m" + string(r) + "\033[39m")
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString("\033[0m")
+
+	return b.String()
+}
+
+// previewFor returns the (cached) preview lines for a key
+func (s *state) previewFor(key string) []string {
+	if lines, ok := s.preview[key]; ok {
+		return lines
+	}
+
+	text := strings.TrimRight(s.opts.Preview(key), "\n")
+	lines := strings.Split(tui.ExpandTabs(text, 8), "\n")
+	s.preview[key] = lines
+	return lines
+}
+
+// deleteWord removes the last word (and trailing spaces) from a query
+func deleteWord(query string) string {
+	query = strings.TrimRight(query, " ")
+	if i := strings.LastIndex(query, " "); i >= 0 {
+		return query[:i+1]
+	}
+	return ""
+}
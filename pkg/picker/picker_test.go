@@ -0,0 +1,23 @@
+package picker
+
+import (
+	"testing"
+)
+
+func TestDeleteWord(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"pk", ""},
+		{"pk tools", "pk "},
+		{"pk tools  ", "pk "},
+	}
+
+	for _, tt := range tests {
+		if got := deleteWord(tt.input); got != tt.expected {
+			t.Errorf("deleteWord(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
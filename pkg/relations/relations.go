@@ -0,0 +1,377 @@
+package relations
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// Relation kinds, as keys of [relations] in .project.toml
+const (
+	DependsOn = "depends_on"
+	Deploys   = "deploys"
+	ForkOf    = "fork_of"
+	PartOf    = "part_of"
+)
+
+// Kinds lists the relation kinds in display order
+var Kinds = []string{DependsOn, Deploys, ForkOf, PartOf}
+
+// labels describe a relation from the side of the project declaring it
+// and from the side of its target
+var labels = map[string][2]string{
+	DependsOn: {"depends on", "required by"},
+	Deploys:   {"deploys", "deployed by"},
+	ForkOf:    {"fork of", "forked as"},
+	PartOf:    {"part of", "contains"},
+}
+
+// Label describes an outbound relation, e.g. "depends on"
+func Label(kind string) string { return labels[kind][0] }
+
+// InboundLabel describes a relation seen from its target, e.g. "required by"
+func InboundLabel(kind string) string { return labels[kind][1] }
+
+// Edge is one relation from a project to another
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Of returns the relations a project declares, in Kinds order
+func Of(p *config.Project) []Edge {
+	var edges []Edge
+	add := func(kind string, targets ...string) {
+		for _, to := range targets {
+			if to = strings.TrimSpace(to); to != "" {
+				edges = append(edges, Edge{From: p.ProjectInfo.ID, To: to, Kind: kind})
+			}
+		}
+	}
+
+	add(DependsOn, p.Relations.DependsOn...)
+	add(Deploys, p.Relations.Deploys...)
+	add(ForkOf, p.Relations.ForkOf)
+	add(PartOf, p.Relations.PartOf)
+	return edges
+}
+
+// Retarget points every relation to oldID at newID instead and returns
+// the kinds it changed
+func Retarget(r *config.Relations, oldID, newID string) []string {
+	var changed []string
+	retargetList := func(kind string, list []string) {
+		for i, id := range list {
+			if strings.EqualFold(id, oldID) {
+				list[i] = newID
+				if len(changed) == 0 || changed[len(changed)-1] != kind {
+					changed = append(changed, kind)
+				}
+			}
+		}
+	}
+
+	retargetList(DependsOn, r.DependsOn)
+	retargetList(Deploys, r.Deploys)
+	if strings.EqualFold(r.ForkOf, oldID) {
+		r.ForkOf = newID
+		changed = append(changed, ForkOf)
+	}
+	if strings.EqualFold(r.PartOf, oldID) {
+		r.PartOf = newID
+		changed = append(changed, PartOf)
+	}
+	return changed
+}
+
+// Graph holds projects and the relations between them
+// Project IDs match case-insensitively, like everywhere else in pk.
+type Graph struct {
+	Projects []*config.Project // Sorted by ID
+	Edges    []Edge
+	byID     map[string]*config.Project
+}
+
+// Build collects the relations declared by projects
+func Build(projects []*config.Project) *Graph {
+	g := &Graph{byID: make(map[string]*config.Project)}
+	for _, p := range projects {
+		if p.ProjectInfo.ID == "" {
+			continue
+		}
+		g.Projects = append(g.Projects, p)
+		g.byID[strings.ToLower(p.ProjectInfo.ID)] = p
+	}
+	sort.Slice(g.Projects, func(i, j int) bool {
+		return g.Projects[i].ProjectInfo.ID < g.Projects[j].ProjectInfo.ID
+	})
+
+	for _, p := range g.Projects {
+		g.Edges = append(g.Edges, Of(p)...)
+	}
+	return g
+}
+
+// Project looks up a project by ID
+func (g *Graph) Project(id string) *config.Project {
+	return g.byID[strings.ToLower(id)]
+}
+
+// Outbound returns the relations project id declares
+func (g *Graph) Outbound(id string) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if strings.EqualFold(e.From, id) {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Inbound returns the relations other projects declare to project id
+func (g *Graph) Inbound(id string) []Edge {
+	var edges []Edge
+	for _, e := range g.Edges {
+		if strings.EqualFold(e.To, id) {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Check reports relations whose target isn't a known project or is the
+// project itself, and depends_on cycles
+func (g *Graph) Check() []error {
+	var errs []error
+	for _, e := range g.Edges {
+		switch {
+		case strings.EqualFold(e.From, e.To):
+			errs = append(errs, fmt.Errorf("%s: %s refers to itself", e.From, e.Kind))
+		case g.Project(e.To) == nil:
+			errs = append(errs, fmt.Errorf("%s: %s '%s' is not a known project", e.From, e.Kind, e.To))
+		}
+	}
+	for _, cycle := range g.dependencyCycles() {
+		errs = append(errs, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → ")))
+	}
+	return errs
+}
+
+// dependencyCycles finds depends_on cycles, each listed once and closed,
+// e.g. [a b a]
+func (g *Graph) dependencyCycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, e := range g.Outbound(id) {
+			if e.Kind != DependsOn || g.Project(e.To) == nil || strings.EqualFold(e.From, e.To) {
+				continue
+			}
+			to := g.Project(e.To).ProjectInfo.ID
+			switch state[to] {
+			case unvisited:
+				visit(to)
+			case visiting:
+				for i, onStack := range stack {
+					if onStack == to {
+						cycles = append(cycles, append(append([]string{}, stack[i:]...), to))
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, p := range g.Projects {
+		if state[p.ProjectInfo.ID] == unvisited {
+			visit(p.ProjectInfo.ID)
+		}
+	}
+	return cycles
+}
+
+// Around returns the part of the graph connected to root through chains
+// of relations: everything root leads to and everything leading to root
+func (g *Graph) Around(root string) *Graph {
+	root = strings.ToLower(root)
+	keep := map[string]bool{root: true}
+
+	for _, outbound := range []bool{true, false} {
+		seen := map[string]bool{root: true}
+		queue := []string{root}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+
+			edges, next := g.Inbound(id), func(e Edge) string { return e.From }
+			if outbound {
+				edges, next = g.Outbound(id), func(e Edge) string { return e.To }
+			}
+			for _, e := range edges {
+				if n := strings.ToLower(next(e)); !seen[n] {
+					seen[n] = true
+					keep[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+
+	sub := &Graph{byID: make(map[string]*config.Project)}
+	for _, p := range g.Projects {
+		if keep[strings.ToLower(p.ProjectInfo.ID)] {
+			sub.Projects = append(sub.Projects, p)
+			sub.byID[strings.ToLower(p.ProjectInfo.ID)] = p
+		}
+	}
+	for _, e := range g.Edges {
+		if keep[strings.ToLower(e.From)] && keep[strings.ToLower(e.To)] {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+	return sub
+}
+
+// missing returns targets of relations that aren't projects, sorted
+func (g *Graph) missing() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, e := range g.Edges {
+		if g.Project(e.To) == nil && !seen[e.To] {
+			seen[e.To] = true
+			ids = append(ids, e.To)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// id returns the canonical spelling of a relation target
+func (g *Graph) id(ref string) string {
+	if p := g.Project(ref); p != nil {
+		return p.ProjectInfo.ID
+	}
+	return ref
+}
+
+// dotStyles draw each kind of relation differently
+var dotStyles = map[string]string{
+	DependsOn: "solid",
+	Deploys:   "bold",
+	ForkOf:    "dashed",
+	PartOf:    "dotted",
+}
+
+// DOT renders the graph for Graphviz
+// Archived projects are grayed out and missing targets drawn dashed.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph projects {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n\n")
+
+	for _, p := range g.Projects {
+		attrs := ""
+		if p.ProjectInfo.Status == "archived" {
+			attrs = ", color=gray, fontcolor=gray"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q%s];\n", p.ProjectInfo.ID, p.ProjectInfo.ID, attrs)
+	}
+	for _, id := range g.missing() {
+		fmt.Fprintf(&b, "  %q [label=%q, style=dashed, color=red];\n", id, id+" (missing)")
+	}
+	if len(g.Edges) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s];\n", g.id(e.From), g.id(e.To), Label(e.Kind), dotStyles[e.Kind])
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidArrows draw each kind of relation differently
+var mermaidArrows = map[string]string{
+	DependsOn: "-->",
+	Deploys:   "==>",
+	ForkOf:    "-.->",
+	PartOf:    "-.->",
+}
+
+// Mermaid renders the graph as a Mermaid flowchart
+// Node names are n0, n1... since project IDs may contain characters
+// Mermaid reads as syntax.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	nodes := make(map[string]string)
+	node := func(id, label string) {
+		name := fmt.Sprintf("n%d", len(nodes))
+		nodes[strings.ToLower(id)] = name
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", name, strings.ReplaceAll(label, `"`, "#quot;"))
+	}
+	for _, p := range g.Projects {
+		node(p.ProjectInfo.ID, p.ProjectInfo.ID)
+	}
+	for _, id := range g.missing() {
+		node(id, id+" (missing)")
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", nodes[strings.ToLower(e.From)], mermaidArrows[e.Kind],
+			Label(e.Kind), nodes[strings.ToLower(e.To)])
+	}
+	return b.String()
+}
+
+// jsonNode is a project in the JSON rendering
+type jsonNode struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// JSON renders the graph as {"nodes": [...], "edges": [...]}
+func (g *Graph) JSON() ([]byte, error) {
+	out := struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []Edge     `json:"edges"`
+	}{Nodes: []jsonNode{}, Edges: []Edge{}}
+
+	for _, p := range g.Projects {
+		out.Nodes = append(out.Nodes, jsonNode{
+			ID:     p.ProjectInfo.ID,
+			Name:   p.ProjectInfo.Name,
+			Status: p.ProjectInfo.Status,
+			Path:   p.Path,
+		})
+	}
+	for _, id := range g.missing() {
+		out.Nodes = append(out.Nodes, jsonNode{ID: id, Missing: true})
+	}
+	for _, e := range g.Edges {
+		out.Edges = append(out.Edges, Edge{From: g.id(e.From), To: g.id(e.To), Kind: e.Kind})
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,165 @@
+package relations
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func testGraph() *Graph {
+	newProject := func(id string, dependsOn ...string) *config.Project {
+		p := &config.Project{}
+		p.ProjectInfo.ID = id
+		p.ProjectInfo.Status = "active"
+		p.Relations.DependsOn = dependsOn
+		return p
+	}
+
+	web := newProject("web", "api")
+	api := newProject("api", "DB") // IDs match case-insensitively
+	db := newProject("db")
+	infra := newProject("infra")
+	infra.Relations.Deploys = []string{"web", "api"}
+	fork := newProject("api-fork")
+	fork.Relations.ForkOf = "api"
+	lone := newProject("lone")
+
+	return Build([]*config.Project{web, api, db, infra, fork, lone})
+}
+
+func edgeList(edges []Edge) string {
+	var parts []string
+	for _, e := range edges {
+		parts = append(parts, e.From+">"+e.To+":"+e.Kind)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestInboundOutbound(t *testing.T) {
+	g := testGraph()
+
+	if got, want := edgeList(g.Outbound("infra")), "infra>web:deploys,infra>api:deploys"; got != want {
+		t.Errorf("Outbound(infra) = %s, want %s", got, want)
+	}
+	if got, want := edgeList(g.Inbound("api")), "api-fork>api:fork_of,infra>api:deploys,web>api:depends_on"; got != want {
+		t.Errorf("Inbound(api) = %s, want %s", got, want)
+	}
+	if got, want := edgeList(g.Inbound("db")), "api>DB:depends_on"; got != want {
+		t.Errorf("Inbound(db) = %s, want %s", got, want)
+	}
+	if got := g.Inbound("lone"); len(got) != 0 {
+		t.Errorf("Inbound(lone) = %v, want none", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if errs := testGraph().Check(); len(errs) != 0 {
+		t.Errorf("Check on a valid graph = %v", errs)
+	}
+
+	a := &config.Project{}
+	a.ProjectInfo.ID = "a"
+	a.Relations.DependsOn = []string{"b", "ghost"}
+	a.Relations.PartOf = "a"
+	b := &config.Project{}
+	b.ProjectInfo.ID = "b"
+	b.Relations.DependsOn = []string{"a"}
+
+	var got []string
+	for _, err := range Build([]*config.Project{a, b}).Check() {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"a: depends_on 'ghost' is not a known project",
+		"a: part_of refers to itself",
+		"dependency cycle: a → b → a",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRetarget(t *testing.T) {
+	r := config.Relations{
+		DependsOn: []string{"db", "API"},
+		Deploys:   []string{"web"},
+		ForkOf:    "api",
+	}
+
+	changed := Retarget(&r, "api", "gateway")
+	if strings.Join(changed, ",") != "depends_on,fork_of" {
+		t.Errorf("changed = %v, want depends_on, fork_of", changed)
+	}
+	if strings.Join(r.DependsOn, ",") != "db,gateway" || r.ForkOf != "gateway" || r.Deploys[0] != "web" {
+		t.Errorf("relations after Retarget = %+v", r)
+	}
+
+	if changed := Retarget(&r, "nothing", "x"); len(changed) != 0 {
+		t.Errorf("Retarget without references changed %v", changed)
+	}
+}
+
+func TestAround(t *testing.T) {
+	g := testGraph()
+
+	tests := []struct {
+		root     string
+		projects string
+	}{
+		{"web", "api,db,infra,web"},         // Downstream api, db; upstream infra
+		{"db", "api,api-fork,db,infra,web"}, // Everything leading to db
+		{"api-fork", "api,api-fork,db"},     // Only what the fork leads to
+		{"lone", "lone"},
+	}
+
+	for _, tt := range tests {
+		var ids []string
+		for _, p := range g.Around(tt.root).Projects {
+			ids = append(ids, p.ProjectInfo.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.projects {
+			t.Errorf("Around(%s) = %s, want %s", tt.root, got, tt.projects)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := testGraph()
+	g.Projects[0].Relations.DependsOn = append(g.Projects[0].Relations.DependsOn, "ghost")
+	g = Build(g.Projects)
+
+	dot := g.DOT()
+	for _, want := range []string{
+		`"web" -> "api" [label="depends on", style=solid];`,
+		`"api" -> "db" [label="depends on", style=solid];`,
+		`"ghost" [label="ghost (missing)", style=dashed, color=red];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %s:\n%s", want, dot)
+		}
+	}
+
+	mermaid := g.Mermaid()
+	for _, want := range []string{"graph LR\n", `["api-fork"]`, "-.->|fork of|", "==>|deploys|"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid missing %s:\n%s", want, mermaid)
+		}
+	}
+
+	data, err := g.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Nodes []jsonNode `json:"nodes"`
+		Edges []Edge     `json:"edges"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != 7 || !decoded.Nodes[6].Missing || len(decoded.Edges) != len(g.Edges) {
+		t.Errorf("JSON = %s", data)
+	}
+}
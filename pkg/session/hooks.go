@@ -0,0 +1,265 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/paths"
+)
+
+// Session lifecycle events
+const (
+	HookSessionStart = "on_session_start"
+	HookAttach       = "on_attach"
+	HookDetach       = "on_detach"
+	HookKill         = "on_kill"
+)
+
+// DefaultHookTimeout bounds each hook command unless [hooks] timeout is set
+const DefaultHookTimeout = 30 * time.Second
+
+// GetHookLogFile returns the path to the hook output log
+func GetHookLogFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	logDir := filepath.Join(homeDir, ".local", "state", "pk", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(logDir, "hooks.log"), nil
+}
+
+// HookCommand returns the command configured for event in a hooks table
+func HookCommand(hooks config.Hooks, event string) string {
+	switch event {
+	case HookSessionStart:
+		return hooks.OnSessionStart
+	case HookAttach:
+		return hooks.OnAttach
+	case HookDetach:
+		return hooks.OnDetach
+	case HookKill:
+		return hooks.OnKill
+	}
+	return ""
+}
+
+// RunHook runs the global and then the project command for event
+// Output of every command is appended to the hook log
+func RunHook(event string, project *config.Project) error {
+	var global config.Hooks
+	if resolver, err := paths.NewResolver(); err == nil {
+		global = resolver.Hooks()
+	}
+
+	var errs []error
+	for _, hooks := range []config.Hooks{global, project.Hooks} {
+		command := HookCommand(hooks, event)
+		if command == "" {
+			continue
+		}
+		if err := runHookCommand(event, command, hookTimeout(hooks), project); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// runHookWarn runs a hook and reports failures without aborting the caller
+func runHookWarn(event string, project *config.Project) {
+	if err := RunHook(event, project); err != nil {
+		logFile, _ := GetHookLogFile()
+		fmt.Fprintf(os.Stderr, "Warning: %s hook failed: %v (see %s)\n", event, err, logFile)
+	}
+}
+
+// hasHook reports whether event is configured globally or for the project
+func hasHook(event string, project *config.Project) bool {
+	if HookCommand(project.Hooks, event) != "" {
+		return true
+	}
+	resolver, err := paths.NewResolver()
+	if err != nil {
+		return false
+	}
+	return HookCommand(resolver.Hooks(), event) != ""
+}
+
+// hookTimeout parses the configured timeout, falling back to the default
+func hookTimeout(hooks config.Hooks) time.Duration {
+	if hooks.Timeout == "" {
+		return DefaultHookTimeout
+	}
+	timeout, err := time.ParseDuration(hooks.Timeout)
+	if err != nil || timeout <= 0 {
+		return DefaultHookTimeout
+	}
+	return timeout
+}
+
+// runHookCommand executes a hook in the project directory with project env applied
+func runHookCommand(event, command string, timeout time.Duration, project *config.Project) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = project.Path
+	cmd.Env = append(os.Environ(), HookEnv(event, project)...)
+	// Don't wait on background children holding the output pipe after a timeout
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	logHook(event, command, project, output, err, time.Since(start))
+	return err
+}
+
+// HookEnv returns the environment applied to hook commands
+// Includes PK_* identifiers, context profiles and the [tmux] env table
+func HookEnv(event string, project *config.Project) []string {
+	env := []string{
+		"PK_HOOK=" + event,
+		"PK_PROJECT_ID=" + project.ProjectInfo.ID,
+		"PK_PROJECT_NAME=" + project.ProjectInfo.Name,
+		"PK_PROJECT_PATH=" + project.Path,
+		"PK_SESSION=" + SanitizeSessionName(project.ProjectInfo.ID),
+	}
+
+	if project.Context.AWSProfile != "" {
+		env = append(env, "AWS_PROFILE="+project.Context.AWSProfile)
+	}
+	if project.Context.GCloudProject != "" {
+		env = append(env, "CLOUDSDK_CORE_PROJECT="+project.Context.GCloudProject)
+	}
+	if project.Context.DatabricksProfile != "" {
+		env = append(env, "DATABRICKS_CONFIG_PROFILE="+project.Context.DatabricksProfile)
+	}
+	if project.Context.SnowflakeAccount != "" {
+		env = append(env, "SNOWFLAKE_ACCOUNT="+project.Context.SnowflakeAccount)
+	}
+
+	for _, k := range sortedKeys(project.Tmux.Env) {
+		env = append(env, k+"="+project.Tmux.Env[k])
+	}
+
+	return env
+}
+
+// logHook appends a hook run and its output to the hook log
+func logHook(event, command string, project *config.Project, output []byte, runErr error, elapsed time.Duration) {
+	logFile, err := GetHookLogFile()
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	result := "ok"
+	if runErr != nil {
+		result = runErr.Error()
+	}
+
+	fmt.Fprintf(f, "[%s] %s %s: %s (%s, %s)\n",
+		time.Now().Format("2006-01-02 15:04:05"),
+		project.ProjectInfo.ID, event, command, result, elapsed.Round(time.Millisecond))
+	if len(output) > 0 {
+		f.Write(output)
+		if output[len(output)-1] != '\n' {
+			fmt.Fprintln(f)
+		}
+	}
+}
+
+// registerDetachHook installs a tmux hook that calls back into pk on detach
+func registerDetachHook(project *config.Project, sessionName string) {
+	if !hasHook(HookDetach, project) {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	callback := fmt.Sprintf("run-shell -b \"'%s' session hook %s '%s'\"", executable, HookDetach, sessionName)
+	tmuxCommand("set-hook", "-t", sessionName, "client-detached", callback).Run()
+}
+
+// activityHookIndex is the slot pk takes in tmux's global hook arrays
+// Index 0 (what a plain set-hook writes) stays free for the user's own hooks.
+const activityHookIndex = 50
+
+// activityHooks are the tmux hooks reporting to 'pk time event'
+var activityHooks = []struct{ hook, event string }{
+	{"client-session-changed", "switch"},
+	{"client-attached", "switch"},
+	{"client-detached", "detach"},
+}
+
+// ActivityHookNames returns the tmux hooks pk installs, e.g.
+// client-attached[50], for 'tmux set-hook -gu'
+func ActivityHookNames() []string {
+	var names []string
+	for _, h := range activityHooks {
+		names = append(names, fmt.Sprintf("%s[%d]", h.hook, activityHookIndex))
+	}
+	return names
+}
+
+// activityHooksEnabled reports whether [time] tmux_hooks is set in config.toml
+func activityHooksEnabled() bool {
+	resolver, err := paths.NewResolver()
+	return err == nil && resolver.TmuxActivityHooks()
+}
+
+// InstallActivityHooks makes tmux report session switches, attaches and
+// detaches to 'pk time event' so work done without going through pk is
+// tracked too. The hooks are global and last until the tmux server exits;
+// with [time] tmux_hooks set, every 'pk session' installs them again.
+func InstallActivityHooks() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	names := ActivityHookNames()
+	for i, h := range activityHooks {
+		callback := fmt.Sprintf("run-shell -b \"'%s' time event %s '#{session_name}'\"", executable, h.event)
+		if output, err := tmuxCommand("set-hook", "-g", names[i], callback).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux set-hook failed: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// RemoveActivityHooks unsets the hooks InstallActivityHooks installed
+func RemoveActivityHooks() error {
+	for _, name := range ActivityHookNames() {
+		if output, err := tmuxCommand("set-hook", "-gu", name).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux set-hook failed: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
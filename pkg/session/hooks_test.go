@@ -0,0 +1,185 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestHookCommand(t *testing.T) {
+	hooks := config.Hooks{
+		OnSessionStart: "make dev",
+		OnAttach:       "echo attach",
+		OnDetach:       "echo detach",
+		OnKill:         "docker compose down",
+	}
+
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{HookSessionStart, "make dev"},
+		{HookAttach, "echo attach"},
+		{HookDetach, "echo detach"},
+		{HookKill, "docker compose down"},
+		{"on_unknown", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := HookCommand(hooks, tt.event); got != tt.want {
+			t.Errorf("HookCommand(%q) = %q, want %q", tt.event, got, tt.want)
+		}
+		if got := HookCommand(config.Hooks{}, tt.event); got != "" {
+			t.Errorf("HookCommand(%q) without hooks = %q, want none", tt.event, got)
+		}
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+	}{
+		{"", DefaultHookTimeout},
+		{"5s", 5 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"250ms", 250 * time.Millisecond},
+		{"30", DefaultHookTimeout},
+		{"soon", DefaultHookTimeout},
+		{"0s", DefaultHookTimeout},
+		{"-5s", DefaultHookTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := hookTimeout(config.Hooks{Timeout: tt.timeout}); got != tt.want {
+			t.Errorf("hookTimeout(%q) = %s, want %s", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestHookEnv(t *testing.T) {
+	base := []string{
+		"PK_HOOK=on_attach",
+		"PK_PROJECT_ID=acme.api",
+		"PK_PROJECT_NAME=Acme API",
+		"PK_PROJECT_PATH=/work/acme-api",
+		"PK_SESSION=acme_api",
+	}
+
+	tests := []struct {
+		name    string
+		context config.Context
+		env     map[string]string
+		want    []string
+	}{
+		{"identifiers only", config.Context{}, nil, base},
+		{
+			"context profiles",
+			config.Context{AWSProfile: "prod", GCloudProject: "acme-gcp", DatabricksProfile: "dbx", SnowflakeAccount: "acme-sf"},
+			nil,
+			append(append([]string{}, base...),
+				"AWS_PROFILE=prod", "CLOUDSDK_CORE_PROJECT=acme-gcp",
+				"DATABRICKS_CONFIG_PROFILE=dbx", "SNOWFLAKE_ACCOUNT=acme-sf"),
+		},
+		{
+			"tmux env sorted after context",
+			config.Context{AWSProfile: "dev"},
+			map[string]string{"STAGE": "dev", "PORT": "3000"},
+			append(append([]string{}, base...), "AWS_PROFILE=dev", "PORT=3000", "STAGE=dev"),
+		},
+	}
+
+	for _, tt := range tests {
+		project := &config.Project{Path: "/work/acme-api", Context: tt.context}
+		project.ProjectInfo.ID = "acme.api"
+		project.ProjectInfo.Name = "Acme API"
+		project.Tmux.Env = tt.env
+
+		if got := HookEnv(HookAttach, project); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: HookEnv = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunHookOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// The global hook runs before the project's, both in the project directory
+	configDir := filepath.Join(tmpDir, ".config", "pk")
+	os.MkdirAll(configDir, 0755)
+	global := "[hooks]\non_session_start = 'echo \"global $PK_HOOK\" >> order.log'\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(global), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := &config.Project{Path: t.TempDir()}
+	project.ProjectInfo.ID = "app"
+	project.Hooks.OnSessionStart = `echo "project $PK_PROJECT_ID" >> order.log`
+	project.Hooks.OnKill = "exit 3"
+
+	if err := RunHook(HookSessionStart, project); err != nil {
+		t.Fatalf("RunHook: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(project.Path, "order.log"))
+	if got := strings.TrimSpace(string(data)); got != "global on_session_start\nproject app" {
+		t.Errorf("order.log = %q", got)
+	}
+
+	if err := RunHook(HookKill, project); err == nil {
+		t.Error("a failing hook should return its error")
+	}
+	if err := RunHook(HookDetach, project); err != nil {
+		t.Errorf("an unset hook should do nothing, got %v", err)
+	}
+}
+
+func TestActivityHooks(t *testing.T) {
+	startTestServer(t, 0, 0)
+
+	if activityHooksEnabled() {
+		t.Error("tmux hooks should be off without [time] tmux_hooks")
+	}
+	configDir := filepath.Join(os.Getenv("HOME"), ".config", "pk")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("[time]\ntmux_hooks = true\n"), 0644)
+	if !activityHooksEnabled() {
+		t.Error("tmux hooks should be on with [time] tmux_hooks = true")
+	}
+
+	// A hook of the user's own at index 0
+	if output, err := tmuxCommand("set-hook", "-g", "client-attached", "display-message mine").CombinedOutput(); err != nil {
+		t.Fatalf("set-hook failed: %v: %s", err, output)
+	}
+	showHooks := func() string {
+		output, _ := tmuxCommand("show-hooks", "-g").Output()
+		return string(output)
+	}
+
+	if err := InstallActivityHooks(); err != nil {
+		t.Fatalf("InstallActivityHooks: %v", err)
+	}
+	hooks := showHooks()
+	for _, name := range ActivityHookNames() {
+		if !strings.Contains(hooks, name+" ") {
+			t.Errorf("%s not installed:\n%s", name, hooks)
+		}
+	}
+
+	if err := RemoveActivityHooks(); err != nil {
+		t.Fatalf("RemoveActivityHooks: %v", err)
+	}
+	hooks = showHooks()
+	if strings.Contains(hooks, "[50]") {
+		t.Errorf("hooks left after removal:\n%s", hooks)
+	}
+	if !strings.Contains(hooks, "display-message mine") {
+		t.Errorf("the user's own hook should stay:\n%s", hooks)
+	}
+}
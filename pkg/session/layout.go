@@ -0,0 +1,375 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// tmuxStep is a single tmux invocation used to build a layout
+type tmuxStep struct {
+	args     []string
+	optional bool // Failure is ignored (cosmetic steps such as send-keys or select-layout)
+}
+
+// layoutIndexes holds the index bases a layout is built against
+// They come from the session's base-index and pane-base-index options
+type layoutIndexes struct {
+	window int
+	pane   int
+}
+
+// CreateWithLayout creates a session with custom window layout
+func CreateWithLayout(project *config.Project) error {
+	sessionName := SanitizeSessionName(project.ProjectInfo.ID)
+
+	if err := buildLayout(project, sessionName); err != nil {
+		return err
+	}
+
+	// Switch to session
+	return SwitchSession(sessionName)
+}
+
+// ResetSession re-applies the project's layout to its running session
+// Existing windows are replaced, so repeated calls never duplicate windows
+func ResetSession(project *config.Project) error {
+	sessionName := SanitizeSessionName(project.ProjectInfo.ID)
+
+	if !SessionExists(sessionName) {
+		return CreateSession(project)
+	}
+
+	if err := resetLayout(project, sessionName); err != nil {
+		return err
+	}
+
+	return AttachSession(project, sessionName)
+}
+
+// buildLayout creates a detached session from the project's layout
+// The first configured window is created together with the session
+func buildLayout(project *config.Project, sessionName string) error {
+	name, path := firstWindow(project)
+
+	// Create base session (detached) with the session-wide environment
+	newSession := []string{"new-session", "-d", "-s", sessionName, "-c", path}
+	if name != "" {
+		newSession = append(newSession, "-n", name)
+	}
+	newSession = append(newSession, envFlags(project.Tmux.Env)...)
+	if err := runSteps([]tmuxStep{{args: newSession}}); err != nil {
+		return err
+	}
+
+	idx, err := queryIndexes(sessionName)
+	if err != nil {
+		return err
+	}
+
+	return runSteps(planWindows(project, sessionName, idx))
+}
+
+// resetLayout rebuilds the windows of a running session in place
+// A fresh first window is created, every other window is killed and the
+// remaining window is renumbered to base-index before the layout is applied.
+// When pk runs inside the session, its own window is killed last, after the
+// new layout is built, since killing it ends pk.
+func resetLayout(project *config.Project, sessionName string) error {
+	output, err := tmuxCommand("list-windows", "-t", sessionName, "-F", "#{window_id}").Output()
+	if err != nil {
+		return fmt.Errorf("failed to list windows of %s: %w", sessionName, err)
+	}
+	oldWindows := splitLines(string(output))
+	current := currentWindow(oldWindows)
+
+	name, path := firstWindow(project)
+	newWindow := []string{"new-window", "-d", "-P", "-F", "#{window_id}", "-t", sessionName + ":", "-c", path}
+	if name != "" {
+		newWindow = append(newWindow, "-n", name)
+	}
+	output, err = tmuxCommand(newWindow...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tmux new-window failed: %s", strings.TrimSpace(string(output)))
+	}
+	first := strings.TrimSpace(string(output))
+
+	var steps []tmuxStep
+	for _, id := range oldWindows {
+		if id != current {
+			steps = append(steps, tmuxStep{args: []string{"kill-window", "-t", id}})
+		}
+	}
+	if current != "" && windowIndex(current) < windowIndex(first) {
+		// The first window must come first once renumbered
+		steps = append(steps, tmuxStep{args: []string{"swap-window", "-d", "-s", first, "-t", current}})
+	}
+	steps = append(steps, tmuxStep{args: []string{"move-window", "-r", "-t", sessionName + ":"}})
+
+	// Refresh the session-wide environment for new panes
+	for _, k := range sortedKeys(project.Tmux.Env) {
+		steps = append(steps, tmuxStep{args: []string{"set-environment", "-t", sessionName, k, project.Tmux.Env[k]}})
+	}
+
+	if err := runSteps(steps); err != nil {
+		return err
+	}
+
+	var layout []tmuxStep
+	if len(project.Tmux.Windows) > 0 {
+		idx, err := queryIndexes(sessionName)
+		if err != nil {
+			return err
+		}
+		if current != "" {
+			// Park pk's window past the ones the layout creates
+			target := fmt.Sprintf("%s:%d", sessionName, idx.window+len(project.Tmux.Windows))
+			layout = append(layout, tmuxStep{args: []string{"move-window", "-d", "-s", current, "-t", target}})
+		}
+		layout = append(layout, planWindows(project, sessionName, idx)...)
+	}
+	if current != "" {
+		if len(project.Tmux.Windows) == 0 {
+			layout = append(layout, tmuxStep{args: []string{"select-window", "-t", first}, optional: true})
+		}
+		layout = append(layout, tmuxStep{args: []string{"kill-window", "-t", current}})
+	}
+
+	return runSteps(layout)
+}
+
+// currentWindow returns the window running pk ($TMUX_PANE) if it is one
+// of windows, or "" otherwise
+func currentWindow(windows []string) string {
+	pane := os.Getenv("TMUX_PANE")
+	if !IsInTmux() || pane == "" {
+		return ""
+	}
+
+	output, err := tmuxCommand("display-message", "-p", "-t", pane, "#{window_id}").Output()
+	if err != nil {
+		return ""
+	}
+	id := strings.TrimSpace(string(output))
+	for _, window := range windows {
+		if window == id {
+			return id
+		}
+	}
+	return ""
+}
+
+// windowIndex returns the index of the window with the given id, or -1
+func windowIndex(id string) int {
+	output, err := tmuxCommand("display-message", "-p", "-t", id, "#{window_index}").Output()
+	if err != nil {
+		return -1
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// firstWindow returns the name and start directory of the first configured window
+func firstWindow(project *config.Project) (string, string) {
+	if len(project.Tmux.Windows) == 0 {
+		return "", project.Path
+	}
+
+	window := project.Tmux.Windows[0]
+	path := resolveDir(project.Path, window.Path)
+	if len(window.Panes) > 0 {
+		path = resolveDir(path, window.Panes[0].Path)
+	}
+
+	return windowName(window, 0), path
+}
+
+// windowName returns the configured name or a positional default
+func windowName(window config.TmuxWindow, i int) string {
+	if window.Name != "" {
+		return window.Name
+	}
+	return fmt.Sprintf("window-%d", i+1)
+}
+
+// queryIndexes reads base-index and pane-base-index for a session
+func queryIndexes(sessionName string) (layoutIndexes, error) {
+	output, err := tmuxCommand("display-message", "-p", "-t", sessionName+":",
+		"#{base-index} #{pane-base-index}").Output()
+	if err != nil {
+		return layoutIndexes{}, fmt.Errorf("failed to read index options of %s: %w", sessionName, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return layoutIndexes{}, fmt.Errorf("unexpected index options for %s: %q", sessionName, output)
+	}
+
+	window, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return layoutIndexes{}, err
+	}
+	pane, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return layoutIndexes{}, err
+	}
+
+	return layoutIndexes{window: window, pane: pane}, nil
+}
+
+// runSteps executes tmux steps in order, stopping at the first required failure
+func runSteps(steps []tmuxStep) error {
+	for _, step := range steps {
+		output, err := tmuxCommand(step.args...).CombinedOutput()
+		if err != nil && !step.optional {
+			return fmt.Errorf("tmux %s failed: %s", step.args[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// planWindows returns the ordered tmux commands that build the project's windows
+// The first window must already exist at idx.window (created with the session)
+// The sequence is deterministic so it can be inspected and tested without tmux
+func planWindows(project *config.Project, sessionName string, idx layoutIndexes) []tmuxStep {
+	var steps []tmuxStep
+
+	focusWindow := ""
+	for i, window := range project.Tmux.Windows {
+		windowTarget := fmt.Sprintf("%s:%d", sessionName, idx.window+i)
+		windowPath := resolveDir(project.Path, window.Path)
+
+		// The window's own command/path describe its first pane unless panes are listed
+		panes := window.Panes
+		if len(panes) == 0 {
+			panes = []config.TmuxPane{{Command: window.Command}}
+		}
+
+		first := panes[0]
+		firstPath := resolveDir(windowPath, first.Path)
+		if i == 0 {
+			// The session's initial window becomes the first window; restart its
+			// shell only when it needs window-specific environment
+			if len(window.Env) > 0 {
+				respawn := []string{"respawn-pane", "-k", "-t", fmt.Sprintf("%s.%d", windowTarget, idx.pane), "-c", firstPath}
+				respawn = append(respawn, envFlags(window.Env)...)
+				steps = append(steps, tmuxStep{args: respawn})
+			}
+		} else {
+			newWindow := []string{"new-window", "-t", windowTarget, "-n", windowName(window, i), "-c", firstPath}
+			newWindow = append(newWindow, envFlags(window.Env)...)
+			steps = append(steps, tmuxStep{args: newWindow})
+		}
+		steps = append(steps, sendKeys(windowTarget, first.Command)...)
+
+		// Each further pane splits the one created before it (the active pane)
+		for _, pane := range panes[1:] {
+			split := []string{"split-window", "-t", windowTarget, splitFlag(pane.Split)}
+			if pane.Size != "" {
+				split = append(split, "-l", pane.Size)
+			}
+			split = append(split, "-c", resolveDir(windowPath, pane.Path))
+			split = append(split, envFlags(window.Env)...)
+			steps = append(steps, tmuxStep{args: split})
+			steps = append(steps, sendKeys(windowTarget, pane.Command)...)
+		}
+
+		// Window layout overrides the session default
+		layout := window.Layout
+		if layout == "" {
+			layout = project.Tmux.Layout
+		}
+		if layout != "" {
+			steps = append(steps, tmuxStep{args: []string{"select-layout", "-t", windowTarget, layout}, optional: true})
+		}
+
+		// Pane focus (last flagged pane wins)
+		for j, pane := range panes {
+			if pane.Focus {
+				steps = append(steps, tmuxStep{
+					args:     []string{"select-pane", "-t", fmt.Sprintf("%s.%d", windowTarget, idx.pane+j)},
+					optional: true,
+				})
+			}
+		}
+
+		if window.Focus {
+			focusWindow = windowTarget
+		}
+	}
+
+	// Without an explicit focus, start on the first window
+	if focusWindow == "" && len(project.Tmux.Windows) > 0 {
+		focusWindow = fmt.Sprintf("%s:%d", sessionName, idx.window)
+	}
+	if focusWindow != "" {
+		steps = append(steps, tmuxStep{args: []string{"select-window", "-t", focusWindow}, optional: true})
+	}
+
+	return steps
+}
+
+// sendKeys returns the step that types a command into the active pane of target
+func sendKeys(target, command string) []tmuxStep {
+	if command == "" {
+		return nil
+	}
+	return []tmuxStep{{args: []string{"send-keys", "-t", target, command, "Enter"}, optional: true}}
+}
+
+// splitFlag maps a split direction to the tmux split-window flag
+// "horizontal" places panes side by side, "vertical" stacks them
+func splitFlag(direction string) string {
+	switch strings.ToLower(direction) {
+	case "vertical", "v":
+		return "-v"
+	default:
+		return "-h"
+	}
+}
+
+// envFlags converts an environment map into sorted -e flags
+func envFlags(env map[string]string) []string {
+	var flags []string
+	for _, k := range sortedKeys(env) {
+		flags = append(flags, "-e", k+"="+env[k])
+	}
+	return flags
+}
+
+// sortedKeys returns the keys of an environment map in sorted order
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolveDir resolves a configured path relative to base
+// Empty paths fall back to base and ~ expands to the home directory
+func resolveDir(base, path string) string {
+	if path == "" {
+		return base
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[1:])
+		}
+	}
+
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
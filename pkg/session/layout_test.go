@@ -0,0 +1,255 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestPlanWindowsPanes(t *testing.T) {
+	project := &config.Project{Path: "/work/app"}
+	project.ProjectInfo.ID = "app"
+	project.Tmux.Env = map[string]string{"STAGE": "dev"}
+	project.Tmux.Windows = []config.TmuxWindow{
+		{
+			Name:   "code",
+			Layout: "main-vertical",
+			Env:    map[string]string{"PORT": "3000"},
+			Panes: []config.TmuxPane{
+				{Command: "nvim"},
+				{Command: "go test ./...", Split: "horizontal", Size: "40%", Focus: true},
+				{Command: "tail -f app.log", Path: "logs", Split: "vertical"},
+			},
+		},
+		{Name: "shell", Focus: true},
+	}
+
+	var got [][]string
+	for _, step := range planWindows(project, "app", layoutIndexes{window: 1, pane: 0}) {
+		got = append(got, step.args)
+	}
+
+	want := [][]string{
+		{"respawn-pane", "-k", "-t", "app:1.0", "-c", "/work/app", "-e", "PORT=3000"},
+		{"send-keys", "-t", "app:1", "nvim", "Enter"},
+		{"split-window", "-t", "app:1", "-h", "-l", "40%", "-c", "/work/app", "-e", "PORT=3000"},
+		{"send-keys", "-t", "app:1", "go test ./...", "Enter"},
+		{"split-window", "-t", "app:1", "-v", "-c", "/work/app/logs", "-e", "PORT=3000"},
+		{"send-keys", "-t", "app:1", "tail -f app.log", "Enter"},
+		{"select-layout", "-t", "app:1", "main-vertical"},
+		{"select-pane", "-t", "app:1.1"},
+		{"new-window", "-t", "app:2", "-n", "shell", "-c", "/work/app"},
+		{"select-window", "-t", "app:2"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planWindows mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestPlanWindowsWindowCommand(t *testing.T) {
+	project := &config.Project{Path: "/work/app"}
+	project.ProjectInfo.ID = "my.app"
+	project.Tmux.Layout = "tiled"
+	project.Tmux.Windows = []config.TmuxWindow{
+		{Command: "make watch", Path: "/tmp"},
+		{Command: "htop"},
+	}
+
+	// base-index 0 / pane-base-index 0 are the tmux defaults
+	var got [][]string
+	for _, step := range planWindows(project, SanitizeSessionName(project.ProjectInfo.ID), layoutIndexes{}) {
+		got = append(got, step.args)
+	}
+
+	want := [][]string{
+		{"send-keys", "-t", "my_app:0", "make watch", "Enter"},
+		{"select-layout", "-t", "my_app:0", "tiled"},
+		{"new-window", "-t", "my_app:1", "-n", "window-2", "-c", "/work/app"},
+		{"send-keys", "-t", "my_app:1", "htop", "Enter"},
+		{"select-layout", "-t", "my_app:1", "tiled"},
+		{"select-window", "-t", "my_app:0"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planWindows mismatch\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// testServers counts servers started by this test binary
+var testServers int
+
+// startTestServer runs an isolated tmux server (tmux -L) with the given index options
+func startTestServer(t *testing.T, baseIndex, paneBaseIndex int) {
+	t.Helper()
+
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir) // No user tmux.conf
+	t.Setenv("TMUX", "")
+	t.Setenv("TMUX_TMPDIR", tmpDir)
+
+	// A fresh socket per test avoids racing the shutdown of the previous server
+	testServers++
+	original := socketName
+	socketName = fmt.Sprintf("pk-test-%d-%d", os.Getpid(), testServers)
+	t.Cleanup(func() {
+		tmuxCommand("kill-server").Run()
+		socketName = original
+	})
+
+	setup := [][]string{
+		{"-f", "/dev/null", "new-session", "-d", "-s", "boot"},
+		{"set-option", "-g", "base-index", fmt.Sprint(baseIndex)},
+		{"set-option", "-gw", "pane-base-index", fmt.Sprint(paneBaseIndex)},
+	}
+	for _, args := range setup {
+		if output, err := tmuxCommand(args...).CombinedOutput(); err != nil {
+			t.Fatalf("tmux %v failed: %v: %s", args, err, output)
+		}
+	}
+}
+
+// listWindows returns "index:name:panes" for every window of a session
+func listWindows(t *testing.T, sessionName string) []string {
+	t.Helper()
+
+	output, err := tmuxCommand("list-windows", "-t", sessionName, "-F",
+		"#{window_index}:#{window_name}:#{window_panes}").Output()
+	if err != nil {
+		t.Fatalf("list-windows failed: %v", err)
+	}
+	return strings.Fields(string(output))
+}
+
+func testLayoutProject(path string) *config.Project {
+	project := &config.Project{Path: path}
+	project.ProjectInfo.ID = "layout"
+	project.Tmux.Windows = []config.TmuxWindow{
+		{Name: "code", Panes: []config.TmuxPane{
+			{Command: ""},
+			{Split: "vertical", Focus: true},
+		}},
+		{Name: "shell"},
+		{Name: "logs", Focus: true},
+	}
+	return project
+}
+
+func TestBuildLayoutRespectsBaseIndex(t *testing.T) {
+	for _, base := range []int{0, 1} {
+		t.Run(fmt.Sprintf("base-index %d", base), func(t *testing.T) {
+			startTestServer(t, base, base)
+			project := testLayoutProject(t.TempDir())
+
+			if err := buildLayout(project, "layout"); err != nil {
+				t.Fatalf("buildLayout failed: %v", err)
+			}
+
+			want := []string{
+				fmt.Sprintf("%d:code:2", base),
+				fmt.Sprintf("%d:shell:1", base+1),
+				fmt.Sprintf("%d:logs:1", base+2),
+			}
+			if got := listWindows(t, "layout"); !reflect.DeepEqual(got, want) {
+				t.Errorf("windows = %v, want %v", got, want)
+			}
+
+			active, _ := tmuxCommand("display-message", "-p", "-t", "layout", "#{window_name}").Output()
+			if strings.TrimSpace(string(active)) != "logs" {
+				t.Errorf("active window = %q, want logs", strings.TrimSpace(string(active)))
+			}
+		})
+	}
+}
+
+func TestResetLayoutIsIdempotent(t *testing.T) {
+	startTestServer(t, 1, 0)
+	project := testLayoutProject(t.TempDir())
+
+	if err := buildLayout(project, "layout"); err != nil {
+		t.Fatalf("buildLayout failed: %v", err)
+	}
+
+	// Drift: an extra window the layout doesn't know about
+	if err := tmuxCommand("new-window", "-t", "layout:", "-n", "stray").Run(); err != nil {
+		t.Fatalf("new-window failed: %v", err)
+	}
+
+	want := []string{"1:code:2", "2:shell:1", "3:logs:1"}
+	for i := 0; i < 2; i++ {
+		if err := resetLayout(project, "layout"); err != nil {
+			t.Fatalf("resetLayout #%d failed: %v", i+1, err)
+		}
+		if got := listWindows(t, "layout"); !reflect.DeepEqual(got, want) {
+			t.Errorf("after reset #%d windows = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestResetLayoutFromInsideSession(t *testing.T) {
+	startTestServer(t, 1, 0)
+	project := testLayoutProject(t.TempDir())
+
+	if err := buildLayout(project, "layout"); err != nil {
+		t.Fatalf("buildLayout failed: %v", err)
+	}
+	output, err := tmuxCommand("list-windows", "-t", "layout", "-F", "#{window_id}").Output()
+	if err != nil {
+		t.Fatalf("list-windows failed: %v", err)
+	}
+	oldWindows := splitLines(string(output))
+
+	// The test binary stands in for pk, resetting from the logs window
+	respawn := []string{"respawn-pane", "-k", "-t", "layout:3",
+		"-e", "PK_TEST_RESET_SOCKET=" + socketName, "-e", "PK_TEST_RESET_DIR=" + project.Path,
+		os.Args[0], "-test.run=^TestResetLayoutHelper$"}
+	if output, err := tmuxCommand(respawn...).CombinedOutput(); err != nil {
+		t.Fatalf("respawn-pane failed: %v: %s", err, output)
+	}
+
+	want := []string{"1:code:2", "2:shell:1", "3:logs:1"}
+	var got []string
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		output, _ := tmuxCommand("list-windows", "-t", "layout", "-F", "#{window_id}").Output()
+		got = listWindows(t, "layout")
+		replaced := true
+		for _, id := range splitLines(string(output)) {
+			for _, old := range oldWindows {
+				replaced = replaced && id != old
+			}
+		}
+		if replaced && reflect.DeepEqual(got, want) {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("windows = %v, want %v", got, want)
+	}
+	active, _ := tmuxCommand("display-message", "-p", "-t", "layout", "#{window_name}").Output()
+	if strings.TrimSpace(string(active)) != "logs" {
+		t.Errorf("active window = %q, want logs", strings.TrimSpace(string(active)))
+	}
+}
+
+// TestResetLayoutHelper is the pk process of TestResetLayoutFromInsideSession
+func TestResetLayoutHelper(t *testing.T) {
+	socket := os.Getenv("PK_TEST_RESET_SOCKET")
+	if socket == "" {
+		t.Skip("run by TestResetLayoutFromInsideSession")
+	}
+	socketName = socket
+
+	if err := resetLayout(testLayoutProject(os.Getenv("PK_TEST_RESET_DIR")), "layout"); err != nil {
+		t.Fatalf("resetLayout failed: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// projectOption is the tmux session option linking a session to its project
+// It survives renames, so sessions can be matched even when the name drifts
+const projectOption = "@pk_project"
+
+// SessionInfo describes a running tmux session
+type SessionInfo struct {
+	Name      string
+	ProjectID string // Empty for sessions not created by pk
+	Windows   int
+	Attached  bool
+	Activity  time.Time
+}
+
+// PruneCandidate is a session selected for pruning and why
+type PruneCandidate struct {
+	Session SessionInfo
+	Reason  string
+}
+
+// markSession records the owning project on a session
+func markSession(sessionName, projectID string) {
+	tmuxCommand("set-option", "-t", sessionName, projectOption, projectID).Run()
+}
+
+// SessionProjectID returns the project recorded on a session, or ""
+func SessionProjectID(sessionName string) string {
+	output, err := tmuxCommand("show-options", "-qv", "-t", sessionName, projectOption).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ListSessionInfo returns all running sessions with activity and project metadata
+func ListSessionInfo() ([]SessionInfo, error) {
+	output, err := tmuxCommand("list-sessions", "-F",
+		"#{session_name}\t#{session_windows}\t#{session_attached}\t#{session_activity}\t#{"+projectOption+"}").Output()
+	if err != nil {
+		// No server running means no sessions
+		return []SessionInfo{}, nil
+	}
+
+	var sessions []SessionInfo
+	for _, line := range splitLines(string(output)) {
+		fields := strings.SplitN(line, "\t", 5)
+		if len(fields) != 5 {
+			continue
+		}
+
+		windows, _ := strconv.Atoi(fields[1])
+		attached, _ := strconv.Atoi(fields[2])
+		activity, _ := strconv.ParseInt(fields[3], 10, 64)
+
+		sessions = append(sessions, SessionInfo{
+			Name:      fields[0],
+			ProjectID: fields[4],
+			Windows:   windows,
+			Attached:  attached > 0,
+			Activity:  time.Unix(activity, 0),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Name < sessions[j].Name
+	})
+
+	return sessions, nil
+}
+
+// RenameSession renames a running session
+func RenameSession(oldName, newName string) error {
+	newName = SanitizeSessionName(newName)
+	if newName == "" {
+		return fmt.Errorf("session name cannot be empty")
+	}
+	if !SessionExists(oldName) {
+		return fmt.Errorf("session '%s' not found", oldName)
+	}
+	if SessionExists(newName) {
+		return fmt.Errorf("session '%s' already exists", newName)
+	}
+
+	output, err := tmuxCommand("rename-session", "-t", oldName, newName).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to rename session: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RenameProjectSession renames a project's running session and points its
+// project marker at projectID
+func RenameProjectSession(oldName, newName, projectID string) error {
+	if err := RenameSession(oldName, newName); err != nil {
+		return err
+	}
+	markSession(SanitizeSessionName(newName), projectID)
+	return nil
+}
+
+// PruneCandidates selects sessions to prune
+// orphan returns a reason when the session's project is gone; idle > 0 also
+// selects sessions without activity for that long. Attached sessions are kept.
+func PruneCandidates(sessions []SessionInfo, orphan func(SessionInfo) string, idle time.Duration, now time.Time) []PruneCandidate {
+	var candidates []PruneCandidate
+
+	for _, s := range sessions {
+		if s.Attached {
+			continue
+		}
+
+		if reason := orphan(s); reason != "" {
+			candidates = append(candidates, PruneCandidate{Session: s, Reason: reason})
+			continue
+		}
+
+		if idle > 0 && now.Sub(s.Activity) > idle {
+			candidates = append(candidates, PruneCandidate{
+				Session: s,
+				Reason:  "idle for " + FormatAge(now.Sub(s.Activity)),
+			})
+		}
+	}
+
+	return candidates
+}
+
+// ParseIdle parses an idle threshold such as "90m", "12h" or "7d"
+func ParseIdle(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid idle threshold '%s'", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid idle threshold '%s' (use e.g. 90m, 12h, 7d)", value)
+	}
+	return d, nil
+}
+
+// FormatAge renders a duration in the largest whole unit (days, hours or minutes)
+func FormatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// LastClientActivity returns the newest input time across attached clients
+// and the session that client is on; ok is false when no client is attached
+func LastClientActivity() (last time.Time, sessionName string, ok bool) {
+	output, err := tmuxCommand("list-clients", "-F", "#{client_activity}\t#{session_name}").Output()
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	for _, line := range splitLines(string(output)) {
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if activity := time.Unix(seconds, 0); !ok || activity.After(last) {
+			last, sessionName, ok = activity, fields[1], true
+		}
+	}
+
+	return last, sessionName, ok
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseIdle(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90m", 90 * time.Minute, false},
+		{"12h", 12 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseIdle(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIdle(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseIdle(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []SessionInfo{
+		{Name: "fresh", ProjectID: "fresh", Activity: now.Add(-time.Hour)},
+		{Name: "stale", ProjectID: "stale", Activity: now.Add(-72 * time.Hour)},
+		{Name: "gone", ProjectID: "gone", Activity: now},
+		{Name: "gone-attached", ProjectID: "gone", Attached: true, Activity: now},
+		{Name: "stale-attached", ProjectID: "stale", Attached: true, Activity: now.Add(-72 * time.Hour)},
+	}
+	orphan := func(s SessionInfo) string {
+		if s.ProjectID == "gone" {
+			return "project deleted"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name string
+		idle time.Duration
+		want []string
+	}{
+		{"orphans only", 0, []string{"gone: project deleted"}},
+		{"with idle threshold", 48 * time.Hour, []string{"stale: idle for 3d", "gone: project deleted"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, c := range PruneCandidates(sessions, orphan, tt.idle, now) {
+			got = append(got, c.Session.Name+": "+c.Reason)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSessionInfoAndRename(t *testing.T) {
+	startTestServer(t, 0, 0)
+
+	if err := createDetached("api", t.TempDir()); err != nil {
+		t.Fatalf("createDetached failed: %v", err)
+	}
+	markSession("api", "api.v2")
+
+	if err := RenameSession("api", "api.next"); err != nil {
+		t.Fatalf("RenameSession failed: %v", err)
+	}
+	if err := RenameSession("api_next", "boot"); err == nil {
+		t.Error("RenameSession onto an existing session should fail")
+	}
+
+	sessions, err := ListSessionInfo()
+	if err != nil {
+		t.Fatalf("ListSessionInfo failed: %v", err)
+	}
+
+	var got []string
+	for _, s := range sessions {
+		got = append(got, s.Name+"="+s.ProjectID)
+		if s.Activity.IsZero() || time.Since(s.Activity) > time.Minute {
+			t.Errorf("session %s has unexpected activity %v", s.Name, s.Activity)
+		}
+	}
+	want := []string{"api_next=api.v2", "boot="}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sessions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,304 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// SessionState is a snapshot of a live tmux session that can be rebuilt later
+type SessionState struct {
+	Name        string        `json:"name"`
+	ProjectID   string        `json:"project_id"`
+	ProjectPath string        `json:"project_path"`
+	SavedAt     time.Time     `json:"saved_at"`
+	Windows     []WindowState `json:"windows"`
+}
+
+// WindowState captures a single tmux window
+type WindowState struct {
+	Index  int         `json:"index"`
+	Name   string      `json:"name"`
+	Layout string      `json:"layout"` // tmux layout string (exact pane geometry)
+	Active bool        `json:"active"`
+	Panes  []PaneState `json:"panes"`
+}
+
+// PaneState captures a single tmux pane
+type PaneState struct {
+	Index   int    `json:"index"`
+	Path    string `json:"path"`
+	Command string `json:"command"` // Foreground command, empty when the pane is at a shell prompt
+	Active  bool   `json:"active"`
+}
+
+// shells are foreground processes that mean "nothing running"
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true,
+	"dash": true, "ksh": true, "tcsh": true, "csh": true, "nu": true,
+}
+
+// GetStateDir returns the directory holding saved session snapshots
+func GetStateDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	stateDir := filepath.Join(homeDir, ".local", "state", "pk", "sessions")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", err
+	}
+
+	return stateDir, nil
+}
+
+// CaptureSession reads windows and panes of a running session
+func CaptureSession(name string) (*SessionState, error) {
+	windowOutput, err := tmuxCommand("list-windows", "-t", name, "-F",
+		"#{window_index}\t#{window_name}\t#{window_layout}\t#{window_active}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows of %s: %w", name, err)
+	}
+
+	state := &SessionState{
+		Name:    name,
+		SavedAt: time.Now(),
+	}
+
+	windowPos := make(map[int]int)
+	for _, line := range splitLines(string(windowOutput)) {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		index, _ := strconv.Atoi(fields[0])
+		windowPos[index] = len(state.Windows)
+		state.Windows = append(state.Windows, WindowState{
+			Index:  index,
+			Name:   fields[1],
+			Layout: fields[2],
+			Active: fields[3] == "1",
+		})
+	}
+
+	paneOutput, err := tmuxCommand("list-panes", "-s", "-t", name, "-F",
+		"#{window_index}\t#{pane_index}\t#{pane_current_path}\t#{pane_current_command}\t#{pane_pid}\t#{pane_active}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes of %s: %w", name, err)
+	}
+
+	children := foregroundCommands()
+	for _, line := range splitLines(string(paneOutput)) {
+		fields := strings.SplitN(line, "\t", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		windowIndex, _ := strconv.Atoi(fields[0])
+		pos, ok := windowPos[windowIndex]
+		if !ok {
+			continue
+		}
+
+		paneIndex, _ := strconv.Atoi(fields[1])
+		command := ""
+		if !shells[strings.TrimPrefix(fields[3], "-")] {
+			// Prefer the full command line of the shell's child over the bare process name
+			command = fields[3]
+			if args, ok := children[fields[4]]; ok {
+				command = args
+			}
+		}
+
+		state.Windows[pos].Panes = append(state.Windows[pos].Panes, PaneState{
+			Index:   paneIndex,
+			Path:    fields[2],
+			Command: command,
+			Active:  fields[5] == "1",
+		})
+	}
+
+	for i := range state.Windows {
+		sort.Slice(state.Windows[i].Panes, func(a, b int) bool {
+			return state.Windows[i].Panes[a].Index < state.Windows[i].Panes[b].Index
+		})
+	}
+
+	return state, nil
+}
+
+// foregroundCommands maps parent PIDs to the command line of their child process
+// Used to recover arguments of programs started from a pane's shell
+func foregroundCommands() map[string]string {
+	commands := make(map[string]string)
+
+	output, err := exec.Command("ps", "-A", "-o", "ppid=", "-o", "args=").Output()
+	if err != nil {
+		return commands
+	}
+
+	for _, line := range splitLines(string(output)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		// Keep the first child only (the job started from the prompt)
+		if _, exists := commands[fields[0]]; !exists {
+			commands[fields[0]] = strings.Join(fields[1:], " ")
+		}
+	}
+
+	return commands
+}
+
+// SaveState writes a session snapshot to the state directory
+func SaveState(state *SessionState) error {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(stateDir, config.FileSafeID(state.Name)+".json"), data, 0644)
+}
+
+// LoadState reads a saved session snapshot by session name
+func LoadState(name string) (*SessionState, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, config.FileSafeID(name)+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no saved state for session '%s'", name)
+		}
+		return nil, err
+	}
+
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// ListStates returns all saved session snapshots sorted by name
+func ListStates() ([]*SessionState, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []*SessionState
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		state, err := LoadState(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			// Skip unreadable snapshots
+			continue
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+
+	return states, nil
+}
+
+// TmuxWindows converts the snapshot into [tmux] window configuration
+// Paths inside root are made relative so the result can be written to .project.toml
+func (s *SessionState) TmuxWindows(root string) []config.TmuxWindow {
+	var windows []config.TmuxWindow
+
+	for _, w := range s.Windows {
+		window := config.TmuxWindow{
+			Name:   w.Name,
+			Layout: w.Layout,
+			Focus:  w.Active,
+		}
+
+		for _, p := range w.Panes {
+			window.Panes = append(window.Panes, config.TmuxPane{
+				Command: p.Command,
+				Path:    relativeTo(root, p.Path),
+				Focus:   p.Active && len(w.Panes) > 1,
+			})
+		}
+
+		windows = append(windows, window)
+	}
+
+	return windows
+}
+
+// RestoreSession rebuilds a saved session (detached) through the layout builder
+func RestoreSession(state *SessionState, project *config.Project) error {
+	if SessionExists(state.Name) {
+		return fmt.Errorf("session '%s' is already running", state.Name)
+	}
+
+	restored := *project
+	restored.Tmux.Layout = ""
+	restored.Tmux.Windows = state.TmuxWindows(project.Path)
+
+	if err := buildLayout(&restored, state.Name); err != nil {
+		return err
+	}
+
+	markSession(state.Name, project.ProjectInfo.ID)
+	runHookWarn(HookSessionStart, project)
+	registerDetachHook(project, state.Name)
+	return nil
+}
+
+// relativeTo returns path relative to root when it lies inside root
+func relativeTo(root, path string) string {
+	if root == "" || path == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return ""
+	}
+	return rel
+}
+
+// splitLines splits command output into non-empty lines
+// Only newlines are trimmed so trailing empty tab-separated fields survive
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,86 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestRelativeTo(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		path string
+		want string
+	}{
+		{"root itself", "/work/app", "/work/app", ""},
+		{"child", "/work/app", "/work/app/api", "api"},
+		{"nested child", "/work/app", "/work/app/api/cmd", "api/cmd"},
+		{"child starting with dots", "/work/app", "/work/app/..cache", "..cache"},
+		{"parent", "/work/app", "/work", "/work"},
+		{"sibling", "/work/app", "/work/app-v2", "/work/app-v2"},
+		{"outside", "/work/app", "/tmp", "/tmp"},
+		{"no root", "", "/work/app/api", "/work/app/api"},
+		{"no path", "/work/app", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := relativeTo(tt.root, tt.path); got != tt.want {
+			t.Errorf("%s: relativeTo(%q, %q) = %q, want %q", tt.name, tt.root, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTmuxWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []WindowState
+		want    []config.TmuxWindow
+	}{
+		{
+			name: "single pane",
+			windows: []WindowState{
+				{Name: "code", Layout: "b25d,80x24,0,0,1", Active: true, Panes: []PaneState{
+					{Path: "/work/app", Command: "nvim", Active: true},
+				}},
+			},
+			want: []config.TmuxWindow{
+				{Name: "code", Layout: "b25d,80x24,0,0,1", Focus: true, Panes: []config.TmuxPane{
+					{Command: "nvim"},
+				}},
+			},
+		},
+		{
+			name: "split panes keep focus and relative paths",
+			windows: []WindowState{
+				{Name: "dev", Panes: []PaneState{
+					{Path: "/work/app/api"},
+					{Path: "/work/app/..cache", Command: "tail -f log", Active: true},
+				}},
+				{Name: "scratch", Panes: []PaneState{
+					{Path: "/tmp", Active: true},
+				}},
+			},
+			want: []config.TmuxWindow{
+				{Name: "dev", Panes: []config.TmuxPane{
+					{Path: "api"},
+					{Path: "..cache", Command: "tail -f log", Focus: true},
+				}},
+				{Name: "scratch", Panes: []config.TmuxPane{
+					{Path: "/tmp"},
+				}},
+			},
+		},
+		{
+			name: "no windows",
+		},
+	}
+
+	for _, tt := range tests {
+		state := &SessionState{Name: "app", ProjectPath: "/work/app", Windows: tt.windows}
+		if got := state.TmuxWindows("/work/app"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: TmuxWindows = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
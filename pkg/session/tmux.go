@@ -9,6 +9,19 @@ import (
 	"github.com/datakaicr/pk/pkg/config"
 )
 
+// socketName selects a private tmux server (tmux -L) when set
+// Empty uses the default server; tests point it at an isolated socket
+var socketName string
+
+// tmuxCommand builds a tmux invocation against the configured server
+// -u keeps tabs in -F output; without a UTF-8 locale tmux rewrites them to "_"
+func tmuxCommand(args ...string) *exec.Cmd {
+	if socketName != "" {
+		args = append([]string{"-L", socketName}, args...)
+	}
+	return exec.Command("tmux", append([]string{"-u"}, args...)...)
+}
+
 // CheckTmux verifies if tmux is installed
 func CheckTmux() error {
 	if _, err := exec.LookPath("tmux"); err != nil {
@@ -25,10 +38,29 @@ func IsInTmux() bool {
 
 // SessionExists checks if a tmux session exists
 func SessionExists(name string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t="+name)
+	cmd := tmuxCommand("has-session", "-t="+name)
 	return cmd.Run() == nil
 }
 
+// ServerRunning reports whether the tmux server is up
+func ServerRunning() bool {
+	return tmuxCommand("list-sessions").Run() == nil
+}
+
+// CurrentSession returns the name of the tmux session pk is running in
+func CurrentSession() (string, error) {
+	if !IsInTmux() {
+		return "", fmt.Errorf("not inside tmux")
+	}
+
+	output, err := tmuxCommand("display-message", "-p", "#S").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 // SanitizeSessionName converts a project name to a valid tmux session name
 func SanitizeSessionName(name string) string {
 	// Replace dots with underscores (tmux doesn't like dots)
@@ -36,42 +68,57 @@ func SanitizeSessionName(name string) string {
 }
 
 // CreateSession creates a new tmux session
+// Lifecycle hooks run around creation: on_session_start once, on_attach every time
 func CreateSession(project *config.Project) error {
 	sessionName := SanitizeSessionName(project.ProjectInfo.ID)
+	if activityHooksEnabled() {
+		InstallActivityHooks()
+	}
 
 	// Check if session already exists
 	if SessionExists(sessionName) {
-		return SwitchSession(sessionName)
+		return AttachSession(project, sessionName)
 	}
 
 	// Create new session based on configuration
+	var err error
 	if len(project.Tmux.Windows) > 0 {
-		return CreateWithLayout(project)
+		err = buildLayout(project, sessionName)
+	} else {
+		err = createDetached(sessionName, project.Path)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Create basic session
-	return CreateBasicSession(sessionName, project.Path)
+	markSession(sessionName, project.ProjectInfo.ID)
+	runHookWarn(HookSessionStart, project)
+	registerDetachHook(project, sessionName)
+
+	return AttachSession(project, sessionName)
 }
 
 // CreateBasicSession creates a simple single-window session
 func CreateBasicSession(sessionName, path string) error {
-	var cmd *exec.Cmd
+	if err := createDetached(sessionName, path); err != nil {
+		return err
+	}
+	return SwitchSession(sessionName)
+}
 
-	if IsInTmux() {
-		// Inside tmux: create detached and switch
-		cmd = exec.Command("tmux", "new-session", "-ds", sessionName, "-c", path)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create tmux session: %w", err)
-		}
-		return SwitchSession(sessionName)
+// createDetached creates a single-window session without attaching to it
+func createDetached(sessionName, path string) error {
+	cmd := tmuxCommand("new-session", "-ds", sessionName, "-c", path)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create tmux session: %w", err)
 	}
+	return nil
+}
 
-	// Outside tmux: attach directly
-	cmd = exec.Command("tmux", "new-session", "-s", sessionName, "-c", path)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// AttachSession runs the on_attach hook and switches to the project's session
+func AttachSession(project *config.Project, sessionName string) error {
+	runHookWarn(HookAttach, project)
+	return SwitchSession(sessionName)
 }
 
 // SwitchSession switches to an existing session
@@ -79,9 +126,9 @@ func SwitchSession(sessionName string) error {
 	var cmd *exec.Cmd
 
 	if IsInTmux() {
-		cmd = exec.Command("tmux", "switch-client", "-t", sessionName)
+		cmd = tmuxCommand("switch-client", "-t", sessionName)
 	} else {
-		cmd = exec.Command("tmux", "attach-session", "-t", sessionName)
+		cmd = tmuxCommand("attach-session", "-t", sessionName)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -90,58 +137,9 @@ func SwitchSession(sessionName string) error {
 	return cmd.Run()
 }
 
-// CreateWithLayout creates a session with custom window layout
-func CreateWithLayout(project *config.Project) error {
-	sessionName := SanitizeSessionName(project.ProjectInfo.ID)
-
-	// Create base session (detached)
-	cmd := exec.Command("tmux", "new-session", "-ds", sessionName, "-c", project.Path)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
-	}
-
-	// Kill the default window
-	exec.Command("tmux", "kill-window", "-t", sessionName+":1").Run()
-
-	// Create windows from configuration
-	for i, window := range project.Tmux.Windows {
-		windowPath := project.Path
-		if window.Path != "" {
-			windowPath = window.Path
-		}
-
-		windowName := window.Name
-		if windowName == "" {
-			windowName = fmt.Sprintf("window-%d", i+1)
-		}
-
-		// Create window
-		windowTarget := fmt.Sprintf("%s:%d", sessionName, i+1)
-		createCmd := exec.Command("tmux", "new-window", "-t", windowTarget, "-n", windowName, "-c", windowPath)
-		if err := createCmd.Run(); err != nil {
-			return fmt.Errorf("failed to create window %s: %w", windowName, err)
-		}
-
-		// Send command if specified
-		if window.Command != "" {
-			sendCmd := exec.Command("tmux", "send-keys", "-t", windowTarget, window.Command, "Enter")
-			sendCmd.Run()
-		}
-	}
-
-	// Set layout if specified
-	if project.Tmux.Layout != "" {
-		layoutCmd := exec.Command("tmux", "select-layout", "-t", sessionName, project.Tmux.Layout)
-		layoutCmd.Run()
-	}
-
-	// Switch to session
-	return SwitchSession(sessionName)
-}
-
 // ListSessions returns all active tmux sessions
 func ListSessions() ([]string, error) {
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
+	cmd := tmuxCommand("list-sessions", "-F", "#{session_name}")
 	output, err := cmd.Output()
 	if err != nil {
 		// No sessions is not an error
@@ -154,6 +152,22 @@ func ListSessions() ([]string, error) {
 
 // KillSession kills a tmux session by name
 func KillSession(name string) error {
-	cmd := exec.Command("tmux", "kill-session", "-t", name)
+	cmd := tmuxCommand("kill-session", "-t", name)
 	return cmd.Run()
 }
+
+// KillProjectSession runs the on_kill hook and kills the project's session
+func KillProjectSession(project *config.Project) error {
+	return KillNamedSession(project, SanitizeSessionName(project.ProjectInfo.ID))
+}
+
+// KillNamedSession runs the project's on_kill hook and kills sessionName
+// Used when the session was renamed away from the project ID
+func KillNamedSession(project *config.Project, sessionName string) error {
+	if !SessionExists(sessionName) {
+		return fmt.Errorf("no session named '%s'", sessionName)
+	}
+
+	runHookWarn(HookKill, project)
+	return KillSession(sessionName)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/datakaicr/pk/pkg/config"
@@ -35,8 +36,21 @@ func GenerateAliases(shell Shell, projects []*config.Project) error {
 	datakai := []*config.Project{}
 	active := []*config.Project{}
 	archived := []*config.Project{}
+	subprojects := []*config.Project{}
+	tagged := make(map[string][]*config.Project)
+	ids := make(map[string]bool)
 
 	for _, p := range projects {
+		ids[p.ProjectInfo.ID] = true
+
+		// Subprojects of active projects get their own section
+		if p.IsSubproject() {
+			if p.ProjectInfo.Status != "archived" {
+				subprojects = append(subprojects, p)
+			}
+			continue
+		}
+
 		// DataKai ecosystem (special handling)
 		if p.ProjectInfo.ID == "conduit" || p.ProjectInfo.ID == "dk" || p.ProjectInfo.ID == "dkos" {
 			datakai = append(datakai, p)
@@ -46,6 +60,9 @@ func GenerateAliases(shell Shell, projects []*config.Project) error {
 		// Categorize others
 		if p.ProjectInfo.Status == "archived" {
 			archived = append(archived, p)
+		} else if tag := firstTag(p); tag != "" {
+			// Tagged projects get a section per tag, under their first one
+			tagged[tag] = append(tagged[tag], p)
 		} else {
 			active = append(active, p)
 		}
@@ -54,8 +71,18 @@ func GenerateAliases(shell Shell, projects []*config.Project) error {
 	// Write DataKai ecosystem
 	writeSection(f, shell, "DataKai Ecosystem", datakai)
 
-	// Special DataKai aliases
-	writeDataKaiSpecial(f, shell)
+	// Write monorepo subprojects
+	writeSubprojectSection(f, shell, subprojects, ids)
+
+	// Write tagged projects
+	tags := make([]string, 0, len(tagged))
+	for tag := range tagged {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		writeSection(f, shell, "Tag: "+tag, tagged[tag])
+	}
 
 	// Write active projects
 	writeSection(f, shell, "Active Projects", active)
@@ -74,6 +101,16 @@ func GenerateAliases(shell Shell, projects []*config.Project) error {
 	return nil
 }
 
+// firstTag returns a project's first non-empty tag, normalized
+func firstTag(p *config.Project) string {
+	for _, t := range p.ProjectInfo.Tags {
+		if tag := config.NormalizeTag(t); tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
 func writeHeader(f *os.File, shell Shell) {
 	switch shell {
 	case Zsh, Bash:
@@ -146,19 +183,33 @@ func writeArchivedSection(f *os.File, shell Shell, projects []*config.Project) {
 	fmt.Fprintf(f, "\n")
 }
 
-func writeDataKaiSpecial(f *os.File, shell Shell) {
-	homeDir, _ := os.UserHomeDir()
+// writeSubprojectSection writes subprojects under their bare name, e.g.
+// "dojo" for dk/dojo, or "dk-dojo" when the name is taken by a project or
+// an earlier subproject (a/web and b/web)
+func writeSubprojectSection(f *os.File, shell Shell, projects []*config.Project, ids map[string]bool) {
+	if len(projects) == 0 {
+		return
+	}
 
-	// Check if dojo exists in monorepo
-	dojoPath := filepath.Join(homeDir, "projects", "dk", "apps", "dojo")
-	if _, err := os.Stat(dojoPath); err == nil {
-		writeAlias(f, shell, "dojo", dojoPath, "")
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].ProjectInfo.ID < projects[j].ProjectInfo.ID
+	})
+
+	switch shell {
+	case Zsh, Bash:
+		fmt.Fprintf(f, "# ---------- Subprojects ----------\n")
+	case Fish:
+		fmt.Fprintf(f, "# Subprojects\n")
 	}
 
-	// Check if vision docs exist
-	visionPath := filepath.Join(homeDir, "projects", "dk", "docs", "vision")
-	if _, err := os.Stat(visionPath); err == nil {
-		writeAlias(f, shell, "vision", visionPath, "")
+	written := make(map[string]bool)
+	for _, p := range projects {
+		name := p.Alias()
+		if ids[name] || written[name] || name == "pk" {
+			name = strings.ReplaceAll(p.ProjectInfo.ID, "/", "-")
+		}
+		written[name] = true
+		writeAlias(f, shell, name, p.Path, p.ProjectInfo.ID)
 	}
 
 	fmt.Fprintf(f, "\n")
@@ -0,0 +1,172 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Side effects a state can have when a project enters it
+const (
+	EffectSetCompleted   = "set_completed"    // dates.completed = today, unless already set
+	EffectClearCompleted = "clear_completed"  // dates.completed = ""
+	EffectArchive        = "move_to_archive"  // Move the project into the archive root
+	EffectUnarchive      = "move_to_projects" // Move the project back into the projects root
+)
+
+var knownEffects = map[string]bool{
+	EffectSetCompleted:   true,
+	EffectClearCompleted: true,
+	EffectArchive:        true,
+	EffectUnarchive:      true,
+}
+
+// colors maps color names usable in config.toml to ANSI codes
+var colors = map[string]string{
+	"red":     "\033[31m",
+	"green":   "\033[32m",
+	"yellow":  "\033[33m",
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"gray":    "\033[90m",
+}
+
+// State is one allowed project status, configured as [[status]] in
+// ~/.config/pk/config.toml
+type State struct {
+	Name    string   `toml:"name"`
+	Color   string   `toml:"color"`    // red | green | yellow | blue | magenta | cyan | gray
+	Next    []string `toml:"next"`     // States a project may move to from this one
+	OnEnter []string `toml:"on_enter"` // Effects applied when a project enters this state
+}
+
+// Has reports whether entering the state has the given effect
+func (s State) Has(effect string) bool {
+	for _, e := range s.OnEnter {
+		if e == effect {
+			return true
+		}
+	}
+	return false
+}
+
+// Machine is the set of states and the transitions between them
+type Machine struct {
+	states []State
+}
+
+// Default is the state machine used without a [[status]] config
+func Default() *Machine {
+	return &Machine{states: []State{
+		{Name: "active", Color: "green", Next: []string{"paused", "completed", "archived"},
+			OnEnter: []string{EffectClearCompleted, EffectUnarchive}},
+		{Name: "paused", Color: "cyan", Next: []string{"active", "completed", "archived"},
+			OnEnter: []string{EffectUnarchive}},
+		{Name: "completed", Color: "blue", Next: []string{"active", "archived"},
+			OnEnter: []string{EffectSetCompleted}},
+		{Name: "archived", Color: "yellow", Next: []string{"active", "paused"},
+			OnEnter: []string{EffectSetCompleted, EffectArchive}},
+		{Name: "experimental", Color: "magenta", Next: []string{"active", "paused", "archived"}},
+	}}
+}
+
+// New builds a machine from configured states, checking that names are
+// unique, transitions point at known states and effects exist
+func New(states []State) (*Machine, error) {
+	if len(states) == 0 {
+		return nil, fmt.Errorf("no states configured")
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range states {
+		if s.Name == "" {
+			return nil, fmt.Errorf("a [[status]] entry has no name")
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("status '%s' is defined twice", s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	for _, s := range states {
+		for _, next := range s.Next {
+			if !seen[next] {
+				return nil, fmt.Errorf("status '%s' allows unknown next status '%s'", s.Name, next)
+			}
+		}
+		for _, effect := range s.OnEnter {
+			if !knownEffects[effect] {
+				return nil, fmt.Errorf("status '%s' has unknown effect '%s'", s.Name, effect)
+			}
+		}
+		if s.Color != "" && colors[s.Color] == "" {
+			return nil, fmt.Errorf("status '%s' has unknown color '%s'", s.Name, s.Color)
+		}
+		if s.Has(EffectArchive) && s.Has(EffectUnarchive) {
+			return nil, fmt.Errorf("status '%s' can't both archive and unarchive", s.Name)
+		}
+	}
+
+	return &Machine{states: states}, nil
+}
+
+// Initial is the status new projects start with: the first state
+func (m *Machine) Initial() string {
+	return m.states[0].Name
+}
+
+// Names returns all state names in configured order
+func (m *Machine) Names() []string {
+	names := make([]string, len(m.states))
+	for i, s := range m.states {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// State looks up a state by name
+func (m *Machine) State(name string) (State, bool) {
+	for _, s := range m.states {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return State{}, false
+}
+
+// Check reports why a project can't move from one status to another
+// A project with an empty or unknown status may move to any known one,
+// so 'pk status' can repair free-text values.
+func (m *Machine) Check(from, to string) error {
+	target, ok := m.State(to)
+	if !ok {
+		return fmt.Errorf("unknown status '%s' (known: %s)", to, strings.Join(m.Names(), ", "))
+	}
+	if from == to {
+		return fmt.Errorf("already %s", to)
+	}
+
+	current, ok := m.State(from)
+	if !ok {
+		return nil
+	}
+	for _, next := range current.Next {
+		if next == target.Name {
+			return nil
+		}
+	}
+
+	if len(current.Next) == 0 {
+		return fmt.Errorf("%s is a final status", from)
+	}
+	return fmt.Errorf("can't go from %s to %s (allowed: %s)", from, to, strings.Join(current.Next, ", "))
+}
+
+// Color returns the ANSI color code of a status, or "" if it has none
+func (m *Machine) Color(name string) string {
+	s, ok := m.State(name)
+	if !ok {
+		return ""
+	}
+	return colors[s.Color]
+}
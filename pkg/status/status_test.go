@@ -0,0 +1,65 @@
+package status
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	m := Default()
+
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"active", "paused", true},
+		{"active", "archived", true},
+		{"paused", "completed", true},
+		{"archived", "active", true},
+		{"archived", "completed", false}, // Not an allowed transition
+		{"active", "active", false},      // No-op
+		{"active", "done", false},        // Unknown target
+		{"", "active", true},             // Unset status can go anywhere
+		{"wip", "paused", true},          // So can free text from before the machine
+	}
+
+	for _, tt := range tests {
+		err := m.Check(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("Check(%q, %q) = %v, want ok=%v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []State
+		ok     bool
+	}{
+		{"valid", []State{{Name: "open", Next: []string{"closed"}}, {Name: "closed", OnEnter: []string{EffectArchive}}}, true},
+		{"empty", nil, false},
+		{"duplicate", []State{{Name: "open"}, {Name: "open"}}, false},
+		{"unknown next", []State{{Name: "open", Next: []string{"closed"}}}, false},
+		{"unknown effect", []State{{Name: "open", OnEnter: []string{"explode"}}}, false},
+		{"unknown color", []State{{Name: "open", Color: "plaid"}}, false},
+		{"conflicting moves", []State{{Name: "open", OnEnter: []string{EffectArchive, EffectUnarchive}}}, false},
+	}
+
+	for _, tt := range tests {
+		m, err := New(tt.states)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: New = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+		if err == nil && m.Initial() != tt.states[0].Name {
+			t.Errorf("%s: Initial = %s, want %s", tt.name, m.Initial(), tt.states[0].Name)
+		}
+	}
+}
+
+func TestFinalState(t *testing.T) {
+	m, err := New([]State{{Name: "open", Next: []string{"closed"}}, {Name: "closed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check("closed", "open"); err == nil || err.Error() != "closed is a final status" {
+		t.Errorf("Check from final state = %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package tomledit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// entry is a key/value pair as it appears in the file
+type entry struct {
+	path       string // Full dotted path, e.g. "consultant.billable"
+	start, end int    // First and last line of the entry (arrays may span lines)
+	valueCol   int    // Column where the value starts on the first line
+	comment    string // Trailing comment of a single-line entry, with leading space
+}
+
+// table is a [header] line
+type table struct {
+	name  string
+	line  int
+	array bool
+}
+
+// document is the line structure of a TOML file
+type document struct {
+	lines   []string
+	entries []entry
+	tables  []table
+}
+
+// Set replaces the value of table.key with literal, keeping everything else
+// in the file (comments, ordering, indentation) as it was. A missing key is
+// added at the end of its table; a missing table is appended to the file.
+// literal must be a TOML value, see Literal.
+func Set(data []byte, tableName, key, literal string) []byte {
+	doc := parse(string(data))
+	path := tableName + "." + key
+
+	for _, e := range doc.entries {
+		if e.path != path {
+			continue
+		}
+		first := doc.lines[e.start]
+		line := first[:e.valueCol] + literal + e.comment
+		lines := append(append(append([]string{}, doc.lines[:e.start]...), line), doc.lines[e.end+1:]...)
+		return join(lines)
+	}
+
+	for i, t := range doc.tables {
+		if t.array || t.name != tableName {
+			continue
+		}
+
+		// End of the table: the next header, or the end of the file
+		limit := len(doc.lines)
+		if i+1 < len(doc.tables) {
+			limit = doc.tables[i+1].line
+		}
+
+		insertAt := t.line + 1
+		indent := ""
+		for _, e := range doc.entries {
+			if e.start > t.line && e.start < limit {
+				insertAt = e.end + 1
+				first := doc.lines[e.start]
+				indent = first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+			}
+		}
+
+		line := indent + key + " = " + literal
+		lines := append(append(append([]string{}, doc.lines[:insertAt]...), line), doc.lines[insertAt:]...)
+		return join(lines)
+	}
+
+	lines := doc.lines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "["+tableName+"]", key+" = "+literal)
+	return join(lines)
+}
+
+// AppendArrayTable adds a [[name]] entry with the given key/literal pairs
+// at the end of the file, e.g. a new [[status_history]] record
+func AppendArrayTable(data []byte, name string, pairs [][2]string) []byte {
+	lines := strings.Split(string(data), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "[["+name+"]]")
+	for _, pair := range pairs {
+		lines = append(lines, pair[0]+" = "+pair[1])
+	}
+	return join(lines)
+}
+
+// join rebuilds file content, always ending with a newline
+func join(lines []string) []byte {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// parse finds the tables and key/value entries of a TOML file
+func parse(content string) *document {
+	doc := &document{lines: strings.Split(content, "\n")}
+
+	current := ""
+	var scan valueScanner
+	var open *entry
+
+	for i, line := range doc.lines {
+		if open != nil {
+			scan.feed(line)
+			if scan.done() {
+				open.end = i
+				doc.entries = append(doc.entries, *open)
+				open = nil
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			array := strings.HasPrefix(trimmed, "[[")
+			name := strings.Trim(stripComment(trimmed), "[] \t")
+			doc.tables = append(doc.tables, table{name: unquoteKey(name), line: i, array: array})
+			current = unquoteKey(name)
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key := unquoteKey(strings.TrimSpace(line[:eq]))
+		path := key
+		if current != "" {
+			path = current + "." + key
+		}
+
+		valueCol := eq + 1
+		for valueCol < len(line) && (line[valueCol] == ' ' || line[valueCol] == '\t') {
+			valueCol++
+		}
+
+		scan = valueScanner{}
+		commentCol := scan.feed(line[valueCol:])
+		e := entry{path: path, start: i, end: i, valueCol: valueCol}
+		if scan.done() {
+			if commentCol >= 0 {
+				// Keep the spacing between the value and the comment
+				value := line[valueCol : valueCol+commentCol]
+				e.comment = value[len(strings.TrimRight(value, " \t")):] + line[valueCol+commentCol:]
+			}
+			doc.entries = append(doc.entries, e)
+			continue
+		}
+		open = &e
+	}
+
+	return doc
+}
+
+// valueScanner tracks brackets and strings across the lines of one value
+type valueScanner struct {
+	depth     int
+	quote     byte // Open string quote, or 0
+	multiline bool
+}
+
+func (s *valueScanner) done() bool {
+	return s.depth <= 0 && !(s.quote != 0 && s.multiline)
+}
+
+// feed scans one line, returning the column of a trailing comment or -1
+func (s *valueScanner) feed(line string) int {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		if s.quote != 0 {
+			switch {
+			case c == '\\' && s.quote == '"':
+				i++
+			case c == s.quote && s.multiline:
+				if strings.HasPrefix(line[i:], strings.Repeat(string(c), 3)) {
+					s.quote = 0
+					s.multiline = false
+					i += 2
+				}
+			case c == s.quote:
+				s.quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '#':
+			return i
+		case '[', '{':
+			s.depth++
+		case ']', '}':
+			s.depth--
+		case '"', '\'':
+			s.quote = c
+			if strings.HasPrefix(line[i:], strings.Repeat(string(c), 3)) {
+				s.multiline = true
+				i += 2
+			}
+		}
+	}
+
+	// Single-line strings can't continue on the next line
+	if !s.multiline {
+		s.quote = 0
+	}
+	return -1
+}
+
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// unquoteKey removes quotes and spaces from each part of a dotted key
+func unquoteKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Literal renders a string, bool, float64 or []string as a TOML value
+func Literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, item := range v {
+			quoted[i] = quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return quote(fmt.Sprint(value))
+}
+
+// quote writes a TOML basic string
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Diff renders a line diff of old and new with two lines of context,
+// "-" for removed and "+" for added lines
+func Diff(oldData, newData []byte) []string {
+	a := strings.Split(strings.TrimSuffix(string(oldData), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(string(newData), "\n"), "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte
+		text string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+
+	const context = 2
+	var out []string
+	lastShown := -1
+	for k, o := range ops {
+		if o.kind == ' ' {
+			near := false
+			for d := -context; d <= context; d++ {
+				if k+d >= 0 && k+d < len(ops) && ops[k+d].kind != ' ' {
+					near = true
+					break
+				}
+			}
+			if !near {
+				continue
+			}
+		}
+		if lastShown >= 0 && k > lastShown+1 {
+			out = append(out, "...")
+		}
+		out = append(out, string(o.kind)+" "+o.text)
+		lastShown = k
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package tomledit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+const sample = `# Project Metadata
+[project]
+  name = "App"   # display name
+  status = "active"
+
+[tech]
+  stack = [
+    "go", # main language
+    "sql",
+  ]
+
+[[tmux.windows]]
+name = "code"
+`
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		key     string
+		literal string
+		want    []string // Lines that must be in the result
+		absent  []string // Lines that must not be
+	}{
+		{
+			name: "replace keeps comment and indent", table: "project", key: "name", literal: `"Web App"`,
+			want: []string{`  name = "Web App"   # display name`, "# Project Metadata"},
+		},
+		{
+			name: "replace multi-line array", table: "tech", key: "stack", literal: `["go", "sql", "rust"]`,
+			want:   []string{`  stack = ["go", "sql", "rust"]`, "[[tmux.windows]]"},
+			absent: []string{`    "go", # main language`},
+		},
+		{
+			name: "add to existing table", table: "project", key: "type", literal: `"product"`,
+			want: []string{"  status = \"active\"\n  type = \"product\"\n"},
+		},
+		{
+			name: "add table", table: "consultant", key: "billable", literal: "true",
+			want: []string{"name = \"code\"\n\n[consultant]\nbillable = true\n"},
+		},
+		{
+			name: "same key in array table is not touched", table: "project", key: "name", literal: `"x"`,
+			want: []string{`name = "code"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Set([]byte(sample), tt.table, tt.key, tt.literal))
+
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("result missing %q:\n%s", want, got)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(got, absent) {
+					t.Errorf("result still has %q:\n%s", absent, got)
+				}
+			}
+
+			var v map[string]interface{}
+			if _, err := toml.Decode(got, &v); err != nil {
+				t.Errorf("result is not valid TOML: %v\n%s", err, got)
+			}
+		})
+	}
+}
+
+func TestAppendArrayTable(t *testing.T) {
+	got := string(AppendArrayTable([]byte(sample+"\n\n"), "status_history", [][2]string{
+		{"from", `"active"`},
+		{"to", `"paused"`},
+	}))
+
+	if !strings.HasSuffix(got, "name = \"code\"\n\n[[status_history]]\nfrom = \"active\"\nto = \"paused\"\n") {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+
+	var v struct {
+		History []map[string]string `toml:"status_history"`
+	}
+	if _, err := toml.Decode(got, &v); err != nil || len(v.History) != 1 || v.History[0]["to"] != "paused" {
+		t.Errorf("decoded history = %+v, err %v", v.History, err)
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"Acme Corp", `"Acme Corp"`},
+		{`say "hi"\now`, `"say \"hi\"\\now"`},
+		{true, "true"},
+		{150.5, "150.5"},
+		{[]string{"go", "rust"}, `["go", "rust"]`},
+		{[]string{}, "[]"},
+	}
+
+	for _, tt := range tests {
+		if got := Literal(tt.value); got != tt.want {
+			t.Errorf("Literal(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldData := "a\nb\nc\nd\ne\nf\ng\n"
+	newData := "a\nb\nc\nD\ne\nf\ng\n"
+
+	got := strings.Join(Diff([]byte(oldData), []byte(newData)), "\n")
+	want := "  b\n  c\n- d\n+ D\n  e\n  f"
+	if got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+
+	if diff := Diff([]byte(oldData), []byte(oldData)); len(diff) != 0 {
+		t.Errorf("Diff of equal content = %v, want nothing", diff)
+	}
+}
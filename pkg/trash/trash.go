@@ -0,0 +1,311 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/session"
+)
+
+// Kinds of trashed directories
+const (
+	KindProject = "project"
+	KindScratch = "scratch"
+)
+
+const manifestFile = "manifest.json"
+
+// Item is a trashed directory and what is needed to put it back
+// On disk an item is ~/.local/share/pk/trash/<id>/ holding manifest.json
+// and the moved directory under its original name.
+type Item struct {
+	ID           string                `json:"id"`
+	Kind         string                `json:"kind"`
+	ProjectID    string                `json:"project_id,omitempty"`
+	Name         string                `json:"name"`
+	OriginalPath string                `json:"original_path"`
+	DeletedAt    time.Time             `json:"deleted_at"`
+	Metadata     string                `json:"metadata,omitempty"` // .project.toml at deletion time
+	Session      *session.SessionState `json:"session,omitempty"`  // tmux layout at deletion time
+
+	dir string // Trash entry directory, set when loaded
+}
+
+// Path returns where the trashed directory currently lives
+func (item *Item) Path() string {
+	return filepath.Join(item.dir, filepath.Base(item.OriginalPath))
+}
+
+// GetTrashDir returns the pk trash directory
+func GetTrashDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	trashDir := filepath.Join(homeDir, ".local", "share", "pk", "trash")
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return "", err
+	}
+
+	return trashDir, nil
+}
+
+// Move puts the directory at item.OriginalPath into the trash
+// ID, DeletedAt and Metadata are filled in when empty.
+func Move(item Item) (*Item, error) {
+	if _, err := os.Stat(item.OriginalPath); err != nil {
+		return nil, err
+	}
+
+	trashDir, err := GetTrashDir()
+	if err != nil {
+		return nil, err
+	}
+
+	if item.DeletedAt.IsZero() {
+		item.DeletedAt = time.Now()
+	}
+	if item.Name == "" {
+		item.Name = filepath.Base(item.OriginalPath)
+	}
+	if item.Metadata == "" {
+		if data, err := os.ReadFile(filepath.Join(item.OriginalPath, ".project.toml")); err == nil {
+			item.Metadata = string(data)
+		}
+	}
+	if item.ID == "" {
+		item.ID = uniqueID(trashDir, filepath.Base(item.OriginalPath)+"-"+item.DeletedAt.Format("20060102-150405"))
+	}
+
+	item.dir = filepath.Join(trashDir, item.ID)
+	if err := os.MkdirAll(item.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := moveDir(item.OriginalPath, item.Path()); err != nil {
+		os.RemoveAll(item.dir)
+		return nil, fmt.Errorf("failed to move to trash: %w", err)
+	}
+
+	if err := writeManifest(&item); err != nil {
+		// Without a manifest the item can't be listed, so put it back
+		moveDir(item.Path(), item.OriginalPath)
+		os.RemoveAll(item.dir)
+		return nil, fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+
+	cache.InvalidateCache()
+	return &item, nil
+}
+
+// List returns trashed items, most recently deleted first
+func List() ([]*Item, error) {
+	trashDir, err := GetTrashDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(trashDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*Item
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		item, err := readManifest(filepath.Join(trashDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// Find returns a trashed item by trash ID, or the latest one deleted
+// under that project ID or name (case-insensitive)
+func Find(ref string) (*Item, error) {
+	items, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.ID == ref {
+			return item, nil
+		}
+	}
+
+	ref = strings.ToLower(ref)
+	for _, item := range items {
+		if strings.ToLower(item.ProjectID) == ref || strings.ToLower(item.Name) == ref ||
+			strings.ToLower(filepath.Base(item.OriginalPath)) == ref {
+			return item, nil
+		}
+	}
+
+	return nil, fmt.Errorf("'%s' is not in the trash", ref)
+}
+
+// Restore moves a trashed item back to destPath (its original path when empty)
+// Refuses when destPath already exists. Returns the restored path.
+func Restore(item *Item, destPath string) (string, error) {
+	if destPath == "" {
+		destPath = item.OriginalPath
+	}
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		return "", fmt.Errorf("%s already exists", destPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", err
+	}
+	if err := moveDir(item.Path(), destPath); err != nil {
+		return "", fmt.Errorf("failed to restore from trash: %w", err)
+	}
+	os.RemoveAll(item.dir)
+
+	cache.InvalidateCache()
+	if item.ProjectID != "" {
+		cache.RelocateProject(item.ProjectID, destPath)
+	}
+
+	return destPath, nil
+}
+
+// Remove permanently deletes a trashed item
+func Remove(item *Item) error {
+	return os.RemoveAll(item.dir)
+}
+
+// Empty permanently deletes items trashed more than olderThan before now
+// An olderThan of 0 empties the whole trash. Returns the removed items.
+func Empty(olderThan time.Duration, now time.Time) ([]*Item, error) {
+	items, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*Item
+	for _, item := range items {
+		if now.Sub(item.DeletedAt) < olderThan {
+			continue
+		}
+		if err := Remove(item); err != nil {
+			return removed, err
+		}
+		removed = append(removed, item)
+	}
+
+	return removed, nil
+}
+
+// uniqueID returns base, or base-2, base-3... if the trash already has it
+func uniqueID(trashDir, base string) string {
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(trashDir, id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+func writeManifest(item *Item) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(item.dir, manifestFile), data, 0644)
+}
+
+func readManifest(dir string) (*Item, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var item Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	item.dir = dir
+	return &item, nil
+}
+
+// moveDir renames src to dst, copying across filesystems when needed
+func moveDir(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a directory tree, keeping modes and symlinks
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			// Sockets, pipes and devices don't survive a move anyway
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,131 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/cache"
+)
+
+func writeProject(t *testing.T, path, id string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[project]\nname = \"" + id + "\"\nid = \"" + id + "\"\nstatus = \"active\"\n"
+	if err := os.WriteFile(filepath.Join(path, ".project.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	projectPath := filepath.Join(tmpDir, "projects", "app")
+	writeProject(t, projectPath, "app")
+
+	item, err := Move(Item{Kind: KindProject, ProjectID: "app", OriginalPath: projectPath})
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if _, err := os.Stat(projectPath); !os.IsNotExist(err) {
+		t.Error("project should be gone from its original path")
+	}
+	if _, err := os.Stat(filepath.Join(item.Path(), ".project.toml")); err != nil {
+		t.Errorf("trashed project missing: %v", err)
+	}
+	if item.Metadata == "" || item.Name != "app" {
+		t.Errorf("manifest = %+v, want metadata snapshot and name", item)
+	}
+
+	found, err := Find("APP")
+	if err != nil || found.ID != item.ID {
+		t.Fatalf("Find(APP) = %v, %v; want %s", found, err, item.ID)
+	}
+
+	// A new project in the old place blocks the restore
+	writeProject(t, projectPath, "other")
+	if _, err := Restore(found, ""); err == nil {
+		t.Error("Restore should fail when the original path is taken")
+	}
+	os.RemoveAll(projectPath)
+
+	if err := cache.AddPin(1, "app", "/stale/app"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Restore(found, "")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored != projectPath {
+		t.Errorf("restored to %s, want %s", restored, projectPath)
+	}
+	if _, err := os.Stat(filepath.Join(projectPath, ".project.toml")); err != nil {
+		t.Errorf("restored project missing: %v", err)
+	}
+	if items, _ := List(); len(items) != 0 {
+		t.Errorf("trash should be empty after restore, has %d items", len(items))
+	}
+	if pins, _ := cache.LoadPins(); pins[1].ProjectPath != projectPath {
+		t.Errorf("pin path = %s, want %s", pins[1].ProjectPath, projectPath)
+	}
+}
+
+func TestListAndEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	now := time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC)
+	ages := map[string]time.Duration{
+		"fresh": time.Hour,
+		"month": 31 * 24 * time.Hour,
+		"old":   90 * 24 * time.Hour,
+	}
+	for name, age := range ages {
+		path := filepath.Join(tmpDir, "scratch", name)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Move(Item{Kind: KindScratch, OriginalPath: path, DeletedAt: now.Add(-age)}); err != nil {
+			t.Fatalf("Move(%s): %v", name, err)
+		}
+	}
+
+	// Same directory deleted twice in the same second gets a distinct ID
+	dup := filepath.Join(tmpDir, "scratch", "fresh")
+	os.MkdirAll(dup, 0755)
+	second, err := Move(Item{Kind: KindScratch, OriginalPath: dup, DeletedAt: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Move duplicate: %v", err)
+	}
+	if second.ID != "fresh-"+now.Add(-time.Hour).Format("20060102-150405")+"-2" {
+		t.Errorf("duplicate ID = %s", second.ID)
+	}
+
+	items, err := List()
+	if err != nil || len(items) != 4 {
+		t.Fatalf("List = %d items, %v; want 4", len(items), err)
+	}
+	if items[len(items)-1].Name != "old" {
+		t.Errorf("oldest item listed last = %s, want old", items[len(items)-1].Name)
+	}
+
+	removed, err := Empty(30*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("Empty: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Empty removed %d items, want 2", len(removed))
+	}
+	if items, _ := List(); len(items) != 2 || items[0].Name != "fresh" {
+		t.Errorf("remaining items = %d, want the 2 fresh ones", len(items))
+	}
+
+	if removed, _ := Empty(0, now); len(removed) != 2 {
+		t.Errorf("Empty(0) removed %d items, want 2", len(removed))
+	}
+}
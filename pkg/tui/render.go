@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"strings"
+)
+
+// Compose renders a full-screen frame from rows
+// With split > 0 the left rows occupy split columns and the right rows are
+// drawn after a vertical separator; otherwise only left rows are drawn.
+func Compose(left, right []string, width, height, split int) string {
+	var b strings.Builder
+	b.WriteString("\033[H")
+
+	for row := 0; row < height; row++ {
+		line := ""
+		if row < len(left) {
+			line = left[row]
+		}
+
+		if split > 0 {
+			cell, visible := Truncate(line, split-1)
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", split-1-visible))
+			b.WriteString("\033[90m│\033[0m ")
+			if row < len(right) {
+				cell, _ := Truncate(right[row], width-split-1)
+				b.WriteString(cell)
+			}
+		} else {
+			cell, _ := Truncate(line, width)
+			b.WriteString(cell)
+		}
+
+		b.WriteString("\033[K")
+		if row < height-1 {
+			b.WriteString("\r\n")
+		}
+	}
+
+	return b.String()
+}
+
+// ExpandTabs replaces tabs with spaces up to the next multiple of tabstop
+func ExpandTabs(s string, tabstop int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			pad := tabstop - col%tabstop
+			b.WriteString(strings.Repeat(" ", pad))
+			col += pad
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// Truncate cuts s to width visible runes, keeping escape sequences intact
+// Returns the result and its visible width; colors are reset when present
+func Truncate(s string, width int) (string, int) {
+	var b strings.Builder
+	visible := 0
+	escaped := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\033' {
+			// Copy the whole CSI sequence (ESC [ ... final byte)
+			j := i + 1
+			if j < len(runes) && runes[j] == '[' {
+				j++
+				for j < len(runes) && (runes[j] < '@' || runes[j] > '~') {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				break
+			}
+			b.WriteString(string(runes[i : j+1]))
+			escaped = true
+			i = j
+			continue
+		}
+
+		if visible >= width {
+			break
+		}
+		b.WriteRune(r)
+		visible++
+	}
+
+	if escaped {
+		b.WriteString("\033[0m")
+	}
+	return b.String(), visible
+}
+
+// Pad truncates or right-pads s to exactly width visible columns
+func Pad(s string, width int) string {
+	cell, visible := Truncate(s, width)
+	return cell + strings.Repeat(" ", width-visible)
+}
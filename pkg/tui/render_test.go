@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		input    string
+		tabstop  int
+		expected string
+	}{
+		{"no tabs", 8, "no tabs"},
+		{"pk\t[me]\tactive", 8, "pk      [me]    active"},
+		{"datakai\tx", 4, "datakai x"},
+		{"\tx", 4, "    x"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandTabs(tt.input, tt.tabstop); got != tt.expected {
+			t.Errorf("ExpandTabs(%q, %d) = %q, want %q", tt.input, tt.tabstop, got, tt.expected)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input   string
+		width   int
+		want    string
+		visible int
+	}{
+		{"hello", 10, "hello", 5},
+		{"hello", 3, "hel", 3},
+		{"\033[32mgreen\033[0m text", 3, "\033[32mgre\033[0m", 3},
+		{"\033[1mab\033[0m", 5, "\033[1mab\033[0m\033[0m", 2},
+		{"✓ clean", 3, "✓ c", 3},
+	}
+
+	for _, tt := range tests {
+		got, visible := Truncate(tt.input, tt.width)
+		if got != tt.want || visible != tt.visible {
+			t.Errorf("Truncate(%q, %d) = %q (%d), want %q (%d)",
+				tt.input, tt.width, got, visible, tt.want, tt.visible)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"ab", 4, "ab  "},
+		{"abcdef", 4, "abcd"},
+		{"\033[1mab\033[0m", 3, "\033[1mab\033[0m\033[0m "},
+	}
+
+	for _, tt := range tests {
+		if got := Pad(tt.input, tt.width); got != tt.expected {
+			t.Errorf("Pad(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.expected)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	got := Compose([]string{"left", "l2"}, []string{"right"}, 12, 2, 6)
+	want := "\033[Hleft \033[90m│\033[0m right\033[K\r\nl2   \033[90m│\033[0m \033[K"
+	if got != want {
+		t.Errorf("Compose split = %q, want %q", got, want)
+	}
+
+	got = Compose([]string{"a long line"}, nil, 6, 1, 0)
+	want = "\033[Ha long\033[K"
+	if got != want {
+		t.Errorf("Compose single = %q, want %q", got, want)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  Key
+	}{
+		{[]byte{27}, Key{Code: KeyEscape}},
+		{[]byte("\033[A"), Key{Code: KeyUp}},
+		{[]byte("\033OB"), Key{Code: KeyDown}},
+		{[]byte("\033[6~"), Key{Code: KeyPageDown}},
+		{[]byte{13}, Key{Code: KeyEnter}},
+		{[]byte{127}, Key{Code: KeyBackspace}},
+		{[]byte{3}, Key{Code: KeyInterrupt}},
+		{[]byte("pk"), Key{Runes: []rune("pk")}},
+		{[]byte("ñ"), Key{Runes: []rune("ñ")}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseKey(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseKey(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
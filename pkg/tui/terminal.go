@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrNoTTY is returned when no controlling terminal is available
+var ErrNoTTY = errors.New("no terminal available")
+
+// Key codes returned by ReadKey
+const (
+	KeyNone = iota // Printable input, see Key.Runes
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyEnter
+	KeyEscape
+	KeyInterrupt // ctrl-c, ctrl-g
+	KeyBackspace
+	KeyClearLine  // ctrl-u
+	KeyDeleteWord // ctrl-w
+)
+
+// Key is a decoded keypress
+type Key struct {
+	Code  int
+	Runes []rune // Typed characters when Code is KeyNone
+}
+
+// Terminal is the controlling terminal in raw mode on the alternate screen
+type Terminal struct {
+	tty   *os.File
+	fd    int
+	state *term.State
+}
+
+// Open switches /dev/tty to raw mode and enters the alternate screen
+func Open() (*Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, ErrNoTTY
+	}
+
+	t := &Terminal{tty: tty, fd: int(tty.Fd())}
+	if err := t.Resume(); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Close restores the terminal and releases it
+func (t *Terminal) Close() {
+	t.Suspend()
+	t.tty.Close()
+}
+
+// Suspend restores cooked mode and the main screen (e.g. to run an editor)
+func (t *Terminal) Suspend() {
+	if t.state == nil {
+		return
+	}
+	fmt.Fprint(t.tty, "\033[?25h\033[?1049l")
+	term.Restore(t.fd, t.state)
+	t.state = nil
+}
+
+// Resume re-enters raw mode on the alternate screen with a hidden cursor
+func (t *Terminal) Resume() error {
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	t.state = state
+	fmt.Fprint(t.tty, "\033[?1049h\033[?25l")
+	return nil
+}
+
+// Size returns the terminal width and height (80x24 when unknown)
+func (t *Terminal) Size() (int, int) {
+	width, height, err := term.GetSize(t.fd)
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Draw writes a rendered frame
+func (t *Terminal) Draw(frame string) {
+	fmt.Fprint(t.tty, frame)
+}
+
+// ReadKey blocks until input arrives and decodes it
+func (t *Terminal) ReadKey() (Key, error) {
+	buf := make([]byte, 64)
+	n, err := t.tty.Read(buf)
+	if err != nil {
+		return Key{}, err
+	}
+	return ParseKey(buf[:n]), nil
+}
+
+// ParseKey maps raw terminal input to a key
+func ParseKey(b []byte) Key {
+	if len(b) == 0 {
+		return Key{}
+	}
+
+	if b[0] == 27 {
+		if len(b) == 1 {
+			return Key{Code: KeyEscape}
+		}
+		// CSI / SS3 sequences: arrows and page keys
+		switch string(b[1:]) {
+		case "[A", "OA":
+			return Key{Code: KeyUp}
+		case "[B", "OB":
+			return Key{Code: KeyDown}
+		case "[5~":
+			return Key{Code: KeyPageUp}
+		case "[6~":
+			return Key{Code: KeyPageDown}
+		}
+		return Key{}
+	}
+
+	switch b[0] {
+	case 3, 7: // ctrl-c, ctrl-g
+		return Key{Code: KeyInterrupt}
+	case 13: // enter
+		return Key{Code: KeyEnter}
+	case 127, 8: // backspace
+		return Key{Code: KeyBackspace}
+	case 21: // ctrl-u
+		return Key{Code: KeyClearLine}
+	case 23: // ctrl-w
+		return Key{Code: KeyDeleteWord}
+	case 11, 16: // ctrl-k, ctrl-p
+		return Key{Code: KeyUp}
+	case 10, 14: // ctrl-j, ctrl-n
+		return Key{Code: KeyDown}
+	}
+
+	var runes []rune
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r != utf8.RuneError && unicode.IsPrint(r) {
+			runes = append(runes, r)
+		}
+		b = b[size:]
+	}
+	return Key{Runes: runes}
+}
@@ -0,0 +1,264 @@
+package worklog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/activity"
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// DateFormat is the layout of Entry.Date
+const DateFormat = "2006-01-02"
+
+// Entry is one manually logged piece of work
+type Entry struct {
+	ProjectID string    `json:"project_id"`
+	Date      string    `json:"date"` // Day the work happened (YYYY-MM-DD)
+	Minutes   int       `json:"minutes"`
+	Note      string    `json:"note"`
+	Tags      []string  `json:"tags,omitempty"`
+	LoggedAt  time.Time `json:"logged_at"`
+}
+
+// Duration returns the logged time
+func (e Entry) Duration() time.Duration {
+	return time.Duration(e.Minutes) * time.Minute
+}
+
+// GetLogDir returns the directory holding per-project work logs
+func GetLogDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	logDir := filepath.Join(homeDir, ".local", "share", "pk", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return "", err
+	}
+
+	return logDir, nil
+}
+
+// ProjectLogFile returns the in-project work log path (<project>/.dev/worklog.jsonl)
+func ProjectLogFile(project *config.Project) string {
+	return filepath.Join(project.Path, ".dev", "worklog.jsonl")
+}
+
+// LogFile returns where new entries for a project are written
+// The in-project log wins once it exists; otherwise entries go to
+// ~/.local/share/pk/logs/<id>.jsonl ("/" in subproject IDs escaped as %2F).
+func LogFile(project *config.Project) (string, error) {
+	if project.Path != "" {
+		if _, err := os.Stat(ProjectLogFile(project)); err == nil {
+			return ProjectLogFile(project), nil
+		}
+	}
+
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(logDir, config.FileSafeID(project.ProjectInfo.ID)+".jsonl"), nil
+}
+
+// Append writes an entry to a log file, creating it as needed
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load returns a project's entries from both log locations, oldest day first
+func Load(project *config.Project) ([]Entry, error) {
+	var paths []string
+	if logDir, err := GetLogDir(); err == nil {
+		paths = append(paths, filepath.Join(logDir, config.FileSafeID(project.ProjectInfo.ID)+".jsonl"))
+	}
+	if project.Path != "" {
+		paths = append(paths, ProjectLogFile(project))
+	}
+
+	var entries []Entry
+	for _, path := range paths {
+		fileEntries, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range fileEntries {
+			// Logs copied between projects still belong to this one
+			entry.ProjectID = project.ProjectInfo.ID
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		return entries[i].LoggedAt.Before(entries[j].LoggedAt)
+	})
+	return entries, nil
+}
+
+// readFile parses a JSON-lines log, skipping malformed lines
+func readFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Minutes > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// ParseDuration reads a work duration: "2h", "90m", "1h30m", "1.5h", "1:30"
+// A bare number is taken as hours.
+func ParseDuration(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	invalid := fmt.Errorf("invalid duration '%s' (use e.g. 2h, 45m, 1h30m, 1.5h or 1:30)", s)
+
+	if hours, minutes, ok := strings.Cut(s, ":"); ok {
+		h, errH := strconv.Atoi(hours)
+		m, errM := strconv.Atoi(minutes)
+		if errH != nil || errM != nil || h < 0 || m < 0 || m >= 60 || h*60+m == 0 {
+			return 0, invalid
+		}
+		return h*60 + m, nil
+	}
+
+	if hours, err := strconv.ParseFloat(s, 64); err == nil {
+		s += "h"
+		if hours <= 0 {
+			return 0, invalid
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return 0, invalid
+	}
+	return int(d.Round(time.Minute).Minutes()), nil
+}
+
+// ParseEntry splits "2h reviewed PR #review" into minutes, note and tags
+// The first word must be a duration; words starting with # become tags.
+func ParseEntry(text string) (int, string, []string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, "", nil, fmt.Errorf("nothing to log: start with a duration, e.g. \"2h reviewed PR\"")
+	}
+
+	minutes, err := ParseDuration(fields[0])
+	if err != nil {
+		return 0, "", nil, err
+	}
+
+	var tags []string
+	for _, word := range fields[1:] {
+		if tag := strings.TrimLeft(word, "#"); strings.HasPrefix(word, "#") && tag != "" {
+			tags = appendTag(tags, tag)
+		}
+	}
+
+	return minutes, strings.Join(fields[1:], " "), tags, nil
+}
+
+// appendTag adds a lowercase tag once
+func appendTag(tags []string, tag string) []string {
+	tag = strings.ToLower(tag)
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// MergeTags adds extra tags (e.g. from --tag) to parsed ones
+func MergeTags(tags []string, extra []string) []string {
+	for _, tag := range extra {
+		if tag = strings.TrimLeft(tag, "#"); tag != "" {
+			tags = appendTag(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasTag reports whether an entry carries tag (case-insensitive)
+func (e Entry) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimLeft(tag, "#"))
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Intervals places entries on the timeline as if worked from local midnight
+// of their day, so reports can treat logged time like tracked time.
+func Intervals(entries []Entry) []activity.Interval {
+	var intervals []activity.Interval
+	for _, entry := range entries {
+		day, err := time.ParseInLocation(DateFormat, entry.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		intervals = append(intervals, activity.Interval{
+			ProjectID: entry.ProjectID,
+			Start:     day,
+			End:       day.Add(entry.Duration()),
+		})
+	}
+	return intervals
+}
+
+// ParseDate accepts YYYY-MM-DD, "today" or "yesterday"
+func ParseDate(value string, now time.Time) (string, error) {
+	switch strings.ToLower(value) {
+	case "", "today":
+		return now.Format(DateFormat), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format(DateFormat), nil
+	}
+
+	day, err := time.ParseInLocation(DateFormat, value, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid date '%s' (use YYYY-MM-DD, today or yesterday)", value)
+	}
+	return day.Format(DateFormat), nil
+}
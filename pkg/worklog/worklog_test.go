@@ -0,0 +1,162 @@
+package worklog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		minutes int
+		wantErr bool
+	}{
+		{"2h", 120, false},
+		{"45m", 45, false},
+		{"1h30m", 90, false},
+		{"1.5h", 90, false},
+		{"1:30", 90, false},
+		{"0:45", 45, false},
+		{"2", 120, false},
+		{"0.25", 15, false},
+		{"2H", 120, false},
+		{"0", 0, true},
+		{"30s", 0, true},
+		{"-1h", 0, true},
+		{"1:75", 0, true},
+		{"reviewed", 0, true},
+	}
+
+	for _, tt := range tests {
+		minutes, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if minutes != tt.minutes {
+			t.Errorf("ParseDuration(%q) = %d, want %d", tt.input, minutes, tt.minutes)
+		}
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		input   string
+		minutes int
+		note    string
+		tags    []string
+		wantErr bool
+	}{
+		{"2h reviewed PR", 120, "reviewed PR", nil, false},
+		{"30m #Meeting standup #meeting #sync", 30, "#Meeting standup #meeting #sync", []string{"meeting", "sync"}, false},
+		{"1h", 60, "", nil, false},
+		{"reviewed PR 2h", 0, "", nil, true},
+		{"  ", 0, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		minutes, note, tags, err := ParseEntry(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEntry(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if minutes != tt.minutes || note != tt.note || strings.Join(tags, ",") != strings.Join(tt.tags, ",") {
+			t.Errorf("ParseEntry(%q) = %d, %q, %v; want %d, %q, %v", tt.input, minutes, note, tags, tt.minutes, tt.note, tt.tags)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2026, 9, 1, 10, 0, 0, 0, time.Local)
+
+	for input, expected := range map[string]string{
+		"":           "2026-09-01",
+		"today":      "2026-09-01",
+		"yesterday":  "2026-08-31",
+		"2026-08-15": "2026-08-15",
+	} {
+		if got, err := ParseDate(input, now); err != nil || got != expected {
+			t.Errorf("ParseDate(%q) = %q, %v; want %q", input, got, err, expected)
+		}
+	}
+
+	if _, err := ParseDate("15/08/2026", now); err == nil {
+		t.Error("ParseDate accepted a non-ISO date")
+	}
+}
+
+func TestLogLocations(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	project := &config.Project{Path: filepath.Join(tmpDir, "projects", "api")}
+	project.ProjectInfo.ID = "api"
+
+	// Without .dev/worklog.jsonl entries go to the shared log directory
+	shared, err := LogFile(project)
+	if err != nil {
+		t.Fatalf("LogFile: %v", err)
+	}
+	if !strings.HasSuffix(shared, filepath.Join(".local", "share", "pk", "logs", "api.jsonl")) {
+		t.Errorf("LogFile = %s, want the shared log", shared)
+	}
+	if err := Append(shared, Entry{Date: "2026-09-02", Minutes: 60, Note: "shared"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// Once the in-project log exists it receives new entries
+	if err := Append(ProjectLogFile(project), Entry{Date: "2026-09-01", Minutes: 30, Note: "in project"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if inProject, _ := LogFile(project); inProject != ProjectLogFile(project) {
+		t.Errorf("LogFile = %s, want %s", inProject, ProjectLogFile(project))
+	}
+
+	// Garbage lines are skipped
+	f, _ := os.OpenFile(shared, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("not json\n")
+	f.Close()
+
+	entries, err := Load(project)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Note != "in project" || entries[1].Note != "shared" {
+		t.Fatalf("Load = %+v, want both entries by date", entries)
+	}
+	if entries[0].ProjectID != "api" {
+		t.Errorf("ProjectID = %q, want api", entries[0].ProjectID)
+	}
+
+	intervals := Intervals(entries)
+	if len(intervals) != 2 || intervals[1].Duration() != time.Hour ||
+		intervals[1].Start.Format(DateFormat) != "2026-09-02" || intervals[1].Start.Hour() != 0 {
+		t.Errorf("Intervals = %+v", intervals)
+	}
+}
+
+func TestSubprojectLogFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	project := &config.Project{Parent: "dk"}
+	project.ProjectInfo.ID = config.SubprojectID("dk", "dojo")
+
+	path, err := LogFile(project)
+	if err != nil {
+		t.Fatalf("LogFile: %v", err)
+	}
+	if filepath.Base(path) != "dk%2Fdojo.jsonl" {
+		t.Errorf("LogFile = %s, want the escaped ID as file name", path)
+	}
+	if err := Append(path, Entry{Date: "2026-09-02", Minutes: 15}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if entries, err := Load(project); err != nil || len(entries) != 1 {
+		t.Errorf("Load = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,247 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+// Actions reported by Apply
+const (
+	ActionClone    = "clone"    // Missing project cloned
+	ActionRecreate = "recreate" // .project.toml written from the workspace
+	ActionPin      = "pin"      // Pin restored
+	ActionDrift    = "drift"    // Differs from the workspace, left alone
+	ActionMissing  = "missing"  // Missing and can't be cloned
+	ActionExtra    = "extra"    // On this machine but not in the workspace
+)
+
+// Options control Apply
+type Options struct {
+	Mirrors []string // Directories holding bare mirrors, tried before the repository URL
+	DryRun  bool     // Only report what would change
+}
+
+// Result is one thing Apply did, would do, or found
+type Result struct {
+	Project string
+	Action  string
+	Detail  string
+	Err     error
+}
+
+// Apply makes this machine match ws: missing repositories are cloned,
+// absent .project.toml files recreated and pins restored. Projects that
+// exist but differ (location, remote, branch) are reported as drift and
+// never changed. existing are the projects found under roots.
+func Apply(ws *Workspace, roots Roots, existing []*config.Project, opts Options) []Result {
+	var results []Result
+	byID := make(map[string]*config.Project)
+	for _, p := range existing {
+		byID[strings.ToLower(p.ProjectInfo.ID)] = p
+	}
+
+	// Where each workspace project ends up, for pins
+	located := make(map[string]string)
+	listed := make(map[string]bool)
+
+	for _, wp := range ws.Projects {
+		listed[strings.ToLower(wp.ID)] = true
+		target := roots.Target(wp)
+
+		if found := byID[strings.ToLower(wp.ID)]; found != nil {
+			located[strings.ToLower(wp.ID)] = found.Path
+			if found.Path != target {
+				results = append(results, Result{Project: wp.ID, Action: ActionDrift,
+					Detail: fmt.Sprintf("is at %s, workspace has %s", found.Path, target)})
+			}
+			results = append(results, checkRepo(wp, found.Path)...)
+			continue
+		}
+
+		if _, err := os.Stat(target); err == nil {
+			if other, err := config.LoadProject(filepath.Join(target, ".project.toml")); err == nil {
+				results = append(results, Result{Project: wp.ID, Action: ActionDrift,
+					Detail: fmt.Sprintf("%s holds project '%s'", target, other.ProjectInfo.ID)})
+				continue
+			}
+
+			// A directory without a (readable) .project.toml
+			located[strings.ToLower(wp.ID)] = target
+			results = append(results, checkRepo(wp, target)...)
+			results = append(results, recreate(wp, target, opts)...)
+			continue
+		}
+
+		source := mirrorFor(wp, opts.Mirrors)
+		if source == "" {
+			source = wp.Repository
+		}
+		if source == "" {
+			results = append(results, Result{Project: wp.ID, Action: ActionMissing,
+				Detail: fmt.Sprintf("%s doesn't exist and has no repository to clone", target)})
+			continue
+		}
+
+		result := Result{Project: wp.ID, Action: ActionClone, Detail: fmt.Sprintf("%s → %s", source, target)}
+		if !opts.DryRun {
+			result.Err = clone(wp, source, target)
+		}
+		results = append(results, result)
+		if result.Err != nil {
+			continue
+		}
+
+		located[strings.ToLower(wp.ID)] = target
+		results = append(results, recreate(wp, target, opts)...)
+	}
+
+	for _, p := range existing {
+		if !listed[strings.ToLower(p.ProjectInfo.ID)] {
+			results = append(results, Result{Project: p.ProjectInfo.ID, Action: ActionExtra,
+				Detail: fmt.Sprintf("%s is not in the workspace", p.Path)})
+		}
+	}
+
+	return append(results, applyPins(ws.Pins, located, opts)...)
+}
+
+// checkRepo reports where the checkout at dir differs from wp
+func checkRepo(wp Project, dir string) []Result {
+	if !isRepo(dir) {
+		return nil
+	}
+
+	var results []Result
+	if remote := git(dir, "remote", "get-url", "origin"); wp.Repository != "" && remote != wp.Repository {
+		results = append(results, Result{Project: wp.ID, Action: ActionDrift,
+			Detail: fmt.Sprintf("origin is %s, workspace has %s", orNone(remote), wp.Repository)})
+	}
+	if branch := git(dir, "branch", "--show-current"); wp.Branch != "" && branch != wp.Branch {
+		results = append(results, Result{Project: wp.ID, Action: ActionDrift,
+			Detail: fmt.Sprintf("on branch %s, workspace has %s", orNone(branch), wp.Branch)})
+	}
+	return results
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// recreate writes .project.toml into target when it's absent
+func recreate(wp Project, target string, opts Options) []Result {
+	tomlPath := filepath.Join(target, ".project.toml")
+	if _, err := os.Stat(tomlPath); err == nil {
+		return nil
+	}
+
+	result := Result{Project: wp.ID, Action: ActionRecreate, Detail: tomlPath}
+	if !opts.DryRun {
+		result.Err = writeMetadata(wp, target)
+	}
+	return []Result{result}
+}
+
+// writeMetadata writes the exported .project.toml, or a minimal one when
+// the workspace has none
+func writeMetadata(wp Project, target string) error {
+	if wp.Metadata != "" {
+		return os.WriteFile(filepath.Join(target, ".project.toml"), []byte(wp.Metadata), 0644)
+	}
+
+	p := &config.Project{Path: target}
+	p.ProjectInfo.ID = wp.ID
+	p.ProjectInfo.Name = wp.ID
+	p.ProjectInfo.Status = "active"
+	p.Links.Repository = wp.Repository
+	if wp.Root == RootArchive {
+		p.ProjectInfo.Status = "archived"
+	}
+	return config.SaveProject(p)
+}
+
+// mirrorFor returns a local bare mirror of wp's repository, or ""
+// Mirrors are looked up as <id>.git, <id> and <repository name>.git.
+func mirrorFor(wp Project, mirrors []string) string {
+	names := []string{wp.ID + ".git", wp.ID}
+	if wp.Repository != "" {
+		base := strings.TrimSuffix(filepath.Base(filepath.FromSlash(wp.Repository)), ".git")
+		if i := strings.LastIndex(base, ":"); i >= 0 {
+			base = base[i+1:]
+		}
+		names = append(names, base+".git")
+	}
+
+	for _, dir := range mirrors {
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// clone checks out source at target on wp's branch
+// A clone from a mirror gets wp's repository as its origin.
+func clone(wp Project, source, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--quiet"}
+	if wp.Branch != "" {
+		args = append(args, "--branch", wp.Branch)
+	}
+	if out, err := exec.Command("git", append(args, source, target)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	if wp.Repository != "" && source != wp.Repository {
+		if out, err := exec.Command("git", "-C", target, "remote", "set-url", "origin", wp.Repository).CombinedOutput(); err != nil {
+			return fmt.Errorf("cloned, but setting origin failed: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// applyPins restores pins whose slot holds something else
+func applyPins(pins []Pin, located map[string]string, opts Options) []Result {
+	var results []Result
+	current, err := cache.LoadPins()
+	if err != nil {
+		return []Result{{Action: ActionPin, Err: fmt.Errorf("failed to load pins: %w", err)}}
+	}
+
+	for _, pin := range pins {
+		if strings.EqualFold(current[pin.Slot].ProjectID, pin.Project) {
+			continue
+		}
+
+		path, ok := located[strings.ToLower(pin.Project)]
+		if !ok {
+			results = append(results, Result{Project: pin.Project, Action: ActionMissing,
+				Detail: fmt.Sprintf("pin slot %d not restored, the project isn't available", pin.Slot)})
+			continue
+		}
+
+		result := Result{Project: pin.Project, Action: ActionPin, Detail: fmt.Sprintf("slot %d", pin.Slot)}
+		if previous := current[pin.Slot].ProjectID; previous != "" {
+			result.Detail += fmt.Sprintf(" (was %s)", previous)
+		}
+		if !opts.DryRun {
+			result.Err = cache.AddPin(pin.Slot, pin.Project, path)
+		}
+		results = append(results, result)
+	}
+	return results
+}
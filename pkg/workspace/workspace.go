@@ -0,0 +1,248 @@
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+	"github.com/datakaicr/pk/pkg/paths"
+)
+
+// Root names used in workspace files
+// Paths are stored relative to a root so a workspace applies on machines
+// with a different home directory or [paths] configuration.
+const (
+	RootProjects    = "projects"
+	RootArchive     = "archive"
+	RootScriptorium = "scriptorium"
+)
+
+// rootOrder is the order roots are exported in
+var rootOrder = []string{RootProjects, RootArchive, RootScriptorium}
+
+// Workspace describes the projects and pins of one machine
+type Workspace struct {
+	Projects []Project `toml:"project"`
+	Pins     []Pin     `toml:"pin"`
+}
+
+// Project is one project in a workspace file
+type Project struct {
+	ID         string `toml:"id"`
+	Root       string `toml:"root"` // projects, archive or scriptorium
+	Path       string `toml:"path"` // Relative to the root, with forward slashes
+	Repository string `toml:"repository,omitempty"`
+	Branch     string `toml:"branch,omitempty"`
+	Metadata   string `toml:"metadata,omitempty"` // .project.toml, recreated when absent
+}
+
+// Pin is a pinned slot in a workspace file
+type Pin struct {
+	Slot    int    `toml:"slot"`
+	Project string `toml:"project"`
+}
+
+// Roots maps root names to directories on this machine
+type Roots map[string]string
+
+// ResolverRoots returns the roots configured for the path resolver
+func ResolverRoots(r *paths.Resolver) Roots {
+	return Roots{
+		RootProjects:    r.Projects(),
+		RootArchive:     r.Archive(),
+		RootScriptorium: r.Scriptorium(),
+	}
+}
+
+// Locate returns the root name and relative path of dir
+func (roots Roots) Locate(dir string) (string, string, bool) {
+	for _, name := range rootOrder {
+		rel, err := filepath.Rel(roots[name], dir)
+		if roots[name] == "" || err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return name, filepath.ToSlash(rel), true
+	}
+	return "", "", false
+}
+
+// Target returns where a workspace project lives on this machine
+func (roots Roots) Target(p Project) string {
+	return filepath.Join(roots[p.Root], filepath.FromSlash(p.Path))
+}
+
+// Export describes projects and pins as a workspace
+// Projects outside the roots (and scratch pseudo-projects) are skipped.
+func Export(projects []*config.Project, roots Roots, pins []cache.PinRecord) *Workspace {
+	ws := &Workspace{}
+	exported := make(map[string]bool)
+
+	for _, p := range projects {
+		root, rel, ok := roots.Locate(p.Path)
+		if !ok || p.IsSubproject() || p.ProjectInfo.Status == "scratch" {
+			continue
+		}
+
+		entry := Project{ID: p.ProjectInfo.ID, Root: root, Path: rel}
+		if isRepo(p.Path) {
+			entry.Repository = git(p.Path, "remote", "get-url", "origin")
+			entry.Branch = git(p.Path, "branch", "--show-current")
+		}
+		if entry.Repository == "" {
+			entry.Repository = p.Links.Repository
+		}
+		if data, err := os.ReadFile(filepath.Join(p.Path, ".project.toml")); err == nil {
+			entry.Metadata = string(data)
+		}
+
+		ws.Projects = append(ws.Projects, entry)
+		exported[strings.ToLower(entry.ID)] = true
+	}
+
+	sort.SliceStable(ws.Projects, func(i, j int) bool {
+		a, b := ws.Projects[i], ws.Projects[j]
+		if a.Root != b.Root {
+			return rootIndex(a.Root) < rootIndex(b.Root)
+		}
+		return a.Path < b.Path
+	})
+
+	for _, pin := range pins {
+		if exported[strings.ToLower(pin.ProjectID)] {
+			ws.Pins = append(ws.Pins, Pin{Slot: pin.Slot, Project: pin.ProjectID})
+		}
+	}
+
+	return ws
+}
+
+func rootIndex(root string) int {
+	for i, name := range rootOrder {
+		if name == root {
+			return i
+		}
+	}
+	return len(rootOrder)
+}
+
+// Write encodes ws as TOML with a short header
+// It's written by hand so metadata stays readable as a multi-line string.
+func Write(w io.Writer, ws *Workspace) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# pk workspace, exported %s\n", time.Now().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "# Recreate with: pk workspace apply <this file>\n")
+
+	for _, p := range ws.Projects {
+		fmt.Fprintf(&b, "\n[[project]]\n")
+		fmt.Fprintf(&b, "id = %s\n", quote(p.ID))
+		fmt.Fprintf(&b, "root = %s\n", quote(p.Root))
+		fmt.Fprintf(&b, "path = %s\n", quote(p.Path))
+		if p.Repository != "" {
+			fmt.Fprintf(&b, "repository = %s\n", quote(p.Repository))
+		}
+		if p.Branch != "" {
+			fmt.Fprintf(&b, "branch = %s\n", quote(p.Branch))
+		}
+		if p.Metadata != "" {
+			fmt.Fprintf(&b, "metadata = %s\n", quoteMultiline(p.Metadata))
+		}
+	}
+
+	for _, pin := range ws.Pins {
+		fmt.Fprintf(&b, "\n[[pin]]\nslot = %d\nproject = %s\n", pin.Slot, quote(pin.Project))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote returns s as a TOML basic string
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteMultiline returns s as a TOML multi-line literal string when it can
+// be written verbatim, and as a basic string otherwise
+func quoteMultiline(s string) string {
+	verbatim := !strings.Contains(s, "'''") && !strings.HasSuffix(s, "'")
+	for _, r := range s {
+		if (r < 0x20 && r != '\n' && r != '\t') || r == 0x7f {
+			verbatim = false
+		}
+	}
+	if !verbatim {
+		return quote(s)
+	}
+	return "'''\n" + s + "'''"
+}
+
+// Load reads and validates a workspace file
+func Load(path string) (*Workspace, error) {
+	var ws Workspace
+	if _, err := toml.DecodeFile(path, &ws); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range ws.Projects {
+		switch {
+		case p.ID == "":
+			return nil, fmt.Errorf("project at '%s' has no id", p.Path)
+		case seen[strings.ToLower(p.ID)]:
+			return nil, fmt.Errorf("project '%s' is listed twice", p.ID)
+		case rootIndex(p.Root) == len(rootOrder):
+			return nil, fmt.Errorf("project '%s' has unknown root '%s' (use %s)", p.ID, p.Root, strings.Join(rootOrder, ", "))
+		case p.Path == "" || filepath.IsAbs(p.Path) || strings.HasPrefix(filepath.Clean(filepath.FromSlash(p.Path)), ".."):
+			return nil, fmt.Errorf("project '%s' path '%s' must be relative to its root", p.ID, p.Path)
+		}
+		seen[strings.ToLower(p.ID)] = true
+	}
+	for _, pin := range ws.Pins {
+		if pin.Slot < 1 || pin.Slot > 5 {
+			return nil, fmt.Errorf("pin slot %d for '%s' must be between 1 and 5", pin.Slot, pin.Project)
+		}
+	}
+
+	return &ws, nil
+}
+
+// isRepo reports whether dir is the top of a git checkout, not just inside one
+func isRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// git runs a git command in dir and returns its trimmed output, or "" on error
+func git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,247 @@
+package workspace
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datakaicr/pk/pkg/cache"
+	"github.com/datakaicr/pk/pkg/config"
+)
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeToml(t *testing.T, dir, id string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "# hand-written\n[project]\nname = \"" + id + "\"\nid = \"" + id + "\"\nstatus = \"active\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".project.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testRoots(base string) Roots {
+	return Roots{
+		RootProjects:    filepath.Join(base, "projects"),
+		RootArchive:     filepath.Join(base, "archive"),
+		RootScriptorium: filepath.Join(base, "scriptorium"),
+	}
+}
+
+// setupMachine creates a machine with a cloned project "app" (its
+// .project.toml untracked), a plain archived project "notes" and app pinned
+// to slot 1. It returns the roots and the file:// remote of app.
+func setupMachine(t *testing.T, tmpDir string) (Roots, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "pk@example.com")
+	}
+
+	remote := filepath.Join(tmpDir, "remotes", "app.git")
+	run(t, tmpDir, "git", "init", "--quiet", "--bare", "--initial-branch=main", remote)
+	seed := filepath.Join(tmpDir, "seed")
+	run(t, tmpDir, "git", "clone", "--quiet", "file://"+remote, seed)
+	os.WriteFile(filepath.Join(seed, "README.md"), []byte("app\n"), 0644)
+	run(t, seed, "git", "add", "README.md")
+	run(t, seed, "git", "commit", "--quiet", "-m", "Initial commit")
+	run(t, seed, "git", "push", "--quiet", "origin", "HEAD:main")
+
+	roots := testRoots(filepath.Join(tmpDir, "a"))
+	app := filepath.Join(roots[RootProjects], "work", "app")
+	run(t, tmpDir, "git", "clone", "--quiet", "file://"+remote, app)
+	writeToml(t, app, "app")
+	writeToml(t, filepath.Join(roots[RootArchive], "notes"), "notes")
+
+	if err := cache.AddPin(1, "app", app); err != nil {
+		t.Fatal(err)
+	}
+	return roots, "file://" + remote
+}
+
+func findAll(t *testing.T, roots Roots) []*config.Project {
+	t.Helper()
+	projects, err := config.FindProjects(roots[RootProjects], roots[RootArchive], roots[RootScriptorium])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return projects
+}
+
+func summarize(results []Result) string {
+	var lines []string
+	for _, r := range results {
+		line := r.Action + " " + r.Project
+		if r.Err != nil {
+			line += " error"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, ", ")
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	roots, remote := setupMachine(t, tmpDir)
+
+	pins, _ := cache.ListPins()
+	ws := Export(findAll(t, roots), roots, pins)
+
+	if len(ws.Projects) != 2 {
+		t.Fatalf("exported %d projects, want 2", len(ws.Projects))
+	}
+	app, notes := ws.Projects[0], ws.Projects[1]
+	if app.ID != "app" || app.Root != RootProjects || app.Path != "work/app" ||
+		app.Repository != remote || app.Branch != "main" || !strings.Contains(app.Metadata, "# hand-written") {
+		t.Errorf("app = %+v", app)
+	}
+	if notes.Root != RootArchive || notes.Path != "notes" || notes.Repository != "" || notes.Branch != "" {
+		t.Errorf("notes = %+v", notes)
+	}
+	if len(ws.Pins) != 1 || ws.Pins[0] != (Pin{Slot: 1, Project: "app"}) {
+		t.Errorf("pins = %+v", ws.Pins)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, ws); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tmpDir, "workspace.toml")
+	os.WriteFile(path, buf.Bytes(), 0644)
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded.Projects) != 2 || loaded.Projects[0] != app || loaded.Pins[0] != ws.Pins[0] {
+		t.Errorf("loaded = %+v", loaded)
+	}
+}
+
+func TestLoadRejectsBadEntries(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"[[project]]\nroot = \"projects\"\npath = \"a\"\n", "has no id"},
+		{"[[project]]\nid = \"a\"\nroot = \"home\"\npath = \"a\"\n", "unknown root"},
+		{"[[project]]\nid = \"a\"\nroot = \"projects\"\npath = \"../a\"\n", "must be relative"},
+		{"[[project]]\nid = \"a\"\nroot = \"projects\"\npath = \"a\"\n[[project]]\nid = \"A\"\nroot = \"archive\"\npath = \"a\"\n", "listed twice"},
+		{"[[pin]]\nslot = 9\nproject = \"a\"\n", "between 1 and 5"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "workspace.toml")
+		os.WriteFile(path, []byte(tt.content), 0644)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) = %v, want error containing %q", tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	roots, remote := setupMachine(t, tmpDir)
+	pins, _ := cache.ListPins()
+	ws := Export(findAll(t, roots), roots, pins)
+
+	// A new machine with nothing on it
+	cache.ClearPins()
+	fresh := testRoots(filepath.Join(tmpDir, "b"))
+
+	if got := summarize(Apply(ws, fresh, nil, Options{DryRun: true})); got != "clone app, recreate app, missing notes, pin app" {
+		t.Errorf("dry run = %s", got)
+	}
+	if _, err := os.Stat(fresh[RootProjects]); !os.IsNotExist(err) {
+		t.Error("dry run should not create anything")
+	}
+
+	if got := summarize(Apply(ws, fresh, nil, Options{})); got != "clone app, recreate app, missing notes, pin app" {
+		t.Errorf("apply = %s", got)
+	}
+	app := filepath.Join(fresh[RootProjects], "work", "app")
+	if data, err := os.ReadFile(filepath.Join(app, ".project.toml")); err != nil || string(data) != ws.Projects[0].Metadata {
+		t.Errorf(".project.toml = %q, %v; want the exported metadata", data, err)
+	}
+	if origin := run(t, app, "git", "remote", "get-url", "origin"); origin != remote {
+		t.Errorf("origin = %s, want %s", origin, remote)
+	}
+	if pin, err := cache.GetPin(1); err != nil || pin.ProjectPath != app {
+		t.Errorf("pin 1 = %+v, %v", pin, err)
+	}
+
+	// Applying again only reports drift
+	run(t, app, "git", "checkout", "--quiet", "-b", "feature")
+	writeToml(t, filepath.Join(fresh[RootProjects], "other"), "other")
+	if got := summarize(Apply(ws, fresh, findAll(t, fresh), Options{})); got != "drift app, missing notes, extra other" {
+		t.Errorf("second apply = %s", got)
+	}
+}
+
+func TestApplyFromMirror(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	roots, remote := setupMachine(t, tmpDir)
+	ws := Export(findAll(t, roots), roots, nil)
+
+	mirrors := filepath.Join(tmpDir, "mirrors")
+	run(t, tmpDir, "git", "clone", "--quiet", "--bare", remote, filepath.Join(mirrors, "app.git"))
+
+	// The URL is unreachable, so only the mirror can provide the clone
+	ws.Projects[0].Repository = "file://" + filepath.Join(tmpDir, "gone", "app.git")
+	fresh := testRoots(filepath.Join(tmpDir, "b"))
+
+	results := Apply(ws, fresh, nil, Options{Mirrors: []string{mirrors}})
+	if results[0].Action != ActionClone || results[0].Err != nil || !strings.HasPrefix(results[0].Detail, filepath.Join(mirrors, "app.git")) {
+		t.Fatalf("clone result = %+v", results[0])
+	}
+	app := filepath.Join(fresh[RootProjects], "work", "app")
+	if origin := run(t, app, "git", "remote", "get-url", "origin"); origin != ws.Projects[0].Repository {
+		t.Errorf("origin = %s, want the workspace repository", origin)
+	}
+}
+
+func TestWriteQuoting(t *testing.T) {
+	metadata := []string{
+		"[project]\nid = \"a\"\n",
+		"notes = '''\nraw\n'''\n",
+		"ends with a quote '",
+		"tab\there, bell\a, backslash \\ and \"quotes\"",
+	}
+
+	for _, m := range metadata {
+		ws := &Workspace{Projects: []Project{{ID: `a "b"`, Root: RootProjects, Path: "a", Metadata: m}}}
+		var buf bytes.Buffer
+		if err := Write(&buf, ws); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "workspace.toml")
+		os.WriteFile(path, buf.Bytes(), 0644)
+
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%q): %v\n%s", m, err, buf.String())
+		}
+		if loaded.Projects[0] != ws.Projects[0] {
+			t.Errorf("round trip of %q = %+v", m, loaded.Projects[0])
+		}
+	}
+}